@@ -0,0 +1,191 @@
+package database
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/subtle"
+    "database/sql"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+    "skyflow/internal/models"
+    "github.com/lib/pq"
+)
+
+// Формат ключа: sfk_<prefix>_<secret>. По prefix ключ ищется в базе,
+// secret сверяется с хэшем
+const (
+    apiKeyTag       = "sfk_"
+    apiKeyPrefixLen = 8
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// Не чаще раза в минуту, чтобы не писать в базу на каждый запрос
+const apiKeyUsageResolution = time.Minute
+
+type APIKeyRepository struct {
+    db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+    return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, name, prefix, scopes, COALESCE(airline, ''), COALESCE(created_by, ''),
+    created_at, expires_at, last_used_at, revoked_at`
+
+func scanAPIKey(row rowScanner) (*models.APIKey, string, error) {
+    var (
+        key                              models.APIKey
+        scopes                           []string
+        expiresAt, lastUsedAt, revokedAt sql.NullTime
+        hash                             string
+    )
+    err := row.Scan(
+        &key.ID,
+        &key.Name,
+        &key.Prefix,
+        pq.Array(&scopes),
+        &key.Airline,
+        &key.CreatedBy,
+        &key.CreatedAt,
+        &expiresAt,
+        &lastUsedAt,
+        &revokedAt,
+        &hash,
+    )
+    if err != nil {
+        return nil, "", err
+    }
+    
+    key.Scopes = make([]models.Permission, len(scopes))
+    for i, scope := range scopes {
+        key.Scopes[i] = models.Permission(scope)
+    }
+    key.ExpiresAt = nullTimePtr(expiresAt)
+    key.LastUsedAt = nullTimePtr(lastUsedAt)
+    key.RevokedAt = nullTimePtr(revokedAt)
+    
+    return &key, hash, nil
+}
+
+// Выпуск ключа. Возвращает сам ключ — в базе только хэш
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) (string, error) {
+    prefixBytes := make([]byte, apiKeyPrefixLen/2)
+    if _, err := rand.Read(prefixBytes); err != nil {
+        return "", fmt.Errorf("failed to generate api key: %w", err)
+    }
+    secret, err := RandomToken(32)
+    if err != nil {
+        return "", err
+    }
+    
+    key.ID = generateID()
+    key.Prefix = hex.EncodeToString(prefixBytes)
+    key.CreatedAt = time.Now()
+    
+    scopes := make([]string, len(key.Scopes))
+    for i, scope := range key.Scopes {
+        scopes[i] = string(scope)
+    }
+    
+    _, err = r.db.ExecContext(ctx, `
+        INSERT INTO api_keys (id, name, prefix, key_hash, scopes, airline, created_by, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+    `,
+        key.ID,
+        key.Name,
+        key.Prefix,
+        hashToken(secret),
+        pq.Array(scopes),
+        nullString(key.Airline),
+        nullString(key.CreatedBy),
+        key.CreatedAt,
+        key.ExpiresAt,
+    )
+    if err != nil {
+        return "", fmt.Errorf("failed to create api key: %w", err)
+    }
+    
+    return apiKeyTag + key.Prefix + "_" + secret, nil
+}
+
+// Все ключи, включая отозванные
+func (r *APIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+    rows, err := r.db.QueryContext(ctx, `SELECT `+apiKeyColumns+`, key_hash FROM api_keys ORDER BY created_at DESC`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list api keys: %w", err)
+    }
+    defer rows.Close()
+    
+    keys := []models.APIKey{}
+    for rows.Next() {
+        key, _, err := scanAPIKey(rows)
+        if err != nil {
+            return nil, err
+        }
+        keys = append(keys, *key)
+    }
+    
+    return keys, rows.Err()
+}
+
+// Отзыв ключа. Действует сразу: ключ проверяется по базе на каждом запросе
+func (r *APIKeyRepository) Revoke(ctx context.Context, id string) error {
+    result, err := r.db.ExecContext(ctx,
+        `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1`, id)
+    if err != nil {
+        return fmt.Errorf("failed to revoke api key: %w", err)
+    }
+    
+    if rows, _ := result.RowsAffected(); rows == 0 {
+        return ErrAPIKeyNotFound
+    }
+    return nil
+}
+
+// Проверка ключа из заголовка X-API-Key и отметка об использовании.
+// Неизвестный, отозванный или истекший ключ — nil без ошибки
+func (r *APIKeyRepository) Verify(ctx context.Context, presented string) (*models.APIKey, error) {
+    rest := strings.TrimPrefix(strings.TrimSpace(presented), apiKeyTag)
+    if len(rest) < apiKeyPrefixLen+2 || rest[apiKeyPrefixLen] != '_' {
+        return nil, nil
+    }
+    prefix, secret := rest[:apiKeyPrefixLen], rest[apiKeyPrefixLen+1:]
+    
+    key, hash, err := scanAPIKey(r.db.QueryRowContext(ctx,
+        `SELECT `+apiKeyColumns+`, key_hash FROM api_keys WHERE prefix = $1`, prefix))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to find api key: %w", err)
+    }
+    
+    if subtle.ConstantTimeCompare([]byte(hash), []byte(hashToken(secret))) != 1 {
+        return nil, nil
+    }
+    if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+        return nil, nil
+    }
+    
+    if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyUsageResolution {
+        now := time.Now()
+        if _, err := r.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`, now, key.ID); err != nil {
+            return nil, fmt.Errorf("failed to record api key usage: %w", err)
+        }
+        key.LastUsedAt = &now
+    }
+    
+    return key, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+    if !t.Valid {
+        return nil
+    }
+    return &t.Time
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+    "context"
+    "log"
+    "sync"
+    "time"
+    "skyflow/internal/models"
+)
+
+// Кэш отозванных access-токенов (по jti) поверх таблицы revoked_tokens.
+// Периодически перечитывается, чтобы видеть отзывы с других реплик
+type TokenDenylist struct {
+    repo *TokenRepository
+    
+    mu      sync.RWMutex
+    entries map[string]time.Time
+}
+
+func NewTokenDenylist(repo *TokenRepository) *TokenDenylist {
+    return &TokenDenylist{
+        repo:    repo,
+        entries: make(map[string]time.Time),
+    }
+}
+
+func (d *TokenDenylist) IsRevoked(jti string) bool {
+    d.mu.RLock()
+    defer d.mu.RUnlock()
+    
+    _, ok := d.entries[jti]
+    return ok
+}
+
+// Добавление в локальный кэш (в базу токены уже записаны репозиторием)
+func (d *TokenDenylist) Add(tokens ...models.RevokedToken) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    
+    for _, token := range tokens {
+        d.entries[token.JTI] = token.ExpiresAt
+    }
+}
+
+// Отзыв одного access-токена: в базу и в кэш
+func (d *TokenDenylist) Revoke(ctx context.Context, token models.RevokedToken) error {
+    if err := d.repo.RevokeAccessToken(ctx, token); err != nil {
+        return err
+    }
+    d.Add(token)
+    return nil
+}
+
+// Перечитывает список из базы
+func (d *TokenDenylist) Reload(ctx context.Context) error {
+    tokens, err := d.repo.ListRevoked(ctx)
+    if err != nil {
+        return err
+    }
+    
+    entries := make(map[string]time.Time, len(tokens))
+    for _, token := range tokens {
+        entries[token.JTI] = token.ExpiresAt
+    }
+    
+    d.mu.Lock()
+    d.entries = entries
+    d.mu.Unlock()
+    return nil
+}
+
+// Обновляет кэш каждые interval и чистит истекшие токены, до отмены ctx
+func (d *TokenDenylist) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := d.repo.DeleteExpired(ctx); err != nil {
+                log.Printf("Failed to delete expired tokens: %v", err)
+            }
+            if err := d.Reload(ctx); err != nil {
+                log.Printf("Failed to reload token denylist: %v", err)
+            }
+        }
+    }
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+    "context"
+    "skyflow/internal/models"
+)
+
+// Хранилище рейсов, которое дополняет выдаваемые рейсы названиями
+// из справочников (город, авиакомпания, тип ВС)
+type EnrichedFlightStore struct {
+    FlightStore
+    reference *ReferenceCache
+}
+
+func NewEnrichedFlightStore(store FlightStore, reference *ReferenceCache) *EnrichedFlightStore {
+    return &EnrichedFlightStore{FlightStore: store, reference: reference}
+}
+
+func (s *EnrichedFlightStore) Create(ctx context.Context, flight *models.Flight, author *models.FlightAuthor) error {
+    if err := s.FlightStore.Create(ctx, flight, author); err != nil {
+        return err
+    }
+    *flight = s.reference.Enrich(*flight)
+    return nil
+}
+
+func (s *EnrichedFlightStore) CreateMany(ctx context.Context, flights []*models.Flight, author *models.FlightAuthor) error {
+    if err := s.FlightStore.CreateMany(ctx, flights, author); err != nil {
+        return err
+    }
+    for _, flight := range flights {
+        *flight = s.reference.Enrich(*flight)
+    }
+    return nil
+}
+
+func (s *EnrichedFlightStore) GetAll(ctx context.Context) ([]models.Flight, error) {
+    flights, err := s.FlightStore.GetAll(ctx)
+    if err != nil {
+        return nil, err
+    }
+    for i := range flights {
+        flights[i] = s.reference.Enrich(flights[i])
+    }
+    return flights, nil
+}
+
+func (s *EnrichedFlightStore) List(ctx context.Context, filter models.FlightFilter) (*models.FlightPage, error) {
+    page, err := s.FlightStore.List(ctx, filter)
+    if err != nil {
+        return nil, err
+    }
+    for i := range page.Flights {
+        page.Flights[i] = s.reference.Enrich(page.Flights[i])
+    }
+    return page, nil
+}
+
+func (s *EnrichedFlightStore) GetByID(ctx context.Context, id string) (*models.Flight, error) {
+    return s.enrich(s.FlightStore.GetByID(ctx, id))
+}
+
+func (s *EnrichedFlightStore) GetByFlightNumber(ctx context.Context, flightNumber string) (*models.Flight, error) {
+    return s.enrich(s.FlightStore.GetByFlightNumber(ctx, flightNumber))
+}
+
+func (s *EnrichedFlightStore) Update(ctx context.Context, flight *models.Flight, author *models.FlightAuthor) error {
+    if err := s.FlightStore.Update(ctx, flight, author); err != nil {
+        return err
+    }
+    *flight = s.reference.Enrich(*flight)
+    return nil
+}
+
+func (s *EnrichedFlightStore) enrich(flight *models.Flight, err error) (*models.Flight, error) {
+    if err != nil || flight == nil {
+        return flight, err
+    }
+    enriched := s.reference.Enrich(*flight)
+    return &enriched, nil
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "fmt"
+    "time"
+    "skyflow/internal/models"
+)
+
+type FlightEventRepository struct {
+    db *sql.DB
+}
+
+func NewFlightEventRepository(db *sql.DB) *FlightEventRepository {
+    return &FlightEventRepository{db: db}
+}
+
+// Запись изменения рейса в журнал
+func (r *FlightEventRepository) Create(ctx context.Context, event *models.FlightEvent) error {
+    return insertFlightEvent(ctx, r.db, event)
+}
+
+// Запись в журнал напрямую или в транзакции изменения рейса
+func insertFlightEvent(ctx context.Context, db execer, event *models.FlightEvent) error {
+    query := `
+        INSERT INTO flight_events (
+            id, flight_id, flight_number, action, user_id, username, changes, created_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+    
+    changes, err := json.Marshal(event.Changes)
+    if err != nil {
+        return fmt.Errorf("failed to encode changes: %w", err)
+    }
+    
+    event.ID = generateID()
+    event.CreatedAt = time.Now()
+    
+    _, err = db.ExecContext(ctx, query,
+        event.ID,
+        event.FlightID,
+        event.FlightNumber,
+        event.Action,
+        nullString(event.UserID),
+        nullString(event.Username),
+        string(changes),
+        event.CreatedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to record flight event: %w", err)
+    }
+    
+    return nil
+}
+
+// История рейса в хронологическом порядке
+func (r *FlightEventRepository) GetByFlightID(ctx context.Context, flightID string) ([]models.FlightEvent, error) {
+    query := `
+        SELECT id, flight_id, flight_number, action,
+               COALESCE(user_id, ''), COALESCE(username, ''), changes, created_at
+        FROM flight_events
+        WHERE flight_id = $1
+        ORDER BY created_at, id
+    `
+    
+    rows, err := r.db.QueryContext(ctx, query, flightID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get flight history: %w", err)
+    }
+    defer rows.Close()
+    
+    events := []models.FlightEvent{}
+    for rows.Next() {
+        var event models.FlightEvent
+        var changes []byte
+        err := rows.Scan(
+            &event.ID,
+            &event.FlightID,
+            &event.FlightNumber,
+            &event.Action,
+            &event.UserID,
+            &event.Username,
+            &changes,
+            &event.CreatedAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        
+        if err := json.Unmarshal(changes, &event.Changes); err != nil {
+            return nil, fmt.Errorf("failed to decode changes: %w", err)
+        }
+        events = append(events, event)
+    }
+    
+    return events, rows.Err()
+}
+
+func nullString(s string) sql.NullString {
+    return sql.NullString{String: s, Valid: s != ""}
+}
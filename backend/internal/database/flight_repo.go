@@ -3,9 +3,12 @@ package database
 import (
     "context"
     "database/sql"
+    "encoding/json"
     "fmt"
+    "strings"
     "time"
     "skyflow/internal/models"
+    "github.com/lib/pq"
 )
 
 type FlightRepository struct {
@@ -16,22 +19,158 @@ func NewFlightRepository(db *sql.DB) *FlightRepository {
     return &FlightRepository{db: db}
 }
 
-// Создание рейса с защитой от SQL-инъекций
-func (r *FlightRepository) Create(ctx context.Context, flight *models.Flight) error {
-    query := `
-        INSERT INTO flights (
-            id, flight_number, airline, origin, destination, 
-            scheduled_time, actual_time, terminal, gate, status,
-            delay_reason, created_at, updated_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-        RETURNING id
-    `
+const flightColumns = `id, flight_number, airline, origin, destination,
+    scheduled_time, actual_time, terminal, gate, status,
+    delay_reason, COALESCE(aircraft_type, ''), scheduled_arrival_time,
+    COALESCE(schedule_id, ''), COALESCE(to_char(service_date, 'YYYY-MM-DD'), ''), schedule_override,
+    status_updated_at, status_source, archived_at,
+    estimated_off_block, estimated_takeoff, estimated_landing, estimated_in_block,
+    actual_off_block, actual_takeoff, actual_landing, actual_in_block,
+    delay_minutes, arrival_delay_minutes, delay_codes, codeshares, created_at, updated_at`
+
+const flightInsert = `
+    INSERT INTO flights (
+        id, flight_number, airline, origin, destination,
+        scheduled_time, actual_time, terminal, gate, status,
+        delay_reason, aircraft_type, scheduled_arrival_time,
+        schedule_id, service_date, schedule_override,
+        status_updated_at, status_source, archived_at,
+        estimated_off_block, estimated_takeoff, estimated_landing, estimated_in_block,
+        actual_off_block, actual_takeoff, actual_landing, actual_in_block,
+        delay_minutes, arrival_delay_minutes, delay_codes, codeshares, created_at, updated_at
+    ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+        $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33)`
+
+func scanFlight(row rowScanner) (*models.Flight, error) {
+    var flight models.Flight
+    var scheduledArrival, archivedAt sql.NullTime
+    var stages [8]sql.NullTime
+    var delayCodes, codeshares []byte
+    err := row.Scan(
+        &flight.ID,
+        &flight.FlightNumber,
+        &flight.Airline,
+        &flight.From,
+        &flight.To,
+        &flight.Scheduled,
+        &flight.Actual,
+        &flight.Terminal,
+        &flight.Gate,
+        &flight.Status,
+        &flight.DelayReason,
+        &flight.Aircraft,
+        &scheduledArrival,
+        &flight.ScheduleID,
+        &flight.ServiceDate,
+        &flight.ScheduleOverride,
+        &flight.StatusUpdatedAt,
+        &flight.StatusSource,
+        &archivedAt,
+        &stages[0], &stages[1], &stages[2], &stages[3],
+        &stages[4], &stages[5], &stages[6], &stages[7],
+        &flight.DelayMinutes,
+        &flight.ArrivalDelayMinutes,
+        &delayCodes,
+        &codeshares,
+        &flight.CreatedAt,
+        &flight.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    
+    // TIMESTAMPTZ приходит в поясе сессии; в API время всегда в UTC
+    flight.Scheduled = flight.Scheduled.UTC()
+    flight.Actual = flight.Actual.UTC()
+    if scheduledArrival.Valid {
+        arrival := scheduledArrival.Time.UTC()
+        flight.ScheduledArrival = &arrival
+    }
+    flight.StatusUpdatedAt = flight.StatusUpdatedAt.UTC()
+    if archivedAt.Valid {
+        archived := archivedAt.Time.UTC()
+        flight.ArchivedAt = &archived
+    }
+    for i, stage := range flight.StageTimes() {
+        if stages[i].Valid {
+            t := stages[i].Time.UTC()
+            *stage.Value = &t
+        }
+    }
+    if err := json.Unmarshal(delayCodes, &flight.DelayCodes); err != nil {
+        return nil, fmt.Errorf("failed to decode delay codes: %w", err)
+    }
+    if err := json.Unmarshal(codeshares, &flight.Codeshares); err != nil {
+        return nil, fmt.Errorf("failed to decode codeshares: %w", err)
+    }
+    if len(flight.Codeshares) == 0 {
+        flight.Codeshares = nil
+    }
+    return &flight, nil
+}
+
+// Создание рейса с защитой от SQL-инъекций; запись в журнал — в той же транзакции
+func (r *FlightRepository) Create(ctx context.Context, flight *models.Flight, author *models.FlightAuthor) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
     
     flight.ID = generateID()
-    flight.CreatedAt = time.Now()
-    flight.UpdatedAt = time.Now()
+    flight.CreatedAt = time.Now().UTC()
+    flight.UpdatedAt = flight.CreatedAt
+    initStatusStamp(flight)
+    flight.ComputeDelay()
+    
+    if err := tx.QueryRowContext(ctx, flightInsert+` RETURNING id`, flightInsertArgs(flight)...).Scan(&flight.ID); err != nil {
+        return fmt.Errorf("failed to create flight: %w", err)
+    }
+    if author != nil {
+        if err := insertFlightEvent(ctx, tx, author.Event(models.FlightEventCreated, *flight, models.FlightSnapshot(*flight, false))); err != nil {
+            return err
+        }
+    }
+    
+    return tx.Commit()
+}
+
+// Создание рейсов одной транзакцией: при ошибке не создается ни один
+func (r *FlightRepository) CreateMany(ctx context.Context, flights []*models.Flight, author *models.FlightAuthor) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    
+    stmt, err := tx.PrepareContext(ctx, flightInsert)
+    if err != nil {
+        return fmt.Errorf("failed to prepare flight insert: %w", err)
+    }
+    defer stmt.Close()
     
-    err := r.db.QueryRowContext(ctx, query,
+    now := time.Now().UTC()
+    for i, flight := range flights {
+        flight.ID = generateID()
+        flight.CreatedAt = now
+        flight.UpdatedAt = now
+        initStatusStamp(flight)
+        flight.ComputeDelay()
+        if _, err := stmt.ExecContext(ctx, flightInsertArgs(flight)...); err != nil {
+            return fmt.Errorf("failed to create flight #%d (%s): %w", i+1, flight.FlightNumber, err)
+        }
+        if author != nil {
+            if err := insertFlightEvent(ctx, tx, author.Event(models.FlightEventCreated, *flight, models.FlightSnapshot(*flight, false))); err != nil {
+                return err
+            }
+        }
+    }
+    
+    return tx.Commit()
+}
+
+func flightInsertArgs(flight *models.Flight) []interface{} {
+    return []interface{}{
         flight.ID,
         flight.FlightNumber,
         flight.Airline,
@@ -43,22 +182,62 @@ func (r *FlightRepository) Create(ctx context.Context, flight *models.Flight) er
         flight.Gate,
         flight.Status,
         flight.DelayReason,
+        nullString(flight.Aircraft),
+        flight.ScheduledArrival,
+        nullString(flight.ScheduleID),
+        nullString(flight.ServiceDate),
+        flight.ScheduleOverride,
+        flight.StatusUpdatedAt,
+        flight.StatusSource,
+        flight.ArchivedAt,
+        flight.EstimatedOffBlock,
+        flight.EstimatedTakeoff,
+        flight.EstimatedLanding,
+        flight.EstimatedInBlock,
+        flight.ActualOffBlock,
+        flight.ActualTakeoff,
+        flight.ActualLanding,
+        flight.ActualInBlock,
+        flight.DelayMinutes,
+        flight.ArrivalDelayMinutes,
+        delayCodesJSON(flight.DelayCodes),
+        codesharesJSON(flight.Codeshares),
         flight.CreatedAt,
         flight.UpdatedAt,
-    ).Scan(&flight.ID)
-    
-    return err
+    }
+}
+
+// Коды задержки для столбца JSONB; пустой список — []
+func delayCodesJSON(codes []models.FlightDelay) string {
+    if codes == nil {
+        codes = []models.FlightDelay{}
+    }
+    data, _ := json.Marshal(codes)
+    return string(data)
+}
+
+// Коммерческие номера для столбца JSONB; пустой список — []
+func codesharesJSON(codeshares []models.Codeshare) string {
+    if codeshares == nil {
+        codeshares = []models.Codeshare{}
+    }
+    data, _ := json.Marshal(codeshares)
+    return string(data)
+}
+
+// Новый рейс: статус выставлен в момент создания, по умолчанию — вручную
+func initStatusStamp(flight *models.Flight) {
+    if flight.StatusUpdatedAt.IsZero() {
+        flight.StatusUpdatedAt = flight.CreatedAt
+    }
+    if flight.StatusSource == "" {
+        flight.StatusSource = models.StatusSourceManual
+    }
 }
 
 // Получение всех рейсов
 func (r *FlightRepository) GetAll(ctx context.Context) ([]models.Flight, error) {
-    query := `
-        SELECT id, flight_number, airline, origin, destination, 
-               scheduled_time, actual_time, terminal, gate, status,
-               delay_reason, created_at, updated_at
-        FROM flights
-        ORDER BY scheduled_time
-    `
+    query := `SELECT ` + flightColumns + ` FROM flights ORDER BY scheduled_time`
     
     rows, err := r.db.QueryContext(ctx, query)
     if err != nil {
@@ -68,57 +247,171 @@ func (r *FlightRepository) GetAll(ctx context.Context) ([]models.Flight, error)
     
     var flights []models.Flight
     for rows.Next() {
-        var flight models.Flight
-        err := rows.Scan(
-            &flight.ID,
-            &flight.FlightNumber,
-            &flight.Airline,
-            &flight.From,
-            &flight.To,
-            &flight.Scheduled,
-            &flight.Actual,
-            &flight.Terminal,
-            &flight.Gate,
-            &flight.Status,
-            &flight.DelayReason,
-            &flight.CreatedAt,
-            &flight.UpdatedAt,
-        )
+        flight, err := scanFlight(rows)
         if err != nil {
             return nil, err
         }
-        flights = append(flights, flight)
+        flights = append(flights, *flight)
     }
     
     return flights, nil
 }
 
+// Столбцы для сортировки списка; NULL приводится к пустой строке,
+// чтобы сравнение с курсором было определено
+var flightSortColumns = map[string]string{
+    models.SortScheduled:    "scheduled_time",
+    models.SortActual:       "actual_time",
+    models.SortFlightNumber: "flight_number",
+    models.SortAirline:      "airline",
+    models.SortFrom:         "origin",
+    models.SortTo:           "destination",
+    models.SortTerminal:     "COALESCE(terminal, '')",
+    models.SortGate:         "COALESCE(gate, '')",
+    models.SortStatus:       "status",
+    models.SortUpdatedAt:    "updated_at",
+}
+
+// Страница рейсов по фильтру. Курсор — значение ключа сортировки и ID
+// последнего рейса предыдущей страницы (keyset), поэтому страницы не
+// сдвигаются, когда в начало списка добавляются рейсы
+func (r *FlightRepository) List(ctx context.Context, filter models.FlightFilter) (*models.FlightPage, error) {
+    var (
+        where []string
+        args  []interface{}
+    )
+    arg := func(value interface{}) string {
+        args = append(args, value)
+        return fmt.Sprintf("$%d", len(args))
+    }
+    
+    switch filter.Archived {
+    case models.ArchivedOnly:
+        where = append(where, "archived_at IS NOT NULL")
+    case models.ArchivedInclude:
+    default:
+        where = append(where, "archived_at IS NULL")
+    }
+    if filter.Origin != "" {
+        where = append(where, "origin = "+arg(filter.Origin))
+    }
+    if filter.Destination != "" {
+        where = append(where, "destination = "+arg(filter.Destination))
+    }
+    if filter.ScheduledFrom != nil {
+        where = append(where, "scheduled_time >= "+arg(*filter.ScheduledFrom))
+    }
+    if filter.ScheduledTo != nil {
+        where = append(where, "scheduled_time < "+arg(*filter.ScheduledTo))
+    }
+    if filter.Airline != "" {
+        where = append(where, "airline = "+arg(filter.Airline))
+    }
+    if len(filter.Statuses) > 0 {
+        where = append(where, "status = ANY("+arg(pq.Array(filter.Statuses))+")")
+    }
+    if filter.Terminal != "" {
+        where = append(where, "LOWER(terminal) = LOWER("+arg(filter.Terminal)+")")
+    }
+    if filter.Gate != "" {
+        where = append(where, "LOWER(gate) = LOWER("+arg(filter.Gate)+")")
+    }
+    if filter.Search != "" {
+        pattern := arg("%" + escapeLike(models.NormalizeFlightSearch(filter.Search)) + "%")
+        airports := arg(pq.Array(filter.SearchAirports))
+        where = append(where, fmt.Sprintf(
+            "(UPPER(REPLACE(flight_number, ' ', '')) LIKE %s OR origin = ANY(%s) OR destination = ANY(%s)"+
+                " OR EXISTS (SELECT 1 FROM jsonb_array_elements(codeshares) c WHERE UPPER(REPLACE(c->>'flightNumber', ' ', '')) LIKE %s))",
+            pattern, airports, airports, pattern))
+    }
+    
+    conditions := ""
+    if len(where) > 0 {
+        conditions = " WHERE " + strings.Join(where, " AND ")
+    }
+    
+    page := &models.FlightPage{Flights: []models.Flight{}}
+    if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM flights`+conditions, args...).Scan(&page.Total); err != nil {
+        return nil, fmt.Errorf("failed to count flights: %w", err)
+    }
+    
+    column := flightSortColumns[sortKey(filter)]
+    direction, compare := "ASC", ">"
+    if filter.SortDesc {
+        direction, compare = "DESC", "<"
+    }
+    
+    if c := filter.After; c != nil {
+        var value interface{} = c.Value
+        if models.IsTimeSortKey(c.SortKey) {
+            t, err := c.Time()
+            if err != nil {
+                return nil, models.ErrInvalidCursor
+            }
+            value = t
+        }
+        keyset := fmt.Sprintf("(%s, id) %s (%s, %s)", column, compare, arg(value), arg(c.ID))
+        if conditions == "" {
+            conditions = " WHERE " + keyset
+        } else {
+            conditions += " AND " + keyset
+        }
+    }
+    
+    query := `SELECT ` + flightColumns + ` FROM flights` + conditions +
+        fmt.Sprintf(` ORDER BY %s %s, id %s`, column, direction, direction)
+    if filter.Limit > 0 {
+        // Лишняя строка показывает, есть ли следующая страница
+        query += ` LIMIT ` + arg(filter.Limit+1)
+    }
+    
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list flights: %w", err)
+    }
+    defer rows.Close()
+    
+    for rows.Next() {
+        flight, err := scanFlight(rows)
+        if err != nil {
+            return nil, err
+        }
+        page.Flights = append(page.Flights, *flight)
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    
+    return paginate(page, filter), nil
+}
+
+// Обрезает лишний рейс и выставляет курсор следующей страницы
+func paginate(page *models.FlightPage, filter models.FlightFilter) *models.FlightPage {
+    if filter.Limit > 0 && len(page.Flights) > filter.Limit {
+        page.Flights = page.Flights[:filter.Limit]
+        last := page.Flights[len(page.Flights)-1]
+        page.NextCursor = models.NewFlightCursor(last, sortKey(filter), filter.SortDesc).Encode()
+    }
+    return page
+}
+
+func sortKey(filter models.FlightFilter) string {
+    if models.ValidFlightSortKey(filter.SortKey) {
+        return filter.SortKey
+    }
+    return models.SortScheduled
+}
+
+// Экранирует % и _ для LIKE (escape-символ по умолчанию — обратная косая)
+func escapeLike(s string) string {
+    return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 // Получение рейса по ID
 func (r *FlightRepository) GetByID(ctx context.Context, id string) (*models.Flight, error) {
-    query := `
-        SELECT id, flight_number, airline, origin, destination, 
-               scheduled_time, actual_time, terminal, gate, status,
-               delay_reason, created_at, updated_at
-        FROM flights
-        WHERE id = $1
-    `
+    query := `SELECT ` + flightColumns + ` FROM flights WHERE id = $1`
     
-    var flight models.Flight
-    err := r.db.QueryRowContext(ctx, query, id).Scan(
-        &flight.ID,
-        &flight.FlightNumber,
-        &flight.Airline,
-        &flight.From,
-        &flight.To,
-        &flight.Scheduled,
-        &flight.Actual,
-        &flight.Terminal,
-        &flight.Gate,
-        &flight.Status,
-        &flight.DelayReason,
-        &flight.CreatedAt,
-        &flight.UpdatedAt,
-    )
+    flight, err := scanFlight(r.db.QueryRowContext(ctx, query, id))
     
     if err == sql.ErrNoRows {
         return nil, nil
@@ -128,11 +421,31 @@ func (r *FlightRepository) GetByID(ctx context.Context, id string) (*models.Flig
         return nil, fmt.Errorf("failed to get flight: %w", err)
     }
     
-    return &flight, nil
+    return flight, nil
 }
 
 // Обновление рейса
-func (r *FlightRepository) Update(ctx context.Context, flight *models.Flight) error {
+func (r *FlightRepository) Update(ctx context.Context, flight *models.Flight, author *models.FlightAuthor) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    
+    // Журнал сравнивает с тем, что лежит в базе, а не с прочитанным раньше
+    before, err := scanFlight(tx.QueryRowContext(ctx, `SELECT `+flightColumns+` FROM flights WHERE id = $1 FOR UPDATE`, flight.ID))
+    if err == sql.ErrNoRows {
+        return ErrFlightNotFound
+    }
+    if err != nil {
+        return fmt.Errorf("failed to update flight: %w", err)
+    }
+    // Переход проверяется по заблокированной строке: параллельный запрос
+    // мог сменить статус после того, как вызывающий его прочитал
+    if err := checkStatusChange(*before, *flight); err != nil {
+        return err
+    }
+    
     query := `
         UPDATE flights SET
             flight_number = $1,
@@ -145,13 +458,33 @@ func (r *FlightRepository) Update(ctx context.Context, flight *models.Flight) er
             gate = $8,
             status = $9,
             delay_reason = $10,
-            updated_at = $11
-        WHERE id = $12
+            aircraft_type = $11,
+            scheduled_arrival_time = $12,
+            schedule_override = $13,
+            updated_at = $14,
+            -- Смена статуса через Update — ручная
+            status_updated_at = CASE WHEN status = $9 THEN status_updated_at ELSE $14 END,
+            status_source = CASE WHEN status = $9 THEN status_source ELSE 'manual' END,
+            estimated_off_block = $16,
+            estimated_takeoff = $17,
+            estimated_landing = $18,
+            estimated_in_block = $19,
+            actual_off_block = $20,
+            actual_takeoff = $21,
+            actual_landing = $22,
+            actual_in_block = $23,
+            delay_minutes = $24,
+            arrival_delay_minutes = $25,
+            delay_codes = $26,
+            codeshares = $27
+        WHERE id = $15
+        RETURNING status_updated_at, status_source
     `
     
-    flight.UpdatedAt = time.Now()
+    flight.UpdatedAt = time.Now().UTC()
+    flight.ComputeDelay()
     
-    result, err := r.db.ExecContext(ctx, query,
+    err = tx.QueryRowContext(ctx, query,
         flight.FlightNumber,
         flight.Airline,
         flight.From,
@@ -162,67 +495,172 @@ func (r *FlightRepository) Update(ctx context.Context, flight *models.Flight) er
         flight.Gate,
         flight.Status,
         flight.DelayReason,
+        nullString(flight.Aircraft),
+        flight.ScheduledArrival,
+        flight.ScheduleOverride,
         flight.UpdatedAt,
         flight.ID,
-    )
+        flight.EstimatedOffBlock,
+        flight.EstimatedTakeoff,
+        flight.EstimatedLanding,
+        flight.EstimatedInBlock,
+        flight.ActualOffBlock,
+        flight.ActualTakeoff,
+        flight.ActualLanding,
+        flight.ActualInBlock,
+        flight.DelayMinutes,
+        flight.ArrivalDelayMinutes,
+        delayCodesJSON(flight.DelayCodes),
+        codesharesJSON(flight.Codeshares),
+    ).Scan(&flight.StatusUpdatedAt, &flight.StatusSource)
     
+    if err == sql.ErrNoRows {
+        return ErrFlightNotFound
+    }
     if err != nil {
         return fmt.Errorf("failed to update flight: %w", err)
     }
+    flight.StatusUpdatedAt = flight.StatusUpdatedAt.UTC()
+    
+    if changes := models.DiffFlights(*before, *flight); author != nil && len(changes) > 0 {
+        if err := insertFlightEvent(ctx, tx, author.Event(models.FlightEventUpdated, *flight, changes)); err != nil {
+            return err
+        }
+    }
+    
+    return tx.Commit()
+}
+
+// Смена статуса планировщиком. Проходит, только если статус не менялся
+// с момента чтения рейса (иначе false): ручная правка всегда побеждает
+func (r *FlightRepository) AdvanceStatus(ctx context.Context, flight *models.Flight, status models.FlightStatus, at time.Time, author *models.FlightAuthor) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
     
-    rows, _ := result.RowsAffected()
-    if rows == 0 {
-        return fmt.Errorf("flight not found")
+    query := `
+        UPDATE flights SET
+            status = $1,
+            status_source = 'auto',
+            status_updated_at = $2,
+            updated_at = $2
+        WHERE id = $3 AND status = $4 AND status_updated_at = $5 AND archived_at IS NULL
+    `
+    
+    at = at.UTC().Truncate(time.Microsecond)
+    result, err := tx.ExecContext(ctx, query, string(status), at, flight.ID, flight.Status, flight.StatusUpdatedAt)
+    if err != nil {
+        return false, fmt.Errorf("failed to advance flight status: %w", err)
+    }
+    if rows, _ := result.RowsAffected(); rows == 0 {
+        return false, nil
+    }
+    
+    advanced := *flight
+    advanced.Status = string(status)
+    advanced.StatusSource = models.StatusSourceAuto
+    advanced.StatusUpdatedAt = at
+    advanced.UpdatedAt = at
+    
+    if changes := models.DiffFlights(*flight, advanced); author != nil && len(changes) > 0 {
+        if err := insertFlightEvent(ctx, tx, author.Event(models.FlightEventUpdated, advanced, changes)); err != nil {
+            return false, err
+        }
     }
     
-    return nil
+    if err := tx.Commit(); err != nil {
+        return false, err
+    }
+    *flight = advanced
+    return true, nil
+}
+
+// Переносит завершенный рейс в архив, если статус не менялся с момента чтения
+func (r *FlightRepository) Archive(ctx context.Context, flight *models.Flight, at time.Time, author *models.FlightAuthor) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+    
+    query := `
+        UPDATE flights SET archived_at = $1, updated_at = $1
+        WHERE id = $2 AND status = $3 AND status_updated_at = $4 AND archived_at IS NULL
+    `
+    
+    at = at.UTC().Truncate(time.Microsecond)
+    result, err := tx.ExecContext(ctx, query, at, flight.ID, flight.Status, flight.StatusUpdatedAt)
+    if err != nil {
+        return false, fmt.Errorf("failed to archive flight: %w", err)
+    }
+    if rows, _ := result.RowsAffected(); rows == 0 {
+        return false, nil
+    }
+    
+    if author != nil {
+        event := author.Event(models.FlightEventArchived, *flight, map[string]models.FieldChange{
+            "archivedAt": {To: at},
+        })
+        if err := insertFlightEvent(ctx, tx, event); err != nil {
+            return false, err
+        }
+    }
+    
+    if err := tx.Commit(); err != nil {
+        return false, err
+    }
+    flight.ArchivedAt = &at
+    flight.UpdatedAt = at
+    return true, nil
 }
 
 // Удаление рейса
-func (r *FlightRepository) Delete(ctx context.Context, id string) error {
-    query := `DELETE FROM flights WHERE id = $1`
+func (r *FlightRepository) Delete(ctx context.Context, id string, author *models.FlightAuthor) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    
+    query := `DELETE FROM flights WHERE id = $1 RETURNING ` + flightColumns
     
-    result, err := r.db.ExecContext(ctx, query, id)
+    flight, err := scanFlight(tx.QueryRowContext(ctx, query, id))
+    if err == sql.ErrNoRows {
+        return ErrFlightNotFound
+    }
     if err != nil {
         return fmt.Errorf("failed to delete flight: %w", err)
     }
     
-    rows, _ := result.RowsAffected()
-    if rows == 0 {
-        return fmt.Errorf("flight not found")
+    if author != nil {
+        if err := insertFlightEvent(ctx, tx, author.Event(models.FlightEventDeleted, *flight, models.FlightSnapshot(*flight, true))); err != nil {
+            return err
+        }
     }
     
-    return nil
+    return tx.Commit()
 }
 
-// Получение рейса по номеру
+// Получение рейса по номеру: выполняемому или коммерческому (код-шеринг).
+// Возвращается выполняемый рейс; из нескольких дат — текущий или ближайший
 func (r *FlightRepository) GetByFlightNumber(ctx context.Context, flightNumber string) (*models.Flight, error) {
     query := `
-        SELECT id, flight_number, airline, origin, destination, 
-               scheduled_time, actual_time, terminal, gate, status,
-               delay_reason, created_at, updated_at
+        SELECT ` + flightColumns + `
         FROM flights
-        WHERE flight_number = $1
-        ORDER BY scheduled_time DESC
+        WHERE flight_number = $1 OR codeshares @> jsonb_build_array(jsonb_build_object('flightNumber', $1::text))
+        ORDER BY
+            archived_at IS NOT NULL,
+            scheduled_time < $2,
+            CASE WHEN scheduled_time >= $2 THEN scheduled_time END ASC,
+            scheduled_time DESC
         LIMIT 1
     `
     
-    var flight models.Flight
-    err := r.db.QueryRowContext(ctx, query, flightNumber).Scan(
-        &flight.ID,
-        &flight.FlightNumber,
-        &flight.Airline,
-        &flight.From,
-        &flight.To,
-        &flight.Scheduled,
-        &flight.Actual,
-        &flight.Terminal,
-        &flight.Gate,
-        &flight.Status,
-        &flight.DelayReason,
-        &flight.CreatedAt,
-        &flight.UpdatedAt,
-    )
+    // Порядок тот же, что у models.PreferByFlightNumber
+    since := time.Now().Add(-models.FlightNumberGrace)
+    flight, err := scanFlight(r.db.QueryRowContext(ctx, query, models.NormalizeFlightNumber(flightNumber), since))
     
     if err == sql.ErrNoRows {
         return nil, nil
@@ -232,7 +670,7 @@ func (r *FlightRepository) GetByFlightNumber(ctx context.Context, flightNumber s
         return nil, fmt.Errorf("failed to get flight by number: %w", err)
     }
     
-    return &flight, nil
+    return flight, nil
 }
 
 func generateID() string {
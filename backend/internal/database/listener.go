@@ -0,0 +1,176 @@
+package database
+
+import (
+    "context"
+    "encoding/json"
+    "log"
+    "time"
+    "skyflow/internal/events"
+    "skyflow/internal/models"
+    "github.com/lib/pq"
+)
+
+// Канал, в который триггер flights_notify_change шлет изменения
+const FlightChangesChannel = "flight_changes"
+
+const listenerPingInterval = 90 * time.Second
+
+type flightChangeNotification struct {
+    Op           string `json:"op"`
+    ID           string `json:"id"`
+    FlightNumber string `json:"flightNumber"`
+}
+
+// Слушает LISTEN flight_changes и публикует изменения в локальный брокер.
+// Так клиенты любой реплики видят изменения, сделанные через другие реплики
+type FlightChangeListener struct {
+    databaseURL string
+    repo        *FlightRepository
+    broker      *events.Broker
+    // Последнее известное состояние неархивных рейсов, для ресинхронизации
+    // после обрыва. Рейс, ушедший в архив, отсюда удаляется
+    known map[string]models.Flight
+}
+
+func NewFlightChangeListener(databaseURL string, repo *FlightRepository, broker *events.Broker) *FlightChangeListener {
+    return &FlightChangeListener{
+        databaseURL: databaseURL,
+        repo:        repo,
+        broker:      broker,
+        known:       make(map[string]models.Flight),
+    }
+}
+
+// Блокируется до отмены ctx. pq.Listener сам переподключается при обрыве
+func (l *FlightChangeListener) Run(ctx context.Context) error {
+    listener := pq.NewListener(l.databaseURL, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+        switch ev {
+        case pq.ListenerEventDisconnected:
+            log.Printf("Flight change listener disconnected: %v", err)
+        case pq.ListenerEventReconnected:
+            log.Println("Flight change listener reconnected")
+        case pq.ListenerEventConnectionAttemptFailed:
+            log.Printf("Flight change listener reconnect failed: %v", err)
+        }
+    })
+    defer listener.Close()
+    
+    if err := listener.Listen(FlightChangesChannel); err != nil {
+        return err
+    }
+    
+    // Начальный снимок, без публикации событий
+    l.resync(ctx, false)
+    
+    for {
+        select {
+        case <-ctx.Done():
+            return nil
+        
+        case n := <-listener.Notify:
+            if n == nil {
+                // После переподключения pq присылает nil: уведомления могли потеряться
+                l.resync(ctx, true)
+                continue
+            }
+            l.handle(ctx, n.Extra)
+        
+        case <-time.After(listenerPingInterval):
+            go listener.Ping()
+        }
+    }
+}
+
+func (l *FlightChangeListener) handle(ctx context.Context, payload string) {
+    var change flightChangeNotification
+    if err := json.Unmarshal([]byte(payload), &change); err != nil {
+        log.Printf("Invalid flight change notification %q: %v", payload, err)
+        return
+    }
+    
+    if change.Op == "delete" {
+        flight, ok := l.known[change.ID]
+        delete(l.known, change.ID)
+        
+        if !ok {
+            flight = models.Flight{ID: change.ID, FlightNumber: change.FlightNumber}
+        }
+        l.broker.Publish(events.FlightDeleted, flight)
+        return
+    }
+    
+    flight, err := l.repo.GetByID(ctx, change.ID)
+    if err != nil {
+        log.Printf("Failed to load changed flight %s: %v", change.ID, err)
+        return
+    }
+    if flight == nil {
+        // Рейс уже удален, придет отдельное уведомление
+        return
+    }
+    
+    _, existed := l.known[flight.ID]
+    if flight.ArchivedAt != nil {
+        // Архивный рейс больше не отслеживаем; клиенты уберут его по событию
+        delete(l.known, flight.ID)
+        if existed {
+            l.broker.Publish(events.FlightUpdated, *flight)
+        }
+        return
+    }
+    l.known[flight.ID] = *flight
+    
+    if change.Op == "insert" || !existed {
+        l.broker.Publish(events.FlightCreated, *flight)
+    } else {
+        l.broker.Publish(events.FlightUpdated, *flight)
+    }
+}
+
+// Перечитывает неархивные рейсы и публикует разницу с последним известным состоянием
+func (l *FlightChangeListener) resync(ctx context.Context, publish bool) {
+    page, err := l.repo.List(ctx, models.FlightFilter{})
+    if err != nil {
+        log.Printf("Flight change listener resync failed: %v", err)
+        return
+    }
+    
+    current := make(map[string]models.Flight, len(page.Flights))
+    for _, flight := range page.Flights {
+        current[flight.ID] = flight
+    }
+    
+    previous := l.known
+    l.known = current
+    
+    if !publish {
+        return
+    }
+    
+    for id, flight := range current {
+        old, ok := previous[id]
+        if !ok {
+            l.broker.Publish(events.FlightCreated, flight)
+        } else if !old.UpdatedAt.Equal(flight.UpdatedAt) {
+            l.broker.Publish(events.FlightUpdated, flight)
+        }
+    }
+    for id, flight := range previous {
+        if _, ok := current[id]; ok {
+            continue
+        }
+        // Пропавший рейс мог уйти в архив, а не быть удаленным
+        archived, err := l.repo.GetByID(ctx, id)
+        if err != nil {
+            log.Printf("Failed to load flight %s during resync: %v", id, err)
+            continue
+        }
+        if archived != nil {
+            l.broker.Publish(events.FlightUpdated, *archived)
+        } else {
+            l.broker.Publish(events.FlightDeleted, flight)
+        }
+    }
+    
+    log.Printf("Flight change listener resynced %d flights", len(current))
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "log"
+    "time"
+    "skyflow/internal/models"
+)
+
+// Пороги блокировки входа
+type ThrottlePolicy struct {
+    MaxUserFailures int           // неудачных попыток на имя до блокировки (0 — не блокировать)
+    MaxIPFailures   int           // неудачных попыток с одного IP до блокировки (0 — не блокировать)
+    BaseLockout     time.Duration // первая блокировка, дальше удваивается
+    MaxLockout      time.Duration
+    Window          time.Duration // после стольких минут без ошибок счетчик сбрасывается
+}
+
+// Защита входа от перебора паролей
+type LoginThrottle struct {
+    db     *sql.DB
+    policy ThrottlePolicy
+}
+
+func NewLoginThrottle(db *sql.DB, policy ThrottlePolicy) *LoginThrottle {
+    return &LoginThrottle{db: db, policy: policy}
+}
+
+// Сколько ждать до следующей попытки (0 — можно пробовать)
+func (t *LoginThrottle) RetryAfter(ctx context.Context, username, ip string) (time.Duration, error) {
+    var lockedUntil sql.NullTime
+    err := t.db.QueryRowContext(ctx, `
+        SELECT MAX(locked_until) FROM login_throttle
+        WHERE (kind = $1 AND subject = $2) OR (kind = $3 AND subject = $4)
+    `, models.LockoutByUsername, username, models.LockoutByIP, ip).Scan(&lockedUntil)
+    if err != nil {
+        return 0, fmt.Errorf("failed to check login lockout: %w", err)
+    }
+    
+    if !lockedUntil.Valid {
+        return 0, nil
+    }
+    if wait := time.Until(lockedUntil.Time); wait > 0 {
+        return wait, nil
+    }
+    return 0, nil
+}
+
+// Учет неудачной попытки. Возвращает срок блокировки, если она наступила
+func (t *LoginThrottle) RecordFailure(ctx context.Context, username, ip string) (time.Duration, error) {
+    userWait, err := t.fail(ctx, models.LockoutByUsername, username, t.policy.MaxUserFailures)
+    if err != nil {
+        return 0, err
+    }
+    
+    ipWait, err := t.fail(ctx, models.LockoutByIP, ip, t.policy.MaxIPFailures)
+    if err != nil {
+        return 0, err
+    }
+    
+    if ipWait > userWait {
+        return ipWait, nil
+    }
+    return userWait, nil
+}
+
+// Успешный вход сбрасывает счетчик имени. Счетчик IP не сбрасывается,
+// иначе вход в свою учетную запись обнулял бы перебор чужих
+func (t *LoginThrottle) RecordSuccess(ctx context.Context, username string) error {
+    return t.Clear(ctx, models.LockoutByUsername, username)
+}
+
+// Текущие счетчики и блокировки
+func (t *LoginThrottle) List(ctx context.Context) ([]models.LoginLockout, error) {
+    rows, err := t.db.QueryContext(ctx, `
+        SELECT kind, subject, failures, last_failure_at, locked_until
+        FROM login_throttle
+        WHERE locked_until > NOW() OR last_failure_at > $1
+        ORDER BY locked_until DESC NULLS LAST, last_failure_at DESC
+    `, time.Now().Add(-t.policy.Window))
+    if err != nil {
+        return nil, fmt.Errorf("failed to list lockouts: %w", err)
+    }
+    defer rows.Close()
+    
+    lockouts := []models.LoginLockout{}
+    for rows.Next() {
+        var lockout models.LoginLockout
+        var lockedUntil sql.NullTime
+        err := rows.Scan(
+            &lockout.Kind,
+            &lockout.Subject,
+            &lockout.Failures,
+            &lockout.LastFailureAt,
+            &lockedUntil,
+        )
+        if err != nil {
+            return nil, err
+        }
+        if lockedUntil.Valid {
+            lockout.LockedUntil = &lockedUntil.Time
+        }
+        lockouts = append(lockouts, lockout)
+    }
+    
+    return lockouts, rows.Err()
+}
+
+// Снятие блокировки
+func (t *LoginThrottle) Clear(ctx context.Context, kind, subject string) error {
+    _, err := t.db.ExecContext(ctx, `DELETE FROM login_throttle WHERE kind = $1 AND subject = $2`, kind, subject)
+    if err != nil {
+        return fmt.Errorf("failed to clear lockout: %w", err)
+    }
+    return nil
+}
+
+// Удаляет счетчики, по которым окно ошибок прошло и блокировка истекла
+func (t *LoginThrottle) Purge(ctx context.Context) error {
+    _, err := t.db.ExecContext(ctx, `
+        DELETE FROM login_throttle
+        WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < NOW())
+    `, time.Now().Add(-t.policy.Window))
+    if err != nil {
+        return fmt.Errorf("failed to purge login throttle: %w", err)
+    }
+    return nil
+}
+
+// Чистит устаревшие счетчики каждые interval, до отмены ctx
+func (t *LoginThrottle) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := t.Purge(ctx); err != nil {
+                log.Printf("Failed to purge login throttle: %v", err)
+            }
+        }
+    }
+}
+
+func (t *LoginThrottle) fail(ctx context.Context, kind, subject string, threshold int) (time.Duration, error) {
+    var failures int
+    err := t.db.QueryRowContext(ctx, `
+        INSERT INTO login_throttle (kind, subject, failures, last_failure_at)
+        VALUES ($1, $2, 1, NOW())
+        ON CONFLICT (kind, subject) DO UPDATE SET
+            failures = CASE
+                WHEN login_throttle.last_failure_at < $3 THEN 1
+                ELSE login_throttle.failures + 1
+            END,
+            last_failure_at = NOW()
+        RETURNING failures
+    `, kind, subject, time.Now().Add(-t.policy.Window)).Scan(&failures)
+    if err != nil {
+        return 0, fmt.Errorf("failed to record login failure: %w", err)
+    }
+    
+    lockout := t.lockoutFor(failures, threshold)
+    if lockout == 0 {
+        return 0, nil
+    }
+    
+    _, err = t.db.ExecContext(ctx, `
+        UPDATE login_throttle SET locked_until = $3 WHERE kind = $1 AND subject = $2
+    `, kind, subject, time.Now().Add(lockout))
+    if err != nil {
+        return 0, fmt.Errorf("failed to lock login: %w", err)
+    }
+    
+    return lockout, nil
+}
+
+// Экспоненциальная блокировка: base, 2*base, 4*base... но не больше max.
+// Нулевой или отрицательный порог отключает блокировку
+func (t *LoginThrottle) lockoutFor(failures, threshold int) time.Duration {
+    if threshold <= 0 || failures < threshold {
+        return 0
+    }
+    
+    lockout := t.policy.BaseLockout
+    for i := threshold; i < failures && lockout < t.policy.MaxLockout; i++ {
+        lockout *= 2
+    }
+    if lockout > t.policy.MaxLockout {
+        lockout = t.policy.MaxLockout
+    }
+    return lockout
+}
@@ -0,0 +1,221 @@
+package database
+
+import (
+    "context"
+    "sort"
+    "sync"
+    "time"
+    "skyflow/internal/models"
+    "skyflow/reference"
+)
+
+// Справочники в памяти для демо-режима
+type MemoryReferenceStore struct {
+    mu       sync.RWMutex
+    airports map[string]models.Airport
+    airlines map[string]models.Airline
+    aircraft map[string]models.AircraftType
+}
+
+func NewMemoryReferenceStore() *MemoryReferenceStore {
+    return &MemoryReferenceStore{
+        airports: make(map[string]models.Airport),
+        airlines: make(map[string]models.Airline),
+        aircraft: make(map[string]models.AircraftType),
+    }
+}
+
+func (s *MemoryReferenceStore) ListAirports(ctx context.Context) ([]models.Airport, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    
+    airports := make([]models.Airport, 0, len(s.airports))
+    for _, a := range s.airports {
+        airports = append(airports, a)
+    }
+    sort.Slice(airports, func(i, j int) bool { return airports[i].IATACode < airports[j].IATACode })
+    return airports, nil
+}
+
+func (s *MemoryReferenceStore) CreateAirport(ctx context.Context, a *models.Airport) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.airports[a.IATACode]; ok {
+        return ErrReferenceExists
+    }
+    if a.ICAOCode != "" {
+        for _, other := range s.airports {
+            if other.ICAOCode == a.ICAOCode {
+                return ErrReferenceExists
+            }
+        }
+    }
+    a.UpdatedAt = time.Now()
+    s.airports[a.IATACode] = *a
+    return nil
+}
+
+func (s *MemoryReferenceStore) UpdateAirport(ctx context.Context, a *models.Airport) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.airports[a.IATACode]; !ok {
+        return ErrReferenceNotFound
+    }
+    if a.ICAOCode != "" {
+        for code, other := range s.airports {
+            if code != a.IATACode && other.ICAOCode == a.ICAOCode {
+                return ErrReferenceExists
+            }
+        }
+    }
+    a.UpdatedAt = time.Now()
+    s.airports[a.IATACode] = *a
+    return nil
+}
+
+func (s *MemoryReferenceStore) DeleteAirport(ctx context.Context, iataCode string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.airports[iataCode]; !ok {
+        return ErrReferenceNotFound
+    }
+    delete(s.airports, iataCode)
+    return nil
+}
+
+func (s *MemoryReferenceStore) ListAirlines(ctx context.Context) ([]models.Airline, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    
+    airlines := make([]models.Airline, 0, len(s.airlines))
+    for _, a := range s.airlines {
+        airlines = append(airlines, a)
+    }
+    sort.Slice(airlines, func(i, j int) bool { return airlines[i].IATACode < airlines[j].IATACode })
+    return airlines, nil
+}
+
+func (s *MemoryReferenceStore) CreateAirline(ctx context.Context, a *models.Airline) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.airlines[a.IATACode]; ok {
+        return ErrReferenceExists
+    }
+    if a.ICAOCode != "" {
+        for _, other := range s.airlines {
+            if other.ICAOCode == a.ICAOCode {
+                return ErrReferenceExists
+            }
+        }
+    }
+    a.UpdatedAt = time.Now()
+    s.airlines[a.IATACode] = *a
+    return nil
+}
+
+func (s *MemoryReferenceStore) UpdateAirline(ctx context.Context, a *models.Airline) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.airlines[a.IATACode]; !ok {
+        return ErrReferenceNotFound
+    }
+    if a.ICAOCode != "" {
+        for code, other := range s.airlines {
+            if code != a.IATACode && other.ICAOCode == a.ICAOCode {
+                return ErrReferenceExists
+            }
+        }
+    }
+    a.UpdatedAt = time.Now()
+    s.airlines[a.IATACode] = *a
+    return nil
+}
+
+func (s *MemoryReferenceStore) DeleteAirline(ctx context.Context, iataCode string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.airlines[iataCode]; !ok {
+        return ErrReferenceNotFound
+    }
+    delete(s.airlines, iataCode)
+    return nil
+}
+
+func (s *MemoryReferenceStore) ListAircraftTypes(ctx context.Context) ([]models.AircraftType, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    
+    types := make([]models.AircraftType, 0, len(s.aircraft))
+    for _, t := range s.aircraft {
+        types = append(types, t)
+    }
+    sort.Slice(types, func(i, j int) bool { return types[i].ICAOCode < types[j].ICAOCode })
+    return types, nil
+}
+
+func (s *MemoryReferenceStore) CreateAircraftType(ctx context.Context, t *models.AircraftType) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.aircraft[t.ICAOCode]; ok {
+        return ErrReferenceExists
+    }
+    t.UpdatedAt = time.Now()
+    s.aircraft[t.ICAOCode] = *t
+    return nil
+}
+
+func (s *MemoryReferenceStore) UpdateAircraftType(ctx context.Context, t *models.AircraftType) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.aircraft[t.ICAOCode]; !ok {
+        return ErrReferenceNotFound
+    }
+    t.UpdatedAt = time.Now()
+    s.aircraft[t.ICAOCode] = *t
+    return nil
+}
+
+func (s *MemoryReferenceStore) DeleteAircraftType(ctx context.Context, icaoCode string) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.aircraft[icaoCode]; !ok {
+        return ErrReferenceNotFound
+    }
+    delete(s.aircraft, icaoCode)
+    return nil
+}
+
+func (s *MemoryReferenceStore) Seed(ctx context.Context, dataset *reference.Dataset) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    now := time.Now()
+    if len(s.airports) == 0 {
+        for _, a := range dataset.Airports {
+            a.UpdatedAt = now
+            s.airports[a.IATACode] = a
+        }
+    }
+    if len(s.airlines) == 0 {
+        for _, a := range dataset.Airlines {
+            a.UpdatedAt = now
+            s.airlines[a.IATACode] = a
+        }
+    }
+    if len(s.aircraft) == 0 {
+        for _, t := range dataset.AircraftTypes {
+            t.UpdatedAt = now
+            s.aircraft[t.ICAOCode] = t
+        }
+    }
+    return nil
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+    "context"
+    "sort"
+    "sync"
+    "time"
+    "skyflow/internal/models"
+)
+
+// Потокобезопасное хранилище рейсов в памяти
+type MemoryFlightStore struct {
+    mu      sync.RWMutex
+    flights map[string]models.Flight
+}
+
+func NewMemoryFlightStore() *MemoryFlightStore {
+    return &MemoryFlightStore{flights: make(map[string]models.Flight)}
+}
+
+func (s *MemoryFlightStore) Create(ctx context.Context, flight *models.Flight, author *models.FlightAuthor) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    for {
+        flight.ID = generateID()
+        if _, exists := s.flights[flight.ID]; !exists {
+            break
+        }
+    }
+    flight.CreatedAt = time.Now().UTC()
+    flight.UpdatedAt = flight.CreatedAt
+    initStatusStamp(flight)
+    flight.ComputeDelay()
+    
+    s.flights[flight.ID] = *flight
+    return nil
+}
+
+func (s *MemoryFlightStore) CreateMany(ctx context.Context, flights []*models.Flight, author *models.FlightAuthor) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    now := time.Now().UTC()
+    for _, flight := range flights {
+        for {
+            flight.ID = generateID()
+            if _, exists := s.flights[flight.ID]; !exists {
+                break
+            }
+        }
+        flight.CreatedAt = now
+        flight.UpdatedAt = now
+        initStatusStamp(flight)
+        flight.ComputeDelay()
+        s.flights[flight.ID] = *flight
+    }
+    return nil
+}
+
+// Все рейсы, отсортированные по времени вылета
+func (s *MemoryFlightStore) GetAll(ctx context.Context) ([]models.Flight, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    
+    flights := make([]models.Flight, 0, len(s.flights))
+    for _, flight := range s.flights {
+        flights = append(flights, flight)
+    }
+    
+    sort.Slice(flights, func(i, j int) bool {
+        if flights[i].Scheduled.Equal(flights[j].Scheduled) {
+            return flights[i].ID < flights[j].ID
+        }
+        return flights[i].Scheduled.Before(flights[j].Scheduled)
+    })
+    
+    return flights, nil
+}
+
+func (s *MemoryFlightStore) List(ctx context.Context, filter models.FlightFilter) (*models.FlightPage, error) {
+    all, err := s.GetAll(ctx)
+    if err != nil {
+        return nil, err
+    }
+    
+    page := &models.FlightPage{Flights: []models.Flight{}}
+    for _, flight := range all {
+        if filter.Match(flight) {
+            page.Flights = append(page.Flights, flight)
+        }
+    }
+    page.Total = len(page.Flights)
+    
+    key := sortKey(filter)
+    // Тот же порядок, что задает курсор: значение ключа, затем ID
+    sort.Slice(page.Flights, func(i, j int) bool {
+        return models.NewFlightCursor(page.Flights[i], key, filter.SortDesc).Before(page.Flights[j])
+    })
+    
+    if filter.After != nil {
+        rest := page.Flights[:0]
+        for _, flight := range page.Flights {
+            if filter.After.Before(flight) {
+                rest = append(rest, flight)
+            }
+        }
+        page.Flights = rest
+    }
+    if filter.Limit > 0 && len(page.Flights) > filter.Limit+1 {
+        page.Flights = page.Flights[:filter.Limit+1]
+    }
+    
+    return paginate(page, filter), nil
+}
+
+func (s *MemoryFlightStore) GetByID(ctx context.Context, id string) (*models.Flight, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    
+    flight, ok := s.flights[id]
+    if !ok {
+        return nil, nil
+    }
+    return &flight, nil
+}
+
+// Текущий рейс с указанным выполняемым или коммерческим номером
+// (см. models.PreferByFlightNumber)
+func (s *MemoryFlightStore) GetByFlightNumber(ctx context.Context, flightNumber string) (*models.Flight, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    
+    now := time.Now()
+    var found *models.Flight
+    for _, flight := range s.flights {
+        if !flight.HasFlightNumber(flightNumber) {
+            continue
+        }
+        if found == nil || models.PreferByFlightNumber(flight, *found, now) {
+            f := flight
+            found = &f
+        }
+    }
+    return found, nil
+}
+
+func (s *MemoryFlightStore) Update(ctx context.Context, flight *models.Flight, author *models.FlightAuthor) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    existing, ok := s.flights[flight.ID]
+    if !ok {
+        return ErrFlightNotFound
+    }
+    if err := checkStatusChange(existing, *flight); err != nil {
+        return err
+    }
+    
+    flight.CreatedAt = existing.CreatedAt
+    flight.UpdatedAt = time.Now().UTC()
+    // Смена статуса через Update — ручная
+    if flight.Status != existing.Status {
+        flight.StatusUpdatedAt = flight.UpdatedAt
+        flight.StatusSource = models.StatusSourceManual
+    } else {
+        flight.StatusUpdatedAt = existing.StatusUpdatedAt
+        flight.StatusSource = existing.StatusSource
+    }
+    flight.ArchivedAt = existing.ArchivedAt
+    flight.ComputeDelay()
+    s.flights[flight.ID] = *flight
+    return nil
+}
+
+func (s *MemoryFlightStore) AdvanceStatus(ctx context.Context, flight *models.Flight, status models.FlightStatus, at time.Time, author *models.FlightAuthor) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    existing, ok := s.flights[flight.ID]
+    if !ok || !unchangedStatus(existing, *flight) {
+        return false, nil
+    }
+    
+    existing.Status = string(status)
+    existing.StatusSource = models.StatusSourceAuto
+    existing.StatusUpdatedAt = at.UTC()
+    existing.UpdatedAt = existing.StatusUpdatedAt
+    s.flights[flight.ID] = existing
+    *flight = existing
+    return true, nil
+}
+
+func (s *MemoryFlightStore) Archive(ctx context.Context, flight *models.Flight, at time.Time, author *models.FlightAuthor) (bool, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    existing, ok := s.flights[flight.ID]
+    if !ok || !unchangedStatus(existing, *flight) {
+        return false, nil
+    }
+    
+    archived := at.UTC()
+    existing.ArchivedAt = &archived
+    existing.UpdatedAt = archived
+    s.flights[flight.ID] = existing
+    *flight = existing
+    return true, nil
+}
+
+// Смена статуса при Update должна быть допустимым переходом от текущего
+// статуса в хранилище (*models.StatusTransitionError)
+func checkStatusChange(current, next models.Flight) error {
+    if current.Status == next.Status {
+        return nil
+    }
+    return models.ValidateStatusTransition(models.FlightStatus(current.Status), models.FlightStatus(next.Status))
+}
+
+// Статус не менялся с момента чтения и рейс не в архиве
+func unchangedStatus(current, read models.Flight) bool {
+    return current.ArchivedAt == nil && current.Status == read.Status && current.StatusUpdatedAt.Equal(read.StatusUpdatedAt)
+}
+
+func (s *MemoryFlightStore) Delete(ctx context.Context, id string, author *models.FlightAuthor) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if _, ok := s.flights[id]; !ok {
+        return ErrFlightNotFound
+    }
+    delete(s.flights, id)
+    return nil
+}
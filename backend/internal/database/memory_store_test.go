@@ -0,0 +1,198 @@
+package database
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "testing"
+    "time"
+    "skyflow/internal/models"
+)
+
+// Рейсы с повторяющимися значениями ключей сортировки: порядок
+// внутри одинаковых значений задает только ID
+func seedPaginationFlights(t *testing.T, store *MemoryFlightStore) {
+    t.Helper()
+    
+    base := time.Date(2026, 11, 2, 12, 0, 0, 0, time.UTC)
+    gates := []string{"A1", "B2", "A1", ""}
+    for i := 0; i < 23; i++ {
+        flight := models.Flight{
+            FlightNumber: fmt.Sprintf("SU %d", 100+i),
+            Scheduled:    base.Add(time.Duration(i/3) * time.Hour),
+            Gate:         gates[i%len(gates)],
+            Status:       string(models.StatusScheduled),
+        }
+        if err := store.Create(context.Background(), &flight, nil); err != nil {
+            t.Fatal(err)
+        }
+    }
+}
+
+// Проходит список страницами по limit рейсов и возвращает ID в порядке выдачи
+func walkPages(t *testing.T, store *MemoryFlightStore, filter models.FlightFilter, limit int) []string {
+    t.Helper()
+    
+    var ids []string
+    for pages := 0; ; pages++ {
+        if pages > 100 {
+            t.Fatal("pagination does not terminate")
+        }
+        filter.Limit = limit
+        page, err := store.List(context.Background(), filter)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if len(page.Flights) > limit {
+            t.Fatalf("page has %d flights, limit %d", len(page.Flights), limit)
+        }
+        for _, flight := range page.Flights {
+            ids = append(ids, flight.ID)
+        }
+        if page.NextCursor == "" {
+            return ids
+        }
+        filter.After, err = models.DecodeFlightCursor(page.NextCursor, filter.SortKey, filter.SortDesc)
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+}
+
+func TestMemoryFlightStoreListPagination(t *testing.T) {
+    store := NewMemoryFlightStore()
+    seedPaginationFlights(t, store)
+    
+    tests := []struct {
+        key  string
+        desc bool
+    }{
+        {models.SortScheduled, false},
+        {models.SortScheduled, true},
+        {models.SortGate, false},
+        {models.SortGate, true},
+        {models.SortStatus, false},
+    }
+    
+    for _, tt := range tests {
+        for _, limit := range []int{1, 4, 5, 23, 50} {
+            t.Run(fmt.Sprintf("%s desc=%v limit=%d", tt.key, tt.desc, limit), func(t *testing.T) {
+                filter := models.FlightFilter{SortKey: tt.key, SortDesc: tt.desc}
+                full, err := store.List(context.Background(), filter)
+                if err != nil {
+                    t.Fatal(err)
+                }
+                if full.Total != 23 || len(full.Flights) != 23 || full.NextCursor != "" {
+                    t.Fatalf("unpaginated list: total %d, %d flights, cursor %q", full.Total, len(full.Flights), full.NextCursor)
+                }
+                
+                got := walkPages(t, store, filter, limit)
+                if len(got) != len(full.Flights) {
+                    t.Fatalf("got %d flights over pages, want %d", len(got), len(full.Flights))
+                }
+                for i, flight := range full.Flights {
+                    if got[i] != flight.ID {
+                        t.Fatalf("position %d: got %s, want %s", i, got[i], flight.ID)
+                    }
+                }
+            })
+        }
+    }
+}
+
+// Рейс, добавленный перед позицией курсора, не сдвигает следующие страницы
+func TestMemoryFlightStoreListCursorSurvivesInsert(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryFlightStore()
+    seedPaginationFlights(t, store)
+    
+    filter := models.FlightFilter{SortKey: models.SortScheduled, Limit: 5}
+    first, err := store.List(ctx, filter)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    early := models.Flight{FlightNumber: "SU 1", Scheduled: time.Date(2026, 11, 2, 6, 0, 0, 0, time.UTC)}
+    if err := store.Create(ctx, &early, nil); err != nil {
+        t.Fatal(err)
+    }
+    
+    filter.After, err = models.DecodeFlightCursor(first.NextCursor, filter.SortKey, filter.SortDesc)
+    if err != nil {
+        t.Fatal(err)
+    }
+    second, err := store.List(ctx, filter)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    seen := make(map[string]bool)
+    for _, flight := range first.Flights {
+        seen[flight.ID] = true
+    }
+    for _, flight := range second.Flights {
+        if seen[flight.ID] || flight.ID == early.ID {
+            t.Errorf("flight %s repeated on the second page", flight.FlightNumber)
+        }
+    }
+    if second.Total != 24 {
+        t.Errorf("Total = %d, want 24", second.Total)
+    }
+    if len(second.Flights) != 5 {
+        t.Errorf("second page has %d flights, want 5", len(second.Flights))
+    }
+}
+
+func TestDecodeFlightCursorRejectsOtherSort(t *testing.T) {
+    store := NewMemoryFlightStore()
+    seedPaginationFlights(t, store)
+    
+    page, err := store.List(context.Background(), models.FlightFilter{SortKey: models.SortScheduled, Limit: 3})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if _, err := models.DecodeFlightCursor(page.NextCursor, models.SortScheduled, true); err != models.ErrInvalidCursor {
+        t.Errorf("cursor for another direction: err = %v, want ErrInvalidCursor", err)
+    }
+    if _, err := models.DecodeFlightCursor(page.NextCursor, models.SortGate, false); err != models.ErrInvalidCursor {
+        t.Errorf("cursor for another key: err = %v, want ErrInvalidCursor", err)
+    }
+    if _, err := models.DecodeFlightCursor("garbage", models.SortScheduled, false); err != models.ErrInvalidCursor {
+        t.Errorf("garbage cursor: err = %v, want ErrInvalidCursor", err)
+    }
+}
+
+// Два изменения по одному прочитанному рейсу: второе проверяется
+// по статусу, который уже записало первое
+func TestMemoryFlightStoreUpdateChecksCurrentStatus(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryFlightStore()
+    flight := models.Flight{FlightNumber: "SU 100", Status: string(models.StatusBoarding)}
+    if err := store.Create(ctx, &flight, nil); err != nil {
+        t.Fatal(err)
+    }
+    
+    departed, cancelled := flight, flight
+    departed.Status = string(models.StatusDeparted)
+    cancelled.Status = string(models.StatusCancelled)
+    
+    if err := store.Update(ctx, &departed, nil); err != nil {
+        t.Fatal(err)
+    }
+    err := store.Update(ctx, &cancelled, nil)
+    var transitionErr *models.StatusTransitionError
+    if !errors.As(err, &transitionErr) || transitionErr.From != models.StatusDeparted {
+        t.Fatalf("second update: err = %v, want transition error from departed", err)
+    }
+    
+    stored, _ := store.GetByID(ctx, flight.ID)
+    if stored.Status != string(models.StatusDeparted) {
+        t.Errorf("status = %s, want departed", stored.Status)
+    }
+    
+    // Без смены статуса проверка не мешает остальным правкам
+    departed.Gate = "12"
+    if err := store.Update(ctx, &departed, nil); err != nil {
+        t.Errorf("update without status change: %v", err)
+    }
+}
@@ -0,0 +1,248 @@
+package database
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "io/fs"
+    "log"
+    "regexp"
+    "sort"
+    "strconv"
+    "time"
+)
+
+// Ключ advisory lock, чтобы несколько реплик не применяли миграции одновременно
+const migrationLockID = 7340021
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-zA-Z0-9_]+)\.(up|down)\.sql$`)
+
+type Migration struct {
+    Version int64
+    Name    string
+    Up      string
+    Down    string
+}
+
+type MigrationStatus struct {
+    Version   int64
+    Name      string
+    Applied   bool
+    AppliedAt *time.Time
+}
+
+type Migrator struct {
+    db         *sql.DB
+    migrations []Migration
+}
+
+// Читает файлы NNN_name.up.sql / NNN_name.down.sql из fsys
+func NewMigrator(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+    entries, err := fs.ReadDir(fsys, ".")
+    if err != nil {
+        return nil, fmt.Errorf("failed to read migrations: %w", err)
+    }
+
+    byVersion := make(map[int64]*Migration)
+    for _, entry := range entries {
+        if entry.IsDir() {
+            continue
+        }
+
+        match := migrationFileRe.FindStringSubmatch(entry.Name())
+        if match == nil {
+            continue
+        }
+
+        version, err := strconv.ParseInt(match[1], 10, 64)
+        if err != nil {
+            return nil, fmt.Errorf("invalid migration version %q: %w", entry.Name(), err)
+        }
+
+        body, err := fs.ReadFile(fsys, entry.Name())
+        if err != nil {
+            return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+        }
+
+        m, ok := byVersion[version]
+        if !ok {
+            m = &Migration{Version: version, Name: match[2]}
+            byVersion[version] = m
+        } else if m.Name != match[2] {
+            return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+        }
+
+        if match[3] == "up" {
+            m.Up = string(body)
+        } else {
+            m.Down = string(body)
+        }
+    }
+
+    migrations := make([]Migration, 0, len(byVersion))
+    for _, m := range byVersion {
+        if m.Up == "" {
+            return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+        }
+        migrations = append(migrations, *m)
+    }
+    sort.Slice(migrations, func(i, j int) bool {
+        return migrations[i].Version < migrations[j].Version
+    })
+
+    return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Применяет все еще не примененные миграции по порядку
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+    applied := 0
+    err := m.withLock(ctx, func(conn *sql.Conn) error {
+        done, err := appliedVersions(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for _, migration := range m.migrations {
+            if _, ok := done[migration.Version]; ok {
+                continue
+            }
+
+            err := runInTx(ctx, conn, migration.Up,
+                `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+                migration.Version, migration.Name)
+            if err != nil {
+                return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+            }
+
+            log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+            applied++
+        }
+        return nil
+    })
+
+    return applied, err
+}
+
+// Откатывает steps последних примененных миграций
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+    rolledBack := 0
+    err := m.withLock(ctx, func(conn *sql.Conn) error {
+        done, err := appliedVersions(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for i := len(m.migrations) - 1; i >= 0 && rolledBack < steps; i-- {
+            migration := m.migrations[i]
+            if _, ok := done[migration.Version]; !ok {
+                continue
+            }
+
+            if migration.Down == "" {
+                return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+            }
+
+            err := runInTx(ctx, conn, migration.Down,
+                `DELETE FROM schema_migrations WHERE version = $1`,
+                migration.Version)
+            if err != nil {
+                return fmt.Errorf("rollback of %d_%s failed: %w", migration.Version, migration.Name, err)
+            }
+
+            log.Printf("Rolled back migration %d_%s", migration.Version, migration.Name)
+            rolledBack++
+        }
+        return nil
+    })
+
+    return rolledBack, err
+}
+
+// Список всех известных миграций с отметкой о применении
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+    var statuses []MigrationStatus
+    err := m.withLock(ctx, func(conn *sql.Conn) error {
+        done, err := appliedVersions(ctx, conn)
+        if err != nil {
+            return err
+        }
+
+        for _, migration := range m.migrations {
+            status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+            if appliedAt, ok := done[migration.Version]; ok {
+                status.Applied = true
+                status.AppliedAt = &appliedAt
+            }
+            statuses = append(statuses, status)
+        }
+        return nil
+    })
+
+    return statuses, err
+}
+
+// Выполняет fn на отдельном соединении под pg_advisory_lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+    conn, err := m.db.Conn(ctx)
+    if err != nil {
+        return fmt.Errorf("failed to get connection: %w", err)
+    }
+    defer conn.Close()
+
+    if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+        return fmt.Errorf("failed to acquire migration lock: %w", err)
+    }
+    defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+    _, err = conn.ExecContext(ctx, `
+        CREATE TABLE IF NOT EXISTS schema_migrations (
+            version BIGINT PRIMARY KEY,
+            name TEXT NOT NULL,
+            applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+        )
+    `)
+    if err != nil {
+        return fmt.Errorf("failed to create schema_migrations: %w", err)
+    }
+
+    return fn(conn)
+}
+
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+    rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+    }
+    defer rows.Close()
+
+    versions := make(map[int64]time.Time)
+    for rows.Next() {
+        var version int64
+        var appliedAt time.Time
+        if err := rows.Scan(&version, &appliedAt); err != nil {
+            return nil, err
+        }
+        versions[version] = appliedAt
+    }
+
+    return versions, rows.Err()
+}
+
+// Скрипт миграции и запись в schema_migrations в одной транзакции
+func runInTx(ctx context.Context, conn *sql.Conn, script, bookkeeping string, args ...interface{}) error {
+    tx, err := conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, script); err != nil {
+        return err
+    }
+
+    if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+    "context"
+    "log"
+    "sync"
+    "time"
+    "skyflow/internal/models"
+)
+
+// Снимок справочников в памяти для проверки кодов и подписей на табло.
+// Перечитывается после изменений через API и периодически —
+// чтобы увидеть изменения, сделанные другими репликами
+type ReferenceCache struct {
+    store ReferenceStore
+    
+    mu   sync.RWMutex
+    data *models.ReferenceData
+}
+
+func NewReferenceCache(store ReferenceStore) *ReferenceCache {
+    return &ReferenceCache{
+        store: store,
+        data:  models.NewReferenceData(nil, nil, nil),
+    }
+}
+
+func (c *ReferenceCache) Store() ReferenceStore {
+    return c.store
+}
+
+func (c *ReferenceCache) Data() *models.ReferenceData {
+    c.mu.RLock()
+    defer c.mu.RUnlock()
+    return c.data
+}
+
+func (c *ReferenceCache) Reload(ctx context.Context) error {
+    airports, err := c.store.ListAirports(ctx)
+    if err != nil {
+        return err
+    }
+    airlines, err := c.store.ListAirlines(ctx)
+    if err != nil {
+        return err
+    }
+    aircraft, err := c.store.ListAircraftTypes(ctx)
+    if err != nil {
+        return err
+    }
+    
+    data := models.NewReferenceData(airports, airlines, aircraft)
+    
+    c.mu.Lock()
+    c.data = data
+    c.mu.Unlock()
+    return nil
+}
+
+// Периодически перечитывает справочники до отмены ctx
+func (c *ReferenceCache) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := c.Reload(ctx); err != nil {
+                log.Printf("Failed to reload reference data: %v", err)
+            }
+        }
+    }
+}
+
+// Рейс с названиями из справочников
+func (c *ReferenceCache) Enrich(flight models.Flight) models.Flight {
+    return c.Data().Enrich(flight)
+}
@@ -0,0 +1,256 @@
+package database
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+    "skyflow/internal/models"
+    "skyflow/reference"
+    "github.com/lib/pq"
+)
+
+type ReferenceRepository struct {
+    db *sql.DB
+}
+
+func NewReferenceRepository(db *sql.DB) *ReferenceRepository {
+    return &ReferenceRepository{db: db}
+}
+
+func (r *ReferenceRepository) ListAirports(ctx context.Context) ([]models.Airport, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT iata_code, COALESCE(icao_code, ''), name, city, country, timezone, updated_at
+        FROM airports ORDER BY iata_code
+    `)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list airports: %w", err)
+    }
+    defer rows.Close()
+    
+    airports := []models.Airport{}
+    for rows.Next() {
+        var a models.Airport
+        if err := rows.Scan(&a.IATACode, &a.ICAOCode, &a.Name, &a.City, &a.Country, &a.Timezone, &a.UpdatedAt); err != nil {
+            return nil, err
+        }
+        airports = append(airports, a)
+    }
+    return airports, rows.Err()
+}
+
+func (r *ReferenceRepository) CreateAirport(ctx context.Context, a *models.Airport) error {
+    a.UpdatedAt = time.Now()
+    return r.insert(ctx, `
+        INSERT INTO airports (iata_code, icao_code, name, city, country, timezone, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, a.IATACode, nullString(a.ICAOCode), a.Name, a.City, a.Country, a.Timezone, a.UpdatedAt)
+}
+
+func (r *ReferenceRepository) UpdateAirport(ctx context.Context, a *models.Airport) error {
+    a.UpdatedAt = time.Now()
+    return r.update(ctx, `
+        UPDATE airports SET icao_code = $2, name = $3, city = $4, country = $5, timezone = $6, updated_at = $7
+        WHERE iata_code = $1
+    `, a.IATACode, nullString(a.ICAOCode), a.Name, a.City, a.Country, a.Timezone, a.UpdatedAt)
+}
+
+func (r *ReferenceRepository) DeleteAirport(ctx context.Context, iataCode string) error {
+    return r.update(ctx, `DELETE FROM airports WHERE iata_code = $1`, iataCode)
+}
+
+func (r *ReferenceRepository) ListAirlines(ctx context.Context) ([]models.Airline, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT iata_code, COALESCE(icao_code, ''), name, country, logo_url, updated_at
+        FROM airlines ORDER BY iata_code
+    `)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list airlines: %w", err)
+    }
+    defer rows.Close()
+    
+    airlines := []models.Airline{}
+    for rows.Next() {
+        var a models.Airline
+        if err := rows.Scan(&a.IATACode, &a.ICAOCode, &a.Name, &a.Country, &a.LogoURL, &a.UpdatedAt); err != nil {
+            return nil, err
+        }
+        airlines = append(airlines, a)
+    }
+    return airlines, rows.Err()
+}
+
+func (r *ReferenceRepository) CreateAirline(ctx context.Context, a *models.Airline) error {
+    a.UpdatedAt = time.Now()
+    return r.insert(ctx, `
+        INSERT INTO airlines (iata_code, icao_code, name, country, logo_url, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+    `, a.IATACode, nullString(a.ICAOCode), a.Name, a.Country, a.LogoURL, a.UpdatedAt)
+}
+
+func (r *ReferenceRepository) UpdateAirline(ctx context.Context, a *models.Airline) error {
+    a.UpdatedAt = time.Now()
+    return r.update(ctx, `
+        UPDATE airlines SET icao_code = $2, name = $3, country = $4, logo_url = $5, updated_at = $6
+        WHERE iata_code = $1
+    `, a.IATACode, nullString(a.ICAOCode), a.Name, a.Country, a.LogoURL, a.UpdatedAt)
+}
+
+func (r *ReferenceRepository) DeleteAirline(ctx context.Context, iataCode string) error {
+    return r.update(ctx, `DELETE FROM airlines WHERE iata_code = $1`, iataCode)
+}
+
+func (r *ReferenceRepository) ListAircraftTypes(ctx context.Context) ([]models.AircraftType, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT icao_code, COALESCE(iata_code, ''), name, manufacturer, updated_at
+        FROM aircraft_types ORDER BY icao_code
+    `)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list aircraft types: %w", err)
+    }
+    defer rows.Close()
+    
+    types := []models.AircraftType{}
+    for rows.Next() {
+        var t models.AircraftType
+        if err := rows.Scan(&t.ICAOCode, &t.IATACode, &t.Name, &t.Manufacturer, &t.UpdatedAt); err != nil {
+            return nil, err
+        }
+        types = append(types, t)
+    }
+    return types, rows.Err()
+}
+
+func (r *ReferenceRepository) CreateAircraftType(ctx context.Context, t *models.AircraftType) error {
+    t.UpdatedAt = time.Now()
+    return r.insert(ctx, `
+        INSERT INTO aircraft_types (icao_code, iata_code, name, manufacturer, updated_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `, t.ICAOCode, nullString(t.IATACode), t.Name, t.Manufacturer, t.UpdatedAt)
+}
+
+func (r *ReferenceRepository) UpdateAircraftType(ctx context.Context, t *models.AircraftType) error {
+    t.UpdatedAt = time.Now()
+    return r.update(ctx, `
+        UPDATE aircraft_types SET iata_code = $2, name = $3, manufacturer = $4, updated_at = $5
+        WHERE icao_code = $1
+    `, t.ICAOCode, nullString(t.IATACode), t.Name, t.Manufacturer, t.UpdatedAt)
+}
+
+func (r *ReferenceRepository) DeleteAircraftType(ctx context.Context, icaoCode string) error {
+    return r.update(ctx, `DELETE FROM aircraft_types WHERE icao_code = $1`, icaoCode)
+}
+
+// Заполняет только пустые таблицы: удаленные администратором записи
+// не должны возвращаться при перезапуске. Затем приводит старые рейсы,
+// где авиакомпания записана названием, к коду IATA
+func (r *ReferenceRepository) Seed(ctx context.Context, dataset *reference.Dataset) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    
+    // Одна реплика заполняет, остальные ждут и видят заполненные таблицы
+    if _, err := tx.ExecContext(ctx, `LOCK TABLE airports, airlines, aircraft_types IN EXCLUSIVE MODE`); err != nil {
+        return fmt.Errorf("failed to lock reference tables: %w", err)
+    }
+    
+    if empty, err := tableEmpty(ctx, tx, "airports"); err != nil {
+        return err
+    } else if empty {
+        for _, a := range dataset.Airports {
+            _, err := tx.ExecContext(ctx, `
+                INSERT INTO airports (iata_code, icao_code, name, city, country, timezone)
+                VALUES ($1, $2, $3, $4, $5, $6)
+            `, a.IATACode, nullString(a.ICAOCode), a.Name, a.City, a.Country, a.Timezone)
+            if err != nil {
+                return fmt.Errorf("failed to seed airport %s: %w", a.IATACode, err)
+            }
+        }
+    }
+    
+    if empty, err := tableEmpty(ctx, tx, "airlines"); err != nil {
+        return err
+    } else if empty {
+        for _, a := range dataset.Airlines {
+            _, err := tx.ExecContext(ctx, `
+                INSERT INTO airlines (iata_code, icao_code, name, country, logo_url)
+                VALUES ($1, $2, $3, $4, $5)
+            `, a.IATACode, nullString(a.ICAOCode), a.Name, a.Country, a.LogoURL)
+            if err != nil {
+                return fmt.Errorf("failed to seed airline %s: %w", a.IATACode, err)
+            }
+        }
+    }
+    
+    // Рейсы, в которых авиакомпания записана названием (до справочников),
+    // получают код IATA — по нему работают фильтры, права ключей и названия
+    _, err = tx.ExecContext(ctx, `
+        UPDATE flights SET airline = a.iata_code
+        FROM airlines a
+        WHERE LOWER(flights.airline) = LOWER(a.name) AND flights.airline <> a.iata_code
+    `)
+    if err != nil {
+        return fmt.Errorf("failed to normalize flight airlines: %w", err)
+    }
+    
+    if empty, err := tableEmpty(ctx, tx, "aircraft_types"); err != nil {
+        return err
+    } else if empty {
+        for _, t := range dataset.AircraftTypes {
+            _, err := tx.ExecContext(ctx, `
+                INSERT INTO aircraft_types (icao_code, iata_code, name, manufacturer)
+                VALUES ($1, $2, $3, $4)
+            `, t.ICAOCode, nullString(t.IATACode), t.Name, t.Manufacturer)
+            if err != nil {
+                return fmt.Errorf("failed to seed aircraft type %s: %w", t.ICAOCode, err)
+            }
+        }
+    }
+    
+    _, err = tx.ExecContext(ctx, `
+        UPDATE flights SET airline = a.iata_code
+        FROM airlines a
+        WHERE flights.airline <> a.iata_code
+          AND (LOWER(flights.airline) = LOWER(a.name) OR UPPER(flights.airline) IN (a.iata_code, a.icao_code))
+    `)
+    if err != nil {
+        return fmt.Errorf("failed to normalize flight airlines: %w", err)
+    }
+    
+    return tx.Commit()
+}
+
+func tableEmpty(ctx context.Context, tx *sql.Tx, table string) (bool, error) {
+    var exists bool
+    if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+`)`).Scan(&exists); err != nil {
+        return false, fmt.Errorf("failed to check %s: %w", table, err)
+    }
+    return !exists, nil
+}
+
+func (r *ReferenceRepository) insert(ctx context.Context, query string, args ...interface{}) error {
+    if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+        if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+            return ErrReferenceExists
+        }
+        return fmt.Errorf("failed to save reference entry: %w", err)
+    }
+    return nil
+}
+
+func (r *ReferenceRepository) update(ctx context.Context, query string, args ...interface{}) error {
+    result, err := r.db.ExecContext(ctx, query, args...)
+    if err != nil {
+        if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+            return ErrReferenceExists
+        }
+        return fmt.Errorf("failed to save reference entry: %w", err)
+    }
+    
+    if rows, _ := result.RowsAffected(); rows == 0 {
+        return ErrReferenceNotFound
+    }
+    return nil
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+    "context"
+    "errors"
+    "skyflow/internal/models"
+    "skyflow/reference"
+)
+
+var (
+    ErrReferenceNotFound = errors.New("reference entry not found")
+    ErrReferenceExists   = errors.New("reference entry already exists")
+)
+
+// Справочники аэропортов, авиакомпаний и типов ВС. Реализуется
+// ReferenceRepository (Postgres) и MemoryReferenceStore (демо-режим)
+type ReferenceStore interface {
+    ListAirports(ctx context.Context) ([]models.Airport, error)
+    CreateAirport(ctx context.Context, airport *models.Airport) error
+    UpdateAirport(ctx context.Context, airport *models.Airport) error
+    DeleteAirport(ctx context.Context, iataCode string) error
+    
+    ListAirlines(ctx context.Context) ([]models.Airline, error)
+    CreateAirline(ctx context.Context, airline *models.Airline) error
+    UpdateAirline(ctx context.Context, airline *models.Airline) error
+    DeleteAirline(ctx context.Context, iataCode string) error
+    
+    ListAircraftTypes(ctx context.Context) ([]models.AircraftType, error)
+    CreateAircraftType(ctx context.Context, aircraft *models.AircraftType) error
+    UpdateAircraftType(ctx context.Context, aircraft *models.AircraftType) error
+    DeleteAircraftType(ctx context.Context, icaoCode string) error
+    
+    // Заполняет пустые справочники встроенными данными
+    Seed(ctx context.Context, dataset *reference.Dataset) error
+}
+
+var (
+    _ ReferenceStore = (*ReferenceRepository)(nil)
+    _ ReferenceStore = (*MemoryReferenceStore)(nil)
+)
@@ -0,0 +1,261 @@
+package database
+
+import (
+    "context"
+    "errors"
+    "log"
+    "time"
+    "skyflow/internal/models"
+)
+
+// Имя в журнале изменений для рейсов, созданных фоновой генерацией
+const schedulerUsername = "scheduler"
+
+var schedulerAuthor = &models.FlightAuthor{Username: schedulerUsername}
+
+// Создает рейсы по сезонным расписаниям на horizon дней вперед
+type ScheduleGenerator struct {
+    schedules *ScheduleRepository
+    flights   *FlightRepository
+    reference *ReferenceCache
+    horizon   int
+}
+
+func NewScheduleGenerator(schedules *ScheduleRepository, flights *FlightRepository, reference *ReferenceCache, horizonDays int) *ScheduleGenerator {
+    return &ScheduleGenerator{
+        schedules: schedules,
+        flights:   flights,
+        reference: reference,
+        horizon:   horizonDays,
+    }
+}
+
+// Приводит рейсы расписания на ближайшие дни в соответствие с ним:
+// создает недостающие, обновляет и убирает лишние. Рейсы, измененные
+// вручную или уже не в статусе scheduled, не трогает. Повторный запуск
+// ничего не меняет. Лишние рейсы удаляются только при remove (у того, кто
+// запустил генерацию, есть право flights:delete), иначе считаются в Kept.
+// Изменения пишутся в журнал от имени author
+func (g *ScheduleGenerator) Generate(ctx context.Context, s *models.FlightSchedule, remove bool, author *models.FlightAuthor) (models.ScheduleGenerateResult, error) {
+    fromZone, _ := g.reference.Data().Location(s.From)
+    today := time.Now().In(fromZone)
+    return g.GenerateRange(ctx, s, today, today.AddDate(0, 0, g.horizon), remove, author)
+}
+
+// То же, что Generate, но для местных дат вылета от from до to — например,
+// чтобы сразу создать рейсы на весь сезон
+func (g *ScheduleGenerator) GenerateRange(ctx context.Context, s *models.FlightSchedule, from, to time.Time, remove bool, author *models.FlightAuthor) (models.ScheduleGenerateResult, error) {
+    result := models.ScheduleGenerateResult{ScheduleID: s.ID}
+    
+    data := g.reference.Data()
+    fromZone, _ := data.Location(s.From)
+    toZone, _ := data.Location(s.To)
+    now := time.Now()
+    
+    exceptions, err := g.schedules.Exceptions(ctx, s.ID)
+    if err != nil {
+        return result, err
+    }
+    existing, err := g.schedules.ListInstances(ctx, s.ID, from, to)
+    if err != nil {
+        return result, err
+    }
+    byDate := make(map[string]models.Flight, len(existing))
+    for _, f := range existing {
+        byDate[f.ServiceDate] = f
+    }
+    
+    desired := make(map[string]bool)
+    for _, date := range s.Dates(from, to) {
+        key := date.Format(models.DateLayout)
+        if exceptions[key] {
+            continue
+        }
+        
+        flight, err := s.Instance(date, fromZone, toZone)
+        if err != nil {
+            return result, err
+        }
+        // Рейс, который уже должен был вылететь, задним числом не создаем
+        if !flight.Scheduled.After(now) {
+            continue
+        }
+        desired[key] = true
+        
+        current, ok := byDate[key]
+        if !ok {
+            created, err := g.schedules.InsertInstance(ctx, &flight, author)
+            if err != nil {
+                return result, err
+            }
+            if created {
+                result.Created++
+            }
+            continue
+        }
+        
+        if !generated(current, now) {
+            continue
+        }
+        changes := models.DiffFlights(current, flight)
+        if len(changes) == 0 {
+            continue
+        }
+        
+        // Рейс могли изменить после чтения — тогда он уже не наш
+        flight.ID = current.ID
+        updated, err := g.schedules.UpdateInstance(ctx, &flight, changes, author)
+        if err != nil {
+            return result, err
+        }
+        if updated {
+            result.Updated++
+        }
+    }
+    
+    for key, current := range byDate {
+        if desired[key] || !generated(current, now) {
+            continue
+        }
+        if !remove {
+            result.Kept++
+            continue
+        }
+        removed, err := g.remove(ctx, current, author)
+        if err != nil {
+            return result, err
+        }
+        if removed {
+            result.Removed++
+        }
+    }
+    
+    return result, nil
+}
+
+// Генерация по всем расписаниям. Ошибка одного расписания не мешает остальным.
+// Фоновая генерация только создает и обновляет рейсы: удалять их за того,
+// кто менял расписание без права flights:delete, она не должна
+func (g *ScheduleGenerator) GenerateAll(ctx context.Context) error {
+    schedules, err := g.schedules.List(ctx)
+    if err != nil {
+        return err
+    }
+    
+    for i := range schedules {
+        result, err := g.Generate(ctx, &schedules[i], false, schedulerAuthor)
+        if err != nil {
+            log.Printf("Failed to generate flights for schedule %s (%s): %v", schedules[i].ID, schedules[i].FlightNumber, err)
+            continue
+        }
+        if result.Created+result.Updated+result.Removed > 0 {
+            log.Printf("Schedule %s (%s): %d created, %d updated, %d removed",
+                schedules[i].ID, schedules[i].FlightNumber, result.Created, result.Updated, result.Removed)
+        }
+    }
+    return nil
+}
+
+// Генерирует рейсы сразу и затем каждые interval до отмены ctx
+func (g *ScheduleGenerator) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    
+    for {
+        if err := g.GenerateAll(ctx); err != nil {
+            log.Printf("Failed to generate scheduled flights: %v", err)
+        }
+        
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+// Убирает будущие рейсы расписания, которые генератор создал и никто не менял.
+// Вызывается перед удалением расписания (нужно право flights:delete)
+func (g *ScheduleGenerator) Retire(ctx context.Context, s *models.FlightSchedule, author *models.FlightAuthor) (int, error) {
+    fromZone, _ := g.reference.Data().Location(s.From)
+    now := time.Now()
+    
+    existing, err := g.schedules.ListInstances(ctx, s.ID, now.In(fromZone), time.Time{})
+    if err != nil {
+        return 0, err
+    }
+    
+    count := 0
+    for _, f := range existing {
+        if !generated(f, now) {
+            continue
+        }
+        removed, err := g.remove(ctx, f, author)
+        if err != nil {
+            return count, err
+        }
+        if removed {
+            count++
+        }
+    }
+    return count, nil
+}
+
+// Отмена рейса расписания на одну дату. Дата исключается из расписания,
+// а уже созданный рейс получает статус cancelled и остается на табло
+func (g *ScheduleGenerator) Cancel(ctx context.Context, s *models.FlightSchedule, serviceDate string, author *models.FlightAuthor) (*models.Flight, error) {
+    if err := g.schedules.AddException(ctx, s.ID, serviceDate); err != nil {
+        return nil, err
+    }
+    
+    date, err := time.Parse(models.DateLayout, serviceDate)
+    if err != nil {
+        return nil, err
+    }
+    instances, err := g.schedules.ListInstances(ctx, s.ID, date, date)
+    if err != nil {
+        return nil, err
+    }
+    
+    for _, f := range instances {
+        if f.ServiceDate != serviceDate {
+            continue
+        }
+        if models.ValidateStatusTransition(models.FlightStatus(f.Status), models.StatusCancelled) != nil {
+            // Уже вылетел или отменен — менять нечего
+            return &f, nil
+        }
+        
+        f.Status = string(models.StatusCancelled)
+        f.ScheduleOverride = true
+        err := g.flights.Update(ctx, &f, author)
+        var transitionErr *models.StatusTransitionError
+        if errors.As(err, &transitionErr) {
+            // Рейс успел вылететь — отдаем его как есть
+            return g.flights.GetByID(ctx, f.ID)
+        }
+        if err != nil {
+            return nil, err
+        }
+        return &f, nil
+    }
+    
+    return nil, nil
+}
+
+func (g *ScheduleGenerator) remove(ctx context.Context, flight models.Flight, author *models.FlightAuthor) (bool, error) {
+    err := g.flights.Delete(ctx, flight.ID, author)
+    if errors.Is(err, ErrFlightNotFound) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    return true, nil
+}
+
+// Рейс еще под управлением генератора: не правился вручную,
+// не сменил статус и не вылетел
+func generated(f models.Flight, now time.Time) bool {
+    return !f.ScheduleOverride && f.Status == string(models.StatusScheduled) && f.Scheduled.After(now)
+}
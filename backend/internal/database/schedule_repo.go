@@ -0,0 +1,357 @@
+package database
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+    "skyflow/internal/models"
+)
+
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+type ScheduleRepository struct {
+    db *sql.DB
+}
+
+func NewScheduleRepository(db *sql.DB) *ScheduleRepository {
+    return &ScheduleRepository{db: db}
+}
+
+const scheduleColumns = `id, flight_number, airline, origin, destination,
+    COALESCE(aircraft_type, ''), days_of_week,
+    to_char(valid_from, 'YYYY-MM-DD'), to_char(valid_to, 'YYYY-MM-DD'),
+    departure_time, COALESCE(arrival_time, ''), arrival_day_offset,
+    COALESCE(terminal, ''), COALESCE(gate, ''), COALESCE(source_key, ''),
+    created_at, updated_at`
+
+func scanSchedule(row rowScanner) (*models.FlightSchedule, error) {
+    var s models.FlightSchedule
+    err := row.Scan(
+        &s.ID,
+        &s.FlightNumber,
+        &s.Airline,
+        &s.From,
+        &s.To,
+        &s.Aircraft,
+        &s.DaysOfWeek,
+        &s.ValidFrom,
+        &s.ValidTo,
+        &s.DepartureTime,
+        &s.ArrivalTime,
+        &s.ArrivalDayOffset,
+        &s.Terminal,
+        &s.Gate,
+        &s.SourceKey,
+        &s.CreatedAt,
+        &s.UpdatedAt,
+    )
+    if err != nil {
+        return nil, err
+    }
+    return &s, nil
+}
+
+// Все расписания по номеру рейса
+func (r *ScheduleRepository) List(ctx context.Context) ([]models.FlightSchedule, error) {
+    rows, err := r.db.QueryContext(ctx, `SELECT `+scheduleColumns+` FROM flight_schedules ORDER BY flight_number, valid_from`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list schedules: %w", err)
+    }
+    defer rows.Close()
+    
+    schedules := []models.FlightSchedule{}
+    for rows.Next() {
+        s, err := scanSchedule(rows)
+        if err != nil {
+            return nil, err
+        }
+        schedules = append(schedules, *s)
+    }
+    
+    return schedules, rows.Err()
+}
+
+func (r *ScheduleRepository) GetByID(ctx context.Context, id string) (*models.FlightSchedule, error) {
+    s, err := scanSchedule(r.db.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM flight_schedules WHERE id = $1`, id))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get schedule: %w", err)
+    }
+    return s, nil
+}
+
+// Расписание по ключу источника импорта
+func (r *ScheduleRepository) GetBySourceKey(ctx context.Context, sourceKey string) (*models.FlightSchedule, error) {
+    s, err := scanSchedule(r.db.QueryRowContext(ctx, `SELECT `+scheduleColumns+` FROM flight_schedules WHERE source_key = $1`, sourceKey))
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get schedule: %w", err)
+    }
+    return s, nil
+}
+
+func (r *ScheduleRepository) Create(ctx context.Context, s *models.FlightSchedule) error {
+    s.ID = generateID()
+    s.CreatedAt = time.Now().UTC()
+    s.UpdatedAt = s.CreatedAt
+    
+    _, err := r.db.ExecContext(ctx, `
+        INSERT INTO flight_schedules (
+            id, flight_number, airline, origin, destination, aircraft_type,
+            days_of_week, valid_from, valid_to, departure_time, arrival_time,
+            arrival_day_offset, terminal, gate, source_key, created_at, updated_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+    `,
+        s.ID,
+        s.FlightNumber,
+        s.Airline,
+        s.From,
+        s.To,
+        nullString(s.Aircraft),
+        s.DaysOfWeek,
+        s.ValidFrom,
+        s.ValidTo,
+        s.DepartureTime,
+        nullString(s.ArrivalTime),
+        s.ArrivalDayOffset,
+        nullString(s.Terminal),
+        nullString(s.Gate),
+        nullString(s.SourceKey),
+        s.CreatedAt,
+        s.UpdatedAt,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to create schedule: %w", err)
+    }
+    return nil
+}
+
+func (r *ScheduleRepository) Update(ctx context.Context, s *models.FlightSchedule) error {
+    s.UpdatedAt = time.Now().UTC()
+    
+    result, err := r.db.ExecContext(ctx, `
+        UPDATE flight_schedules SET
+            flight_number = $1,
+            airline = $2,
+            origin = $3,
+            destination = $4,
+            aircraft_type = $5,
+            days_of_week = $6,
+            valid_from = $7,
+            valid_to = $8,
+            departure_time = $9,
+            arrival_time = $10,
+            arrival_day_offset = $11,
+            terminal = $12,
+            gate = $13,
+            updated_at = $14
+        WHERE id = $15
+    `,
+        s.FlightNumber,
+        s.Airline,
+        s.From,
+        s.To,
+        nullString(s.Aircraft),
+        s.DaysOfWeek,
+        s.ValidFrom,
+        s.ValidTo,
+        s.DepartureTime,
+        nullString(s.ArrivalTime),
+        s.ArrivalDayOffset,
+        nullString(s.Terminal),
+        nullString(s.Gate),
+        s.UpdatedAt,
+        s.ID,
+    )
+    if err != nil {
+        return fmt.Errorf("failed to update schedule: %w", err)
+    }
+    
+    if rows, _ := result.RowsAffected(); rows == 0 {
+        return ErrScheduleNotFound
+    }
+    return nil
+}
+
+// Удаление расписания. Созданные рейсы остаются, но теряют связь с ним
+func (r *ScheduleRepository) Delete(ctx context.Context, id string) error {
+    result, err := r.db.ExecContext(ctx, `DELETE FROM flight_schedules WHERE id = $1`, id)
+    if err != nil {
+        return fmt.Errorf("failed to delete schedule: %w", err)
+    }
+    
+    if rows, _ := result.RowsAffected(); rows == 0 {
+        return ErrScheduleNotFound
+    }
+    return nil
+}
+
+// Рейсы расписания с местными датами вылета от from до to включительно.
+// Нулевое to — без верхней границы
+func (r *ScheduleRepository) ListInstances(ctx context.Context, scheduleID string, from, to time.Time) ([]models.Flight, error) {
+    var until interface{}
+    if !to.IsZero() {
+        until = to.Format(models.DateLayout)
+    }
+    
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT `+flightColumns+` FROM flights
+        WHERE schedule_id = $1 AND service_date >= $2 AND ($3::date IS NULL OR service_date <= $3::date)
+        ORDER BY service_date
+    `, scheduleID, from.Format(models.DateLayout), until)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list schedule flights: %w", err)
+    }
+    defer rows.Close()
+    
+    flights := []models.Flight{}
+    for rows.Next() {
+        flight, err := scanFlight(rows)
+        if err != nil {
+            return nil, err
+        }
+        flights = append(flights, *flight)
+    }
+    
+    return flights, rows.Err()
+}
+
+// Создает рейс расписания, если на эту дату его еще нет, и пишет его
+// в журнал от имени author. false — рейс уже был (в том числе созданный
+// другой репликой)
+func (r *ScheduleRepository) InsertInstance(ctx context.Context, flight *models.Flight, author *models.FlightAuthor) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+    
+    flight.ID = generateID()
+    flight.CreatedAt = time.Now().UTC()
+    flight.UpdatedAt = flight.CreatedAt
+    initStatusStamp(flight)
+    flight.ComputeDelay()
+    
+    err = tx.QueryRowContext(ctx,
+        flightInsert+` ON CONFLICT (schedule_id, service_date) DO NOTHING RETURNING id`,
+        flightInsertArgs(flight)...,
+    ).Scan(&flight.ID)
+    if err == sql.ErrNoRows {
+        return false, nil
+    }
+    if err != nil {
+        return false, fmt.Errorf("failed to create schedule flight: %w", err)
+    }
+    
+    if author != nil {
+        if err := insertFlightEvent(ctx, tx, author.Event(models.FlightEventCreated, *flight, models.FlightSnapshot(*flight, false))); err != nil {
+            return false, err
+        }
+    }
+    return true, tx.Commit()
+}
+
+// Обновляет рейс расписания по шаблону (номер, маршрут, время, терминал,
+// выход, тип ВС), только если рейс все еще под управлением генератора: не
+// правился вручную, в статусе scheduled и не в архиве. Проверка — в самом
+// UPDATE, чтобы не затереть правку, сделанную после чтения. false — рейс
+// изменили или удалили, changes пишутся в журнал от имени author
+func (r *ScheduleRepository) UpdateInstance(ctx context.Context, flight *models.Flight, changes map[string]models.FieldChange, author *models.FlightAuthor) (bool, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+    
+    query := `
+        UPDATE flights SET
+            flight_number = $1,
+            airline = $2,
+            origin = $3,
+            destination = $4,
+            aircraft_type = $5,
+            scheduled_time = $6,
+            actual_time = $7,
+            terminal = $8,
+            gate = $9,
+            scheduled_arrival_time = $10,
+            updated_at = $11
+        WHERE id = $12
+            AND schedule_override = false
+            AND status = 'scheduled'
+            AND archived_at IS NULL
+        RETURNING ` + flightColumns
+    
+    updated, err := scanFlight(tx.QueryRowContext(ctx, query,
+        flight.FlightNumber,
+        flight.Airline,
+        flight.From,
+        flight.To,
+        nullString(flight.Aircraft),
+        flight.Scheduled,
+        flight.Actual,
+        flight.Terminal,
+        flight.Gate,
+        flight.ScheduledArrival,
+        time.Now().UTC(),
+        flight.ID,
+    ))
+    if err == sql.ErrNoRows {
+        return false, nil
+    }
+    if err != nil {
+        return false, fmt.Errorf("failed to update schedule flight: %w", err)
+    }
+    
+    if author != nil && len(changes) > 0 {
+        if err := insertFlightEvent(ctx, tx, author.Event(models.FlightEventUpdated, *updated, changes)); err != nil {
+            return false, err
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return false, err
+    }
+    *flight = *updated
+    return true, nil
+}
+
+// Дата исключается из расписания: генератор не будет создавать на нее рейс
+func (r *ScheduleRepository) AddException(ctx context.Context, scheduleID, serviceDate string) error {
+    _, err := r.db.ExecContext(ctx, `
+        INSERT INTO flight_schedule_exceptions (schedule_id, service_date)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING
+    `, scheduleID, serviceDate)
+    if err != nil {
+        return fmt.Errorf("failed to add schedule exception: %w", err)
+    }
+    return nil
+}
+
+// Исключенные даты расписания
+func (r *ScheduleRepository) Exceptions(ctx context.Context, scheduleID string) (map[string]bool, error) {
+    rows, err := r.db.QueryContext(ctx, `
+        SELECT to_char(service_date, 'YYYY-MM-DD') FROM flight_schedule_exceptions WHERE schedule_id = $1
+    `, scheduleID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list schedule exceptions: %w", err)
+    }
+    defer rows.Close()
+    
+    dates := make(map[string]bool)
+    for rows.Next() {
+        var date string
+        if err := rows.Scan(&date); err != nil {
+            return nil, err
+        }
+        dates[date] = true
+    }
+    
+    return dates, rows.Err()
+}
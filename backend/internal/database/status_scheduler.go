@@ -0,0 +1,153 @@
+package database
+
+import (
+    "context"
+    "log"
+    "time"
+    "skyflow/internal/events"
+    "skyflow/internal/models"
+)
+
+// Имя в журнале изменений для автоматической смены статусов
+const statusSchedulerUsername = "status-scheduler"
+
+// Автор автоматических изменений; запись журнала пишет хранилище
+var statusSchedulerAuthor = &models.FlightAuthor{Username: statusSchedulerUsername}
+
+// Двигает статусы рейсов по времени (регистрация, посадка, последний вызов,
+// выход закрыт, вылетел, приземлился) и убирает завершенные рейсы в архив.
+// Статус, выставленный вручную после начала очередного этапа, не трогает
+type StatusScheduler struct {
+    flights FlightStore
+    // nil, если изменения публикует FlightChangeListener (LISTEN/NOTIFY)
+    events  *events.Broker
+    timings models.StatusTimings
+}
+
+func NewStatusScheduler(flights FlightStore, broker *events.Broker, timings models.StatusTimings) *StatusScheduler {
+    return &StatusScheduler{
+        flights: flights,
+        events:  broker,
+        timings: timings,
+    }
+}
+
+// Один проход по неархивным рейсам, у которых может наступить этап
+func (s *StatusScheduler) Tick(ctx context.Context, now time.Time) error {
+    // Рейс, до вылета которого больше времени, чем до открытия регистрации,
+    // менять рано; час запаса — на случай вылета раньше расписания
+    to := now.Add(s.timings.CheckInOpen + time.Hour)
+    page, err := s.flights.List(ctx, models.FlightFilter{ScheduledTo: &to, SortKey: models.SortScheduled})
+    if err != nil {
+        return err
+    }
+    
+    advanced, archived := 0, 0
+    for i := range page.Flights {
+        flight := page.Flights[i]
+        
+        if completed, ok := s.timings.CompletedAt(flight); ok {
+            if now.Sub(completed) < s.timings.ArchiveAfter {
+                continue
+            }
+            ok, err := s.flights.Archive(ctx, &flight, now, statusSchedulerAuthor)
+            if err != nil {
+                log.Printf("Failed to archive flight %s: %v", flight.ID, err)
+                continue
+            }
+            if ok {
+                archived++
+                s.publish(events.FlightUpdated, flight)
+            }
+            continue
+        }
+        
+        target, ok := s.next(flight, now)
+        if !ok {
+            continue
+        }
+        
+        ok, err := s.flights.AdvanceStatus(ctx, &flight, target, now, statusSchedulerAuthor)
+        if err != nil {
+            log.Printf("Failed to change status of flight %s: %v", flight.ID, err)
+            continue
+        }
+        if !ok {
+            // Рейс успели изменить — решим на следующем проходе
+            continue
+        }
+        advanced++
+        s.publish(events.FlightUpdated, flight)
+    }
+    
+    if advanced+archived > 0 {
+        log.Printf("Status scheduler: %d flights advanced, %d archived", advanced, archived)
+    }
+    return nil
+}
+
+// Следующий статус рейса, если он должен смениться. Статус меняется только
+// вперед по жизненному циклу и только разрешенными переходами: если до
+// нужного этапа напрямую не дойти (scheduled — departed), берется самый
+// дальний разрешенный шаг, остальное — на следующих проходах. Отмененные
+// и неизвестные статусы не трогаются
+func (s *StatusScheduler) next(flight models.Flight, now time.Time) (models.FlightStatus, bool) {
+    status := models.FlightStatus(flight.Status)
+    current, ok := status.Stage()
+    if !ok {
+        return "", false
+    }
+    // Задержанный рейс без нового времени ухода по расписанию не двигаем:
+    // когда он на самом деле уйдет, неизвестно
+    if status == models.StatusDelayed && flight.EstimatedOffBlock == nil && flight.ActualOffBlock == nil {
+        return "", false
+    }
+    
+    target, since, ok := s.timings.Target(flight, now)
+    if !ok {
+        return "", false
+    }
+    targetStage, _ := target.Stage()
+    
+    var step models.FlightStatus
+    stepStage := current
+    for _, candidate := range status.AllowedTransitions() {
+        stage, ok := candidate.Stage()
+        if ok && stage > stepStage && stage <= targetStage {
+            step, stepStage = candidate, stage
+        }
+    }
+    if step == "" {
+        return "", false
+    }
+    
+    // Сотрудник сменил статус уже после начала этапа — его решение важнее
+    if flight.StatusSource != models.StatusSourceAuto && !flight.StatusUpdatedAt.Before(since) {
+        return "", false
+    }
+    return step, true
+}
+
+// Проходит сразу и затем каждые interval до отмены ctx
+func (s *StatusScheduler) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    
+    for {
+        if err := s.Tick(ctx, time.Now().UTC()); err != nil {
+            log.Printf("Status scheduler failed: %v", err)
+        }
+        
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+func (s *StatusScheduler) publish(eventType events.EventType, flight models.Flight) {
+    if s.events != nil {
+        s.events.Publish(eventType, flight)
+    }
+}
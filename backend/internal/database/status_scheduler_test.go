@@ -0,0 +1,93 @@
+package database
+
+import (
+    "context"
+    "testing"
+    "time"
+    "skyflow/internal/models"
+)
+
+var testTimings = models.StatusTimings{
+    CheckInOpen:  3 * time.Hour,
+    Boarding:     40 * time.Minute,
+    FinalCall:    15 * time.Minute,
+    GateClosed:   10 * time.Minute,
+    ArchiveAfter: 2 * time.Hour,
+}
+
+func TestStatusSchedulerTick(t *testing.T) {
+    now := time.Date(2026, 11, 2, 12, 0, 0, 0, time.UTC)
+    estimate := now.Add(30 * time.Minute)
+    
+    tests := []struct {
+        name     string
+        flight   models.Flight
+        want     []models.FlightStatus // статус после каждого прохода
+        archived bool
+    }{
+        {
+            // scheduled — departed напрямую нельзя: сначала boarding
+            name:   "steps through allowed transitions",
+            flight: models.Flight{Status: string(models.StatusScheduled), Scheduled: now.Add(-5 * time.Minute)},
+            want:   []models.FlightStatus{models.StatusBoarding, models.StatusDeparted, models.StatusDeparted},
+        },
+        {
+            name:   "check-in opens",
+            flight: models.Flight{Status: string(models.StatusScheduled), Scheduled: now.Add(2 * time.Hour)},
+            want:   []models.FlightStatus{models.StatusCheckIn, models.StatusCheckIn},
+        },
+        {
+            name:   "delayed without estimate stays delayed",
+            flight: models.Flight{Status: string(models.StatusDelayed), Scheduled: now.Add(-5 * time.Minute)},
+            want:   []models.FlightStatus{models.StatusDelayed},
+        },
+        {
+            name:   "delayed with estimate moves by the estimate",
+            flight: models.Flight{Status: string(models.StatusDelayed), Scheduled: now.Add(-5 * time.Minute), EstimatedOffBlock: &estimate},
+            want:   []models.FlightStatus{models.StatusBoarding, models.StatusBoarding},
+        },
+        {
+            name: "manual status after the stage began is kept",
+            flight: models.Flight{
+                Status: string(models.StatusScheduled), Scheduled: now.Add(2 * time.Hour),
+                StatusSource: models.StatusSourceManual, StatusUpdatedAt: now.Add(-time.Minute),
+            },
+            want: []models.FlightStatus{models.StatusScheduled},
+        },
+        {
+            name:     "departed without arrival is archived",
+            flight:   models.Flight{Status: string(models.StatusDeparted), Scheduled: now.Add(-3 * time.Hour)},
+            want:     []models.FlightStatus{models.StatusDeparted},
+            archived: true,
+        },
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            ctx := context.Background()
+            store := NewMemoryFlightStore()
+            flight := tt.flight
+            flight.FlightNumber = "SU 100"
+            if err := store.Create(ctx, &flight, nil); err != nil {
+                t.Fatal(err)
+            }
+            scheduler := NewStatusScheduler(store, nil, testTimings)
+            
+            for i, want := range tt.want {
+                if err := scheduler.Tick(ctx, now); err != nil {
+                    t.Fatal(err)
+                }
+                got, err := store.GetByID(ctx, flight.ID)
+                if err != nil {
+                    t.Fatal(err)
+                }
+                if models.FlightStatus(got.Status) != want {
+                    t.Errorf("tick %d: status = %s, want %s", i+1, got.Status, want)
+                }
+                if archived := got.ArchivedAt != nil; archived != tt.archived {
+                    t.Errorf("tick %d: archived = %v, want %v", i+1, archived, tt.archived)
+                }
+            }
+        })
+    }
+}
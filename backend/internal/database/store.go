@@ -0,0 +1,39 @@
+package database
+
+import (
+    "context"
+    "errors"
+    "time"
+    "skyflow/internal/models"
+)
+
+var ErrFlightNotFound = errors.New("flight not found")
+
+// Хранилище рейсов. Реализуется FlightRepository (Postgres)
+// и MemoryFlightStore (демо-режим без базы)
+type FlightStore interface {
+    // Изменения пишутся в журнал от имени author в той же транзакции
+    // (author nil — без журнала; в демо-режиме журнала нет)
+    Create(ctx context.Context, flight *models.Flight, author *models.FlightAuthor) error
+    // Создание нескольких рейсов: либо все, либо ни одного
+    CreateMany(ctx context.Context, flights []*models.Flight, author *models.FlightAuthor) error
+    GetAll(ctx context.Context) ([]models.Flight, error)
+    // Страница рейсов по фильтру с общим числом подходящих
+    List(ctx context.Context, filter models.FlightFilter) (*models.FlightPage, error)
+    GetByID(ctx context.Context, id string) (*models.Flight, error)
+    // Текущий или ближайший рейс с выполняемым или коммерческим номером
+    // (см. models.PreferByFlightNumber)
+    GetByFlightNumber(ctx context.Context, flightNumber string) (*models.Flight, error)
+    Update(ctx context.Context, flight *models.Flight, author *models.FlightAuthor) error
+    // Смена статуса и архивация планировщиком: только если статус рейса
+    // не менялся с момента чтения; false — рейс изменили, пропускаем
+    AdvanceStatus(ctx context.Context, flight *models.Flight, status models.FlightStatus, at time.Time, author *models.FlightAuthor) (bool, error)
+    Archive(ctx context.Context, flight *models.Flight, at time.Time, author *models.FlightAuthor) (bool, error)
+    Delete(ctx context.Context, id string, author *models.FlightAuthor) error
+}
+
+var (
+    _ FlightStore = (*FlightRepository)(nil)
+    _ FlightStore = (*MemoryFlightStore)(nil)
+    _ FlightStore = (*EnrichedFlightStore)(nil)
+)
@@ -0,0 +1,247 @@
+package database
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "database/sql"
+    "encoding/base64"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "time"
+    "skyflow/internal/models"
+)
+
+var (
+    ErrInvalidRefreshToken = errors.New("invalid refresh token")
+    ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// Refresh-токены с ротацией и список отозванных access-токенов
+type TokenRepository struct {
+    db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) *TokenRepository {
+    return &TokenRepository{db: db}
+}
+
+// Access-токен, выданный вместе с refresh-токеном
+type IssuedAccess struct {
+    JTI       string
+    ExpiresAt time.Time
+}
+
+// Новый refresh-токен для пользователя. Возвращает сам токен — в базе только хэш
+func (r *TokenRepository) CreateRefreshToken(ctx context.Context, userID string, access IssuedAccess, ttl time.Duration) (string, error) {
+    return r.insertRefreshToken(ctx, r.db, userID, generateID(), access, ttl)
+}
+
+// Ротация: старый токен отзывается, выдается новый из того же семейства.
+// Повторное предъявление уже отозванного токена означает кражу —
+// тогда отзывается все семейство, а отозванные access-токены возвращаются
+func (r *TokenRepository) RotateRefreshToken(ctx context.Context, presented string, access IssuedAccess, ttl time.Duration) (userID, token string, revoked []models.RevokedToken, err error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return "", "", nil, err
+    }
+    defer tx.Rollback()
+    
+    var (
+        id, familyID string
+        expiresAt    time.Time
+        revokedAt    sql.NullTime
+    )
+    err = tx.QueryRowContext(ctx, `
+        SELECT id, user_id, family_id, expires_at, revoked_at
+        FROM refresh_tokens
+        WHERE token_hash = $1
+        FOR UPDATE
+    `, hashToken(presented)).Scan(&id, &userID, &familyID, &expiresAt, &revokedAt)
+    
+    if err == sql.ErrNoRows {
+        return "", "", nil, ErrInvalidRefreshToken
+    }
+    if err != nil {
+        return "", "", nil, fmt.Errorf("failed to find refresh token: %w", err)
+    }
+    
+    if revokedAt.Valid {
+        revoked, err = revokeWhere(ctx, tx, `family_id = $1`, familyID)
+        if err != nil {
+            return "", "", nil, err
+        }
+        if err := tx.Commit(); err != nil {
+            return "", "", nil, err
+        }
+        return "", "", revoked, ErrRefreshTokenReused
+    }
+    
+    if time.Now().After(expiresAt) {
+        return "", "", nil, ErrInvalidRefreshToken
+    }
+    
+    _, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1`, id)
+    if err != nil {
+        return "", "", nil, fmt.Errorf("failed to revoke refresh token: %w", err)
+    }
+    
+    token, err = r.insertRefreshToken(ctx, tx, userID, familyID, access, ttl)
+    if err != nil {
+        return "", "", nil, err
+    }
+    
+    return userID, token, nil, tx.Commit()
+}
+
+// Выход: отзыв семейства, к которому относится refresh-токен
+func (r *TokenRepository) RevokeRefreshToken(ctx context.Context, presented string) ([]models.RevokedToken, error) {
+    return r.revoke(ctx, `family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash = $1)`, hashToken(presented))
+}
+
+// Отзыв всех токенов пользователя (блокировка, смена пароля)
+func (r *TokenRepository) RevokeUserTokens(ctx context.Context, userID string) ([]models.RevokedToken, error) {
+    return r.revoke(ctx, `user_id = $1`, userID)
+}
+
+// Отзыв одного access-токена
+func (r *TokenRepository) RevokeAccessToken(ctx context.Context, token models.RevokedToken) error {
+    _, err := r.db.ExecContext(ctx, `
+        INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2)
+        ON CONFLICT (jti) DO NOTHING
+    `, token.JTI, token.ExpiresAt)
+    if err != nil {
+        return fmt.Errorf("failed to revoke token: %w", err)
+    }
+    return nil
+}
+
+// Действующие записи списка отозванных токенов
+func (r *TokenRepository) ListRevoked(ctx context.Context) ([]models.RevokedToken, error) {
+    rows, err := r.db.QueryContext(ctx, `SELECT jti, expires_at FROM revoked_tokens WHERE expires_at > NOW()`)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list revoked tokens: %w", err)
+    }
+    defer rows.Close()
+    
+    var tokens []models.RevokedToken
+    for rows.Next() {
+        var token models.RevokedToken
+        if err := rows.Scan(&token.JTI, &token.ExpiresAt); err != nil {
+            return nil, err
+        }
+        tokens = append(tokens, token)
+    }
+    
+    return tokens, rows.Err()
+}
+
+// Удаление истекших записей
+func (r *TokenRepository) DeleteExpired(ctx context.Context) error {
+    if _, err := r.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < NOW()`); err != nil {
+        return err
+    }
+    _, err := r.db.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
+    return err
+}
+
+func (r *TokenRepository) revoke(ctx context.Context, where string, arg interface{}) ([]models.RevokedToken, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+    
+    revoked, err := revokeWhere(ctx, tx, where, arg)
+    if err != nil {
+        return nil, err
+    }
+    
+    return revoked, tx.Commit()
+}
+
+// Отзывает refresh-токены по условию и вносит их действующие
+// access-токены в revoked_tokens
+func revokeWhere(ctx context.Context, tx *sql.Tx, where string, arg interface{}) ([]models.RevokedToken, error) {
+    rows, err := tx.QueryContext(ctx, `
+        UPDATE refresh_tokens SET revoked_at = COALESCE(revoked_at, NOW())
+        WHERE `+where+`
+        RETURNING access_jti, access_expires_at
+    `, arg)
+    if err != nil {
+        return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+    }
+    
+    var revoked []models.RevokedToken
+    for rows.Next() {
+        var token models.RevokedToken
+        if err := rows.Scan(&token.JTI, &token.ExpiresAt); err != nil {
+            rows.Close()
+            return nil, err
+        }
+        if token.ExpiresAt.After(time.Now()) {
+            revoked = append(revoked, token)
+        }
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+    
+    for _, token := range revoked {
+        _, err := tx.ExecContext(ctx, `
+            INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2)
+            ON CONFLICT (jti) DO NOTHING
+        `, token.JTI, token.ExpiresAt)
+        if err != nil {
+            return nil, fmt.Errorf("failed to revoke token: %w", err)
+        }
+    }
+    
+    return revoked, nil
+}
+
+type execer interface {
+    ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func (r *TokenRepository) insertRefreshToken(ctx context.Context, db execer, userID, familyID string, access IssuedAccess, ttl time.Duration) (string, error) {
+    token, err := RandomToken(32)
+    if err != nil {
+        return "", err
+    }
+    
+    _, err = db.ExecContext(ctx, `
+        INSERT INTO refresh_tokens (
+            id, user_id, family_id, token_hash, access_jti, access_expires_at, expires_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `,
+        generateID(),
+        userID,
+        familyID,
+        hashToken(token),
+        access.JTI,
+        access.ExpiresAt,
+        time.Now().Add(ttl),
+    )
+    if err != nil {
+        return "", fmt.Errorf("failed to store refresh token: %w", err)
+    }
+    
+    return token, nil
+}
+
+// Случайная строка из n байт в base64url
+func RandomToken(n int) (string, error) {
+    buf := make([]byte, n)
+    if _, err := rand.Read(buf); err != nil {
+        return "", fmt.Errorf("failed to generate token: %w", err)
+    }
+    return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
+    return hex.EncodeToString(sum[:])
+}
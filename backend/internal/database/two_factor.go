@@ -0,0 +1,164 @@
+package database
+
+import (
+    "context"
+    "crypto/rand"
+    "database/sql"
+    "encoding/base32"
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// Количество кодов восстановления, выдаваемых при включении 2FA
+const RecoveryCodeCount = 10
+
+var ErrTwoFactorNotSetUp = errors.New("two-factor authentication is not set up")
+
+// Секрет TOTP пользователя. enabled=false — настройка начата, но не подтверждена
+func (r *UserRepository) GetTOTPSecret(ctx context.Context, userID string) (secret string, enabled bool, err error) {
+    var stored sql.NullString
+    err = r.db.QueryRowContext(ctx,
+        `SELECT totp_secret, totp_enabled FROM users WHERE id = $1`, userID,
+    ).Scan(&stored, &enabled)
+    
+    if err == sql.ErrNoRows {
+        return "", false, ErrUserNotFound
+    }
+    if err != nil {
+        return "", false, fmt.Errorf("failed to load totp secret: %w", err)
+    }
+    if !stored.Valid {
+        return "", false, ErrTwoFactorNotSetUp
+    }
+    
+    return stored.String, enabled, nil
+}
+
+// Новый секрет, ожидающий подтверждения кодом. Включенный 2FA не перезаписывается
+func (r *UserRepository) SetPendingTOTPSecret(ctx context.Context, userID, secret string) error {
+    return r.exec(ctx,
+        `UPDATE users SET totp_secret = $1, totp_last_step = 0 WHERE id = $2 AND NOT totp_enabled`,
+        secret, userID)
+}
+
+// Включение 2FA после проверки первого кода
+func (r *UserRepository) EnableTOTP(ctx context.Context, userID string, step int64) error {
+    return r.exec(ctx,
+        `UPDATE users SET totp_enabled = TRUE, totp_last_step = $1, updated_at = NOW()
+         WHERE id = $2 AND totp_secret IS NOT NULL`,
+        step, userID)
+}
+
+// Отключение 2FA: секрет и коды восстановления удаляются
+func (r *UserRepository) DisableTOTP(ctx context.Context, userID string) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    
+    result, err := tx.ExecContext(ctx, `
+        UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = 0, updated_at = NOW()
+        WHERE id = $1
+    `, userID)
+    if err != nil {
+        return fmt.Errorf("failed to disable totp: %w", err)
+    }
+    if rows, _ := result.RowsAffected(); rows == 0 {
+        return ErrUserNotFound
+    }
+    
+    if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return fmt.Errorf("failed to delete recovery codes: %w", err)
+    }
+    
+    return tx.Commit()
+}
+
+// Помечает шаг TOTP использованным. false — код этого или более
+// позднего шага уже принимался (повтор перехваченного кода)
+func (r *UserRepository) UseTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+    result, err := r.db.ExecContext(ctx,
+        `UPDATE users SET totp_last_step = $1 WHERE id = $2 AND totp_last_step < $1`,
+        step, userID)
+    if err != nil {
+        return false, fmt.Errorf("failed to use totp code: %w", err)
+    }
+    
+    rows, _ := result.RowsAffected()
+    return rows > 0, nil
+}
+
+// Выпускает новый набор кодов восстановления взамен прежних.
+// Возвращает сами коды — в базе только хэши
+func (r *UserRepository) ReplaceRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+    
+    if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+    }
+    
+    codes := make([]string, RecoveryCodeCount)
+    for i := range codes {
+        code, err := newRecoveryCode()
+        if err != nil {
+            return nil, err
+        }
+        
+        _, err = tx.ExecContext(ctx,
+            `INSERT INTO recovery_codes (id, user_id, code_hash) VALUES ($1, $2, $3)`,
+            generateID(), userID, hashToken(normalizeRecoveryCode(code)))
+        if err != nil {
+            return nil, fmt.Errorf("failed to store recovery code: %w", err)
+        }
+        codes[i] = code
+    }
+    
+    return codes, tx.Commit()
+}
+
+// Погашение кода восстановления. false — кода нет или он уже использован
+func (r *UserRepository) UseRecoveryCode(ctx context.Context, userID, code string) (bool, error) {
+    result, err := r.db.ExecContext(ctx, `
+        UPDATE recovery_codes SET used_at = NOW()
+        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+    `, userID, hashToken(normalizeRecoveryCode(code)))
+    if err != nil {
+        return false, fmt.Errorf("failed to use recovery code: %w", err)
+    }
+    
+    rows, _ := result.RowsAffected()
+    return rows > 0, nil
+}
+
+// Сколько кодов восстановления еще не использовано
+func (r *UserRepository) CountRecoveryCodes(ctx context.Context, userID string) (int, error) {
+    var count int
+    err := r.db.QueryRowContext(ctx,
+        `SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID,
+    ).Scan(&count)
+    if err != nil {
+        return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+    }
+    return count, nil
+}
+
+// Код вида xxxxx-xxxxx, удобный для записи на бумаге
+func newRecoveryCode() (string, error) {
+    buf := make([]byte, 8)
+    if _, err := rand.Read(buf); err != nil {
+        return "", fmt.Errorf("failed to generate recovery code: %w", err)
+    }
+    code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))[:10]
+    return code[:5] + "-" + code[5:], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+    code = strings.ToLower(strings.TrimSpace(code))
+    return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
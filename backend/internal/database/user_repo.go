@@ -3,11 +3,21 @@ package database
 import (
     "context"
     "database/sql"
+    "errors"
     "fmt"
+    "time"
     "skyflow/internal/models"
+    "github.com/lib/pq"
     "golang.org/x/crypto/bcrypt"
 )
 
+var (
+    ErrUserNotFound    = errors.New("user not found")
+    ErrInvalidPassword = errors.New("invalid password")
+    ErrUserDisabled    = errors.New("user disabled")
+    ErrUsernameTaken   = errors.New("username already taken")
+)
+
 type UserRepository struct {
     db *sql.DB
 }
@@ -16,17 +26,21 @@ func NewUserRepository(db *sql.DB) *UserRepository {
     return &UserRepository{db: db}
 }
 
-// Создание пользователя с паролем 0000 по умолчанию
+// Создание пользователя с паролем 0000 по умолчанию.
+// Пока пароль не сменен, админ обязан сменить его при входе
 func (r *UserRepository) CreateDefaultAdmin(ctx context.Context) error {
     // Проверяем, есть ли уже админ
-    var count int
-    err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE username = 'admin'").Scan(&count)
+    admin, err := r.FindByUsername(ctx, "admin")
     if err != nil {
         return err
     }
     
-    if count > 0 {
-        return nil // Админ уже существует
+    if admin != nil {
+        // Админ уже существует; если пароль все еще 0000 — требуем смену
+        if !admin.MustChangePassword && bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte("0000")) == nil {
+            _, err = r.db.ExecContext(ctx, "UPDATE users SET must_change_password = TRUE WHERE id = $1", admin.ID)
+        }
+        return err
     }
     
     // Хэшируем пароль 0000
@@ -36,8 +50,8 @@ func (r *UserRepository) CreateDefaultAdmin(ctx context.Context) error {
     }
     
     query := `
-        INSERT INTO users (id, username, password, role)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO users (id, username, password, role, must_change_password)
+        VALUES ($1, $2, $3, $4, TRUE)
     `
     
     _, err = r.db.ExecContext(ctx, query,
@@ -50,18 +64,36 @@ func (r *UserRepository) CreateDefaultAdmin(ctx context.Context) error {
     return err
 }
 
-// Поиск пользователя по имени
-func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
-    query := `SELECT id, username, password, role, created_at FROM users WHERE username = $1`
-    
+const userColumns = `id, username, password, role, disabled, must_change_password, totp_enabled, created_at, COALESCE(updated_at, created_at)`
+
+type rowScanner interface {
+    Scan(dest ...interface{}) error
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
     var user models.User
-    err := r.db.QueryRowContext(ctx, query, username).Scan(
+    err := row.Scan(
         &user.ID,
         &user.Username,
         &user.Password,
         &user.Role,
+        &user.Disabled,
+        &user.MustChangePassword,
+        &user.TwoFactorEnabled,
         &user.CreatedAt,
+        &user.UpdatedAt,
     )
+    if err != nil {
+        return nil, err
+    }
+    return &user, nil
+}
+
+// Поиск пользователя по имени
+func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
+    query := `SELECT ` + userColumns + ` FROM users WHERE username = $1`
+    
+    user, err := scanUser(r.db.QueryRowContext(ctx, query, username))
     
     if err == sql.ErrNoRows {
         return nil, nil
@@ -71,7 +103,115 @@ func (r *UserRepository) FindByUsername(ctx context.Context, username string) (*
         return nil, fmt.Errorf("failed to find user: %w", err)
     }
     
-    return &user, nil
+    return user, nil
+}
+
+// Поиск пользователя по ID
+func (r *UserRepository) FindByID(ctx context.Context, id string) (*models.User, error) {
+    query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
+    
+    user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
+    
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    
+    if err != nil {
+        return nil, fmt.Errorf("failed to find user: %w", err)
+    }
+    
+    return user, nil
+}
+
+// Список всех пользователей
+func (r *UserRepository) List(ctx context.Context) ([]models.User, error) {
+    query := `SELECT ` + userColumns + ` FROM users ORDER BY username`
+    
+    rows, err := r.db.QueryContext(ctx, query)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list users: %w", err)
+    }
+    defer rows.Close()
+    
+    users := []models.User{}
+    for rows.Next() {
+        user, err := scanUser(rows)
+        if err != nil {
+            return nil, err
+        }
+        users = append(users, *user)
+    }
+    
+    return users, rows.Err()
+}
+
+// Создание пользователя. При первом входе он должен сменить выданный пароль
+func (r *UserRepository) Create(ctx context.Context, username, password, role string) (*models.User, error) {
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return nil, fmt.Errorf("failed to hash password: %w", err)
+    }
+    
+    query := `
+        INSERT INTO users (id, username, password, role, must_change_password, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, TRUE, $5, $5)
+    `
+    
+    user := &models.User{
+        ID:                 generateID(),
+        Username:           username,
+        Password:           string(hashedPassword),
+        Role:               role,
+        MustChangePassword: true,
+        CreatedAt:          time.Now(),
+    }
+    user.UpdatedAt = user.CreatedAt
+    
+    _, err = r.db.ExecContext(ctx, query, user.ID, user.Username, user.Password, user.Role, user.CreatedAt)
+    if err != nil {
+        if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+            return nil, ErrUsernameTaken
+        }
+        return nil, fmt.Errorf("failed to create user: %w", err)
+    }
+    
+    return user, nil
+}
+
+// Смена роли
+func (r *UserRepository) UpdateRole(ctx context.Context, id, role string) error {
+    return r.exec(ctx, `UPDATE users SET role = $1, updated_at = NOW() WHERE id = $2`, role, id)
+}
+
+// Блокировка и разблокировка учетной записи
+func (r *UserRepository) SetDisabled(ctx context.Context, id string, disabled bool) error {
+    return r.exec(ctx, `UPDATE users SET disabled = $1, updated_at = NOW() WHERE id = $2`, disabled, id)
+}
+
+// Установка нового пароля. mustChange — требовать смену при следующем входе
+func (r *UserRepository) SetPassword(ctx context.Context, id, password string, mustChange bool) error {
+    hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+    if err != nil {
+        return fmt.Errorf("failed to hash password: %w", err)
+    }
+    
+    return r.exec(ctx,
+        `UPDATE users SET password = $1, must_change_password = $2, updated_at = NOW() WHERE id = $3`,
+        string(hashedPassword), mustChange, id)
+}
+
+func (r *UserRepository) exec(ctx context.Context, query string, args ...interface{}) error {
+    result, err := r.db.ExecContext(ctx, query, args...)
+    if err != nil {
+        return fmt.Errorf("failed to update user: %w", err)
+    }
+    
+    rows, _ := result.RowsAffected()
+    if rows == 0 {
+        return ErrUserNotFound
+    }
+    
+    return nil
 }
 
 // Проверка пароля
@@ -82,12 +222,17 @@ func (r *UserRepository) CheckPassword(ctx context.Context, username, password s
     }
     
     if user == nil {
-        return nil, fmt.Errorf("user not found")
+        return nil, ErrUserNotFound
     }
     
     err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
     if err != nil {
-        return nil, fmt.Errorf("invalid password")
+        return nil, ErrInvalidPassword
+    }
+    
+    // Пароль проверяем раньше, чтобы не раскрывать статус чужих учетных записей
+    if user.Disabled {
+        return nil, ErrUserDisabled
     }
     
     return user, nil
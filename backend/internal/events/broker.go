@@ -0,0 +1,194 @@
+package events
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "errors"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    "skyflow/internal/models"
+)
+
+type EventType string
+
+const (
+    FlightCreated EventType = "created"
+    FlightUpdated EventType = "updated"
+    FlightDeleted EventType = "deleted"
+)
+
+// Изменение на табло
+type Event struct {
+    ID     uint64        `json:"id"`
+    Type   EventType     `json:"type"`
+    Flight models.Flight `json:"flight"`
+    Time   time.Time     `json:"time"`
+    // Эпоха брокера, выдавшего ID: номера событий разных процессов не сравнимы
+    Epoch string `json:"-"`
+}
+
+// Идентификатор для клиента (SSE id): эпоха и номер, "3f9a0c1e2b7d-42"
+func (e Event) StreamID() string {
+    return e.Epoch + "-" + strconv.FormatUint(e.ID, 10)
+}
+
+var ErrInvalidEventID = errors.New("invalid event id")
+
+// Разбирает идентификатор из StreamID. Номер без эпохи (старый формат)
+// разбирается с пустой эпохой и не совпадет ни с одним брокером
+func ParseStreamID(value string) (epoch string, id uint64, err error) {
+    number := value
+    if i := strings.LastIndexByte(value, '-'); i >= 0 {
+        epoch, number = value[:i], value[i+1:]
+    }
+    id, err = strconv.ParseUint(number, 10, 64)
+    if err != nil {
+        return "", 0, ErrInvalidEventID
+    }
+    return epoch, id, nil
+}
+
+// Размер буфера канала подписчика. Кто не успевает читать — отключается
+const subscriberBuffer = 64
+
+type Subscription struct {
+    C      <-chan Event
+    broker *Broker
+    ch     chan Event
+}
+
+// Отписка. Безопасно вызывать повторно
+func (s *Subscription) Close() {
+    s.broker.unsubscribe(s.ch)
+}
+
+// Рассылает события всем подписчикам процесса и хранит
+// ограниченный журнал последних событий для переподключений
+type Broker struct {
+    mu          sync.Mutex
+    nextID      uint64
+    log         []Event
+    logSize     int
+    subscribers map[chan Event]struct{}
+    // Дополняет рейс перед публикацией (названия из справочников)
+    enrich func(models.Flight) models.Flight
+    // Случайная метка процесса: клиент, переподключившийся к другой реплике
+    // или после перезапуска, присылает ID чужой эпохи
+    epoch string
+}
+
+// logSize 0 или меньше — журнал не ведется, переподключившимся
+// клиентам придется заново загрузить список
+func NewBroker(logSize int) *Broker {
+    if logSize < 0 {
+        logSize = 0
+    }
+    return &Broker{
+        epoch:       newEpoch(),
+        nextID:      1,
+        logSize:     logSize,
+        subscribers: make(map[chan Event]struct{}),
+    }
+}
+
+func newEpoch() string {
+    b := make([]byte, 6)
+    if _, err := rand.Read(b); err != nil {
+        return strconv.FormatInt(time.Now().UnixNano(), 36)
+    }
+    return hex.EncodeToString(b)
+}
+
+// Задает функцию, через которую проходит каждый публикуемый рейс
+func (b *Broker) SetEnricher(enrich func(models.Flight) models.Flight) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    
+    b.enrich = enrich
+}
+
+// Публикует событие. Никогда не блокируется на медленных подписчиках
+func (b *Broker) Publish(eventType EventType, flight models.Flight) Event {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    
+    if b.enrich != nil {
+        flight = b.enrich(flight)
+    }
+    
+    event := Event{
+        ID:     b.nextID,
+        Type:   eventType,
+        Flight: flight,
+        Time:   time.Now(),
+        Epoch:  b.epoch,
+    }
+    b.nextID++
+    
+    b.log = append(b.log, event)
+    if len(b.log) > b.logSize {
+        b.log = b.log[len(b.log)-b.logSize:]
+    }
+    
+    for ch := range b.subscribers {
+        select {
+        case ch <- event:
+        default:
+            // Подписчик не успевает — закрываем канал, клиент переподключится
+            delete(b.subscribers, ch)
+            close(ch)
+        }
+    }
+    
+    return event
+}
+
+// Подписка на новые события. Если lastID > 0, возвращает пропущенные
+// события из журнала; complete == false означает, что часть событий
+// уже вытеснена из журнала или ID выдан другим процессом (epoch не совпадает)
+// и клиенту нужно перечитать табло целиком
+func (b *Broker) Subscribe(epoch string, lastID uint64) (sub *Subscription, missed []Event, complete bool) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    
+    complete = true
+    if lastID > 0 && epoch != b.epoch {
+        // Номера чужой эпохи ничего не говорят о нашем журнале
+        complete = false
+    } else if lastID > 0 {
+        // Первое событие, которое еще можно отдать (журнал может быть пуст)
+        first := b.nextID
+        if len(b.log) > 0 {
+            first = b.log[0].ID
+        }
+        if first > lastID+1 {
+            complete = false
+        }
+        if lastID >= b.nextID {
+            // Номер из будущего — клиент прислал испорченный ID
+            complete = false
+        }
+        for _, event := range b.log {
+            if event.ID > lastID {
+                missed = append(missed, event)
+            }
+        }
+    }
+    
+    ch := make(chan Event, subscriberBuffer)
+    b.subscribers[ch] = struct{}{}
+    
+    return &Subscription{C: ch, broker: b, ch: ch}, missed, complete
+}
+
+func (b *Broker) unsubscribe(ch chan Event) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    
+    if _, ok := b.subscribers[ch]; ok {
+        delete(b.subscribers, ch)
+        close(ch)
+    }
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+    "testing"
+    "skyflow/internal/models"
+)
+
+func TestBrokerSubscribe(t *testing.T) {
+    // Журнал на одно событие: после трех публикаций в нем только третье
+    broker := NewBroker(1)
+    for i := 0; i < 3; i++ {
+        broker.Publish(FlightUpdated, models.Flight{ID: "1"})
+    }
+    
+    tests := []struct {
+        name     string
+        epoch    string
+        lastID   uint64
+        missed   int
+        complete bool
+    }{
+        {name: "first connection", complete: true},
+        {name: "up to date", epoch: broker.epoch, lastID: 3, complete: true},
+        {name: "missed event in the log", epoch: broker.epoch, lastID: 2, missed: 1, complete: true},
+        {name: "log does not cover the gap", epoch: broker.epoch, lastID: 1, missed: 1},
+        {name: "id from the future", epoch: broker.epoch, lastID: 10},
+        {name: "other process", epoch: "0123456789ab", lastID: 2},
+        {name: "id without epoch", lastID: 2},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            sub, missed, complete := broker.Subscribe(tt.epoch, tt.lastID)
+            defer sub.Close()
+            if len(missed) != tt.missed || complete != tt.complete {
+                t.Errorf("Subscribe() = %d missed, complete %v, want %d, %v", len(missed), complete, tt.missed, tt.complete)
+            }
+        })
+    }
+}
+
+func TestParseStreamID(t *testing.T) {
+    event := Event{ID: 42, Epoch: "3f9a0c1e2b7d"}
+    
+    tests := []struct {
+        value string
+        epoch string
+        id    uint64
+        ok    bool
+    }{
+        {event.StreamID(), "3f9a0c1e2b7d", 42, true},
+        {"42", "", 42, true},
+        {"3f9a0c1e2b7d-", "", 0, false},
+        {"abc", "", 0, false},
+        {"", "", 0, false},
+    }
+    
+    for _, tt := range tests {
+        epoch, id, err := ParseStreamID(tt.value)
+        if epoch != tt.epoch || id != tt.id || (err == nil) != tt.ok {
+            t.Errorf("ParseStreamID(%q) = %q, %d, %v, want %q, %d, ok %v", tt.value, epoch, id, err, tt.epoch, tt.id, tt.ok)
+        }
+    }
+}
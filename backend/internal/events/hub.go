@@ -0,0 +1,253 @@
+package events
+
+import (
+    "context"
+    "encoding/json"
+    "log"
+    "sync"
+    "time"
+    "skyflow/internal/models"
+)
+
+// Размер очереди исходящих сообщений клиента
+const clientBuffer = 32
+
+// Сообщение, отправляемое подписчику конкретного рейса
+type FlightMessage struct {
+    Type         string                        `json:"type"`
+    FlightNumber string                        `json:"flightNumber,omitempty"`
+    Flight       *models.Flight                `json:"flight,omitempty"`
+    Changes      map[string]models.FieldChange `json:"changes,omitempty"`
+    Error        string                        `json:"error,omitempty"`
+}
+
+// Поля, изменения которых получают подписчики рейса
+var passengerFields = []string{"status", "gate", "terminal", "actual"}
+
+// Подписчик хаба (например, WebSocket-соединение). Сообщения читаются из Send;
+// закрытие канала означает, что хаб отключил клиента
+type HubClient struct {
+    Send chan []byte
+    
+    // номер рейса, указанный клиентом -> ID рейса
+    numbers map[string]string
+    closed  bool
+}
+
+// Раздает изменения отдельных рейсов подписанным клиентам.
+// Медленные клиенты отключаются, а не тормозят рассылку
+type Hub struct {
+    broker *Broker
+    
+    mu       sync.Mutex
+    byFlight map[string]map[*HubClient]struct{}
+    last     map[string]models.Flight
+}
+
+func NewHub(broker *Broker) *Hub {
+    return &Hub{
+        broker:   broker,
+        byFlight: make(map[string]map[*HubClient]struct{}),
+        last:     make(map[string]models.Flight),
+    }
+}
+
+// Читает события брокера до отмены ctx
+func (h *Hub) Run(ctx context.Context) {
+    for {
+        sub, _, _ := h.broker.Subscribe("", 0)
+        
+        for running := true; running; {
+            select {
+            case <-ctx.Done():
+                sub.Close()
+                return
+            case event, ok := <-sub.C:
+                if !ok {
+                    // Брокер отключил хаб — переподписываемся
+                    log.Println("WebSocket hub lagged behind event broker, resubscribing")
+                    running = false
+                    break
+                }
+                h.dispatch(event)
+            }
+        }
+        
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(100 * time.Millisecond):
+        }
+    }
+}
+
+func (h *Hub) NewClient() *HubClient {
+    return &HubClient{
+        Send:    make(chan []byte, clientBuffer),
+        numbers: make(map[string]string),
+    }
+}
+
+// Подписывает клиента на рейс и отправляет текущее состояние
+func (h *Hub) Subscribe(c *HubClient, flightNumber string, flight models.Flight) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    
+    if c.closed {
+        return
+    }
+    
+    if oldID, ok := c.numbers[flightNumber]; ok && oldID != flight.ID {
+        h.removeLocked(c, flightNumber)
+    }
+    
+    c.numbers[flightNumber] = flight.ID
+    clients, ok := h.byFlight[flight.ID]
+    if !ok {
+        clients = make(map[*HubClient]struct{})
+        h.byFlight[flight.ID] = clients
+    }
+    clients[c] = struct{}{}
+    
+    if _, ok := h.last[flight.ID]; !ok {
+        h.last[flight.ID] = flight
+    }
+    
+    h.sendLocked(c, FlightMessage{Type: "subscribed", FlightNumber: flightNumber, Flight: &flight})
+}
+
+func (h *Hub) Unsubscribe(c *HubClient, flightNumber string) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    
+    h.removeLocked(c, flightNumber)
+    h.sendLocked(c, FlightMessage{Type: "unsubscribed", FlightNumber: flightNumber})
+}
+
+// Отправка сообщения одному клиенту (например, ошибки)
+func (h *Hub) Notify(c *HubClient, msg FlightMessage) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    
+    h.sendLocked(c, msg)
+}
+
+// Отключение клиента: отписка от всех рейсов и закрытие Send
+func (h *Hub) Remove(c *HubClient) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    
+    h.dropLocked(c)
+}
+
+func (h *Hub) dispatch(event Event) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    
+    flight := event.Flight
+    clients := h.byFlight[flight.ID]
+    if len(clients) == 0 {
+        return
+    }
+    
+    var msg FlightMessage
+    switch event.Type {
+    case FlightDeleted:
+        msg = FlightMessage{Type: "deleted", Flight: &flight}
+    default:
+        changes := diffFlight(h.last[flight.ID], flight)
+        h.last[flight.ID] = flight
+        if len(changes) == 0 {
+            return
+        }
+        msg = FlightMessage{Type: "update", Flight: &flight, Changes: changes}
+    }
+    
+    for c := range clients {
+        for number, id := range c.numbers {
+            if id == flight.ID {
+                msg.FlightNumber = number
+                break
+            }
+        }
+        h.sendLocked(c, msg)
+    }
+    
+    if event.Type == FlightDeleted {
+        for c := range clients {
+            for number, id := range c.numbers {
+                if id == flight.ID {
+                    delete(c.numbers, number)
+                }
+            }
+        }
+        delete(h.byFlight, flight.ID)
+        delete(h.last, flight.ID)
+    }
+}
+
+// Неблокирующая отправка; переполненная очередь — клиент отключается
+func (h *Hub) sendLocked(c *HubClient, msg FlightMessage) {
+    if c.closed {
+        return
+    }
+    
+    data, err := json.Marshal(msg)
+    if err != nil {
+        log.Printf("Failed to encode hub message: %v", err)
+        return
+    }
+    
+    select {
+    case c.Send <- data:
+    default:
+        h.dropLocked(c)
+    }
+}
+
+func (h *Hub) removeLocked(c *HubClient, flightNumber string) {
+    id, ok := c.numbers[flightNumber]
+    if !ok {
+        return
+    }
+    delete(c.numbers, flightNumber)
+    
+    // Тот же рейс может быть подписан под другим номером
+    for _, other := range c.numbers {
+        if other == id {
+            return
+        }
+    }
+    
+    if clients, ok := h.byFlight[id]; ok {
+        delete(clients, c)
+        if len(clients) == 0 {
+            delete(h.byFlight, id)
+            delete(h.last, id)
+        }
+    }
+}
+
+func (h *Hub) dropLocked(c *HubClient) {
+    if c.closed {
+        return
+    }
+    
+    for number := range c.numbers {
+        h.removeLocked(c, number)
+    }
+    c.closed = true
+    close(c.Send)
+}
+
+// Изменения полей, интересных пассажиру
+func diffFlight(before, after models.Flight) map[string]models.FieldChange {
+    all := models.DiffFlights(before, after)
+    changes := make(map[string]models.FieldChange)
+    for _, name := range passengerFields {
+        if change, ok := all[name]; ok {
+            changes[name] = change
+        }
+    }
+    return changes
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strings"
+    "time"
+    "skyflow/internal/database"
+    "skyflow/internal/models"
+    "github.com/go-chi/chi/v5"
+)
+
+type APIKeyHandler struct {
+    keys      *database.APIKeyRepository
+    reference *database.ReferenceCache
+}
+
+func NewAPIKeyHandler(keys *database.APIKeyRepository, reference *database.ReferenceCache) *APIKeyHandler {
+    return &APIKeyHandler{keys: keys, reference: reference}
+}
+
+// Список ключей (без секретов)
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+    keys, err := h.keys.List(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, keys, http.StatusOK)
+}
+
+// Выпуск ключа с набором прав и, возможно, привязкой к авиакомпании
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+    var req models.CreateAPIKeyRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    req.Name = strings.TrimSpace(req.Name)
+    if req.Name == "" {
+        http.Error(w, "Name is required", http.StatusBadRequest)
+        return
+    }
+    if len(req.Scopes) == 0 {
+        http.Error(w, "At least one scope is required", http.StatusBadRequest)
+        return
+    }
+    
+    key := &models.APIKey{
+        Name:      req.Name,
+        Airline:   strings.TrimSpace(req.Airline),
+        ExpiresAt: req.ExpiresAt,
+    }
+    for _, scope := range req.Scopes {
+        if !models.ValidAPIKeyScope(models.Permission(scope)) {
+            jsonResponse(w, errorResponse{
+                Error:   "unknown_scope",
+                Message: "Unknown or forbidden scope: " + scope,
+                Details: map[string]interface{}{"allowed": models.AllAPIKeyScopes()},
+            }, http.StatusBadRequest)
+            return
+        }
+        key.Scopes = append(key.Scopes, models.Permission(scope))
+    }
+    if key.Airline != "" {
+        airline, ok := h.reference.Data().Airline(key.Airline)
+        if !ok {
+            jsonResponse(w, errorResponse{
+                Error:   "unknown_airline",
+                Message: "Unknown airline: " + key.Airline,
+                Details: map[string]interface{}{"airline": key.Airline},
+            }, http.StatusBadRequest)
+            return
+        }
+        key.Airline = airline.IATACode
+    }
+    if key.ExpiresAt != nil && key.ExpiresAt.Before(time.Now()) {
+        http.Error(w, "Expiration time is in the past", http.StatusBadRequest)
+        return
+    }
+    
+    if user, ok := r.Context().Value("user").(*models.User); ok {
+        key.CreatedBy = user.ID
+    }
+    
+    secret, err := h.keys.Create(r.Context(), key)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, models.CreatedAPIKey{APIKey: *key, Key: secret}, http.StatusCreated)
+}
+
+// Отзыв ключа
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+    err := h.keys.Revoke(r.Context(), chi.URLParam(r, "id"))
+    if errors.Is(err, database.ErrAPIKeyNotFound) {
+        http.Error(w, "API key not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    w.WriteHeader(http.StatusNoContent)
+}
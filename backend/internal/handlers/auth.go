@@ -2,22 +2,40 @@ package handlers
 
 import (
     "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "math"
+    "net"
     "net/http"
+    "strconv"
     "time"
     "skyflow/internal/database"
+    "skyflow/internal/jwtkeys"
     "skyflow/internal/models"
+    "github.com/go-chi/chi/v5"
     "github.com/golang-jwt/jwt/v5"
 )
 
 type AuthHandler struct {
-    userRepo *database.UserRepository
-    jwtSecret string
+    userRepo   *database.UserRepository
+    tokenRepo  *database.TokenRepository
+    denylist   *database.TokenDenylist
+    throttle   *database.LoginThrottle
+    keys       *jwtkeys.KeySet
+    accessTTL  time.Duration
+    refreshTTL time.Duration
 }
 
-func NewAuthHandler(userRepo *database.UserRepository, jwtSecret string) *AuthHandler {
+func NewAuthHandler(userRepo *database.UserRepository, tokenRepo *database.TokenRepository, denylist *database.TokenDenylist, throttle *database.LoginThrottle, keys *jwtkeys.KeySet, accessTTL, refreshTTL time.Duration) *AuthHandler {
     return &AuthHandler{
-        userRepo:  userRepo,
-        jwtSecret: jwtSecret,
+        userRepo:   userRepo,
+        tokenRepo:  tokenRepo,
+        denylist:   denylist,
+        throttle:   throttle,
+        keys:       keys,
+        accessTTL:  accessTTL,
+        refreshTTL: refreshTTL,
     }
 }
 
@@ -29,33 +47,268 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
         return
     }
     
+    ip := clientIP(r)
+    
+    // Защита от перебора: имя или IP временно заблокированы
+    wait, err := h.throttle.RetryAfter(r.Context(), req.Username, ip)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if wait > 0 {
+        tooManyAttempts(w, wait)
+        return
+    }
+    
     // Проверяем пользователя
     user, err := h.userRepo.CheckPassword(r.Context(), req.Username, req.Password)
-    if err != nil {
+    if errors.Is(err, database.ErrUserDisabled) {
+        http.Error(w, "Account is disabled", http.StatusForbidden)
+        return
+    }
+    if errors.Is(err, database.ErrUserNotFound) || errors.Is(err, database.ErrInvalidPassword) {
+        wait, err := h.throttle.RecordFailure(r.Context(), req.Username, ip)
+        if err != nil {
+            log.Printf("Failed to record login failure: %v", err)
+        }
+        if wait > 0 {
+            tooManyAttempts(w, wait)
+            return
+        }
         http.Error(w, "Invalid username or password", http.StatusUnauthorized)
         return
     }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    // Пароль верный, но нужен второй фактор. Счетчик ошибок не сбрасываем,
+    // иначе коды можно перебирать, каждый раз заново вводя пароль
+    if user.TwoFactorEnabled {
+        h.respondWithChallenge(w, user)
+        return
+    }
+    
+    if err := h.throttle.RecordSuccess(r.Context(), user.Username); err != nil {
+        log.Printf("Failed to reset login failures: %v", err)
+    }
+    
+    h.respondWithToken(w, r, user)
+}
+
+// Создаем пару access/refresh токенов и отвечаем как на логин
+func (h *AuthHandler) respondWithToken(w http.ResponseWriter, r *http.Request, user *models.User) {
+    access, err := h.newAccess()
+    if err != nil {
+        http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+        return
+    }
     
-    // Создаем JWT токен
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+    tokenString, err := h.signAccessToken(user, access)
+    if err != nil {
+        http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+        return
+    }
+    
+    refreshToken, err := h.tokenRepo.CreateRefreshToken(r.Context(), user.ID, access, h.refreshTTL)
+    if err != nil {
+        http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+        return
+    }
+    
+    h.writeTokens(w, user, tokenString, refreshToken)
+}
+
+func (h *AuthHandler) writeTokens(w http.ResponseWriter, user *models.User, tokenString, refreshToken string) {
+    response := models.LoginResponse{
+        Token:        tokenString,
+        RefreshToken: refreshToken,
+        ExpiresIn:    int64(h.accessTTL / time.Second),
+        User:         *user,
+    }
+    
+    jsonResponse(w, response, http.StatusOK)
+}
+
+// Параметры нового access-токена: уникальный jti для отзыва и срок действия
+func (h *AuthHandler) newAccess() (database.IssuedAccess, error) {
+    jti, err := database.RandomToken(16)
+    if err != nil {
+        return database.IssuedAccess{}, err
+    }
+    return database.IssuedAccess{JTI: jti, ExpiresAt: time.Now().Add(h.accessTTL)}, nil
+}
+
+// Создаем короткоживущий JWT токен
+func (h *AuthHandler) signAccessToken(user *models.User, access database.IssuedAccess) (string, error) {
+    return h.keys.Sign(jwt.MapClaims{
+        "jti": access.JTI,
         "user_id": user.ID,
         "username": user.Username,
         "role": user.Role,
-        "exp": time.Now().Add(time.Hour * 24).Unix(),
+        "pwd_change": user.MustChangePassword,
+        "exp": access.ExpiresAt.Unix(),
     })
+}
+
+// Обмен refresh-токена на новую пару (старый refresh-токен отзывается)
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+    var req models.RefreshRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
     
-    tokenString, err := token.SignedString([]byte(h.jwtSecret))
+    // jti нового access-токена сохраняется вместе с новым refresh-токеном
+    access, err := h.newAccess()
     if err != nil {
         http.Error(w, "Failed to generate token", http.StatusInternalServerError)
         return
     }
     
-    response := models.LoginResponse{
-        Token: tokenString,
-        User:  *user,
+    userID, refreshToken, revoked, err := h.tokenRepo.RotateRefreshToken(r.Context(), req.RefreshToken, access, h.refreshTTL)
+    if errors.Is(err, database.ErrRefreshTokenReused) {
+        h.denylist.Add(revoked...)
+        http.Error(w, "Refresh token reuse detected, please log in again", http.StatusUnauthorized)
+        return
+    }
+    if errors.Is(err, database.ErrInvalidRefreshToken) {
+        http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+        return
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
     }
     
-    jsonResponse(w, response, http.StatusOK)
+    // Роль и статус берем из базы: они могли измениться с прошлого входа
+    user, err := h.userRepo.FindByID(r.Context(), userID)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if user == nil || user.Disabled {
+        revoked, _ := h.tokenRepo.RevokeUserTokens(r.Context(), userID)
+        h.denylist.Add(revoked...)
+        http.Error(w, "Account is disabled", http.StatusUnauthorized)
+        return
+    }
+    
+    tokenString, err := h.signAccessToken(user, access)
+    if err != nil {
+        http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+        return
+    }
+    
+    h.writeTokens(w, user, tokenString, refreshToken)
+}
+
+// Выход: отзыв текущего access-токена и семейства refresh-токена
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+    var req models.LogoutRequest
+    if r.ContentLength != 0 {
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            http.Error(w, "Invalid request body", http.StatusBadRequest)
+            return
+        }
+    }
+    
+    if token, ok := r.Context().Value("token").(models.AccessToken); ok && token.JTI != "" {
+        err := h.denylist.Revoke(r.Context(), models.RevokedToken{JTI: token.JTI, ExpiresAt: token.ExpiresAt})
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+    }
+    
+    if req.RefreshToken != "" {
+        revoked, err := h.tokenRepo.RevokeRefreshToken(r.Context(), req.RefreshToken)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        h.denylist.Add(revoked...)
+    }
+    
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// Смена собственного пароля. Возвращает новый токен
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+    current, ok := r.Context().Value("user").(*models.User)
+    if !ok {
+        http.Error(w, "User not found in context", http.StatusUnauthorized)
+        return
+    }
+    
+    var req models.ChangePasswordRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    var user *models.User
+    check := func() (bool, error) {
+        matched, ok, err := h.passwordMatches(r, current.Username, req.CurrentPassword)
+        user = matched
+        return ok, err
+    }
+    if !h.throttledCheck(w, r, current.Username, check, http.StatusForbidden, "Current password is incorrect") {
+        return
+    }
+    
+    if req.NewPassword == req.CurrentPassword {
+        http.Error(w, "New password must differ from the current one", http.StatusBadRequest)
+        return
+    }
+    if err := validatePassword(req.NewPassword); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    
+    if err := h.userRepo.SetPassword(r.Context(), user.ID, req.NewPassword, false); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    // Все прежние сессии пользователя завершаются
+    revoked, err := h.tokenRepo.RevokeUserTokens(r.Context(), user.ID)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    h.denylist.Add(revoked...)
+    
+    user.MustChangePassword = false
+    h.respondWithToken(w, r, user)
+}
+
+// Счетчики неудачных входов и активные блокировки
+func (h *AuthHandler) ListLockouts(w http.ResponseWriter, r *http.Request) {
+    lockouts, err := h.throttle.List(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, lockouts, http.StatusOK)
+}
+
+// Снять блокировку: /lockouts/user/{subject} или /lockouts/ip/{subject}
+func (h *AuthHandler) ClearLockout(w http.ResponseWriter, r *http.Request) {
+    kind := chi.URLParam(r, "kind")
+    if kind != models.LockoutByUsername && kind != models.LockoutByIP {
+        http.Error(w, "Unknown lockout kind", http.StatusBadRequest)
+        return
+    }
+    
+    if err := h.throttle.Clear(r.Context(), kind, chi.URLParam(r, "subject")); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    w.WriteHeader(http.StatusNoContent)
 }
 
 // Получить текущего пользователя
@@ -70,4 +323,84 @@ func (h *AuthHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
     // Не возвращаем пароль
     user.Password = ""
     jsonResponse(w, user, http.StatusOK)
-}
\ No newline at end of file
+}
+
+const minPasswordLength = 8
+
+func validatePassword(password string) error {
+    if len([]rune(password)) < minPasswordLength {
+        return fmt.Errorf("Password must be at least %d characters long", minPasswordLength)
+    }
+    return nil
+}
+
+// Проверка секрета уже вошедшего пользователя (пароля или кода 2FA) с теми
+// же лимитами, что при входе: иначе украденным токеном можно перебирать
+// пароль или коды. false — ответ уже отправлен
+func (h *AuthHandler) throttledCheck(w http.ResponseWriter, r *http.Request, username string, check func() (bool, error), failStatus int, failMessage string) bool {
+    ip := clientIP(r)
+    
+    wait, err := h.throttle.RetryAfter(r.Context(), username, ip)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return false
+    }
+    if wait > 0 {
+        tooManyAttempts(w, wait)
+        return false
+    }
+    
+    ok, err := check()
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return false
+    }
+    if !ok {
+        wait, err := h.throttle.RecordFailure(r.Context(), username, ip)
+        if err != nil {
+            log.Printf("Failed to record login failure: %v", err)
+        }
+        if wait > 0 {
+            tooManyAttempts(w, wait)
+            return false
+        }
+        http.Error(w, failMessage, failStatus)
+        return false
+    }
+    
+    if err := h.throttle.RecordSuccess(r.Context(), username); err != nil {
+        log.Printf("Failed to reset login failures: %v", err)
+    }
+    return true
+}
+
+// Пароль пользователя верен; ошибка — только сбой базы
+func (h *AuthHandler) passwordMatches(r *http.Request, username, password string) (*models.User, bool, error) {
+    user, err := h.userRepo.CheckPassword(r.Context(), username, password)
+    if errors.Is(err, database.ErrUserNotFound) || errors.Is(err, database.ErrInvalidPassword) || errors.Is(err, database.ErrUserDisabled) {
+        return nil, false, nil
+    }
+    if err != nil {
+        return nil, false, err
+    }
+    return user, true, nil
+}
+
+func tooManyAttempts(w http.ResponseWriter, wait time.Duration) {
+    seconds := int64(math.Ceil(wait.Seconds()))
+    w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+    jsonResponse(w, errorResponse{
+        Error:   "too_many_attempts",
+        Message: "Too many failed login attempts, try again later",
+        Details: map[string]interface{}{"retryAfter": seconds},
+    }, http.StatusTooManyRequests)
+}
+
+// IP клиента. За прокси RemoteAddr уже подменен middleware.RealIP
+func clientIP(r *http.Request) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        return r.RemoteAddr
+    }
+    return host
+}
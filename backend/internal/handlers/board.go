@@ -0,0 +1,152 @@
+package handlers
+
+import (
+    "net/http"
+    "sort"
+    "time"
+    "skyflow/internal/database"
+    "skyflow/internal/models"
+)
+
+// Насколько окно табло можно расширить параметрами запроса
+const maxBoardWindow = 48 * time.Hour
+
+// Рейс прилетает не позже чем через столько после планового вылета:
+// по времени вылета из базы выбирается запас, точное окно считается по прилету
+const maxFlightDuration = 24 * time.Hour
+
+// Табло вылетов и прилетов домашнего аэропорта
+type BoardHandler struct {
+    flightRepo  database.FlightStore
+    reference   *database.ReferenceCache
+    homeAirport string
+    windows     map[string]models.BoardWindow
+}
+
+func NewBoardHandler(flightRepo database.FlightStore, reference *database.ReferenceCache, homeAirport string, departures, arrivals models.BoardWindow) *BoardHandler {
+    return &BoardHandler{
+        flightRepo:  flightRepo,
+        reference:   reference,
+        homeAirport: homeAirport,
+        windows: map[string]models.BoardWindow{
+            models.BoardDepartures: departures,
+            models.BoardArrivals:   arrivals,
+        },
+    }
+}
+
+func (h *BoardHandler) Departures(w http.ResponseWriter, r *http.Request) {
+    h.board(w, r, models.BoardDepartures)
+}
+
+func (h *BoardHandler) Arrivals(w http.ResponseWriter, r *http.Request) {
+    h.board(w, r, models.BoardArrivals)
+}
+
+// Табло за окно показа. Окно можно поменять параметрами
+// past и ahead (длительности, например past=1h&ahead=6h).
+// codeshares=collapse (по умолчанию) — рейс одной строкой со списком
+// коммерческих номеров, expand — отдельная строка на каждый номер
+func (h *BoardHandler) board(w http.ResponseWriter, r *http.Request, direction string) {
+    data := h.reference.Data()
+    airport, ok := data.Airport(h.homeAirport)
+    if !ok {
+        jsonResponse(w, errorResponse{
+            Error:   "home_airport_not_configured",
+            Message: "Home airport is not configured or unknown: " + h.homeAirport,
+        }, http.StatusServiceUnavailable)
+        return
+    }
+    loc, _ := data.Location(airport.IATACode)
+    
+    window := h.windows[direction]
+    for _, p := range []struct {
+        name  string
+        value *time.Duration
+    }{{"past", &window.Past}, {"ahead", &window.Ahead}} {
+        raw := r.URL.Query().Get(p.name)
+        if raw == "" {
+            continue
+        }
+        d, err := time.ParseDuration(raw)
+        if err != nil || d < 0 || d > maxBoardWindow {
+            jsonResponse(w, invalidFilter(p.name, p.name+" must be a duration between 0 and "+maxBoardWindow.String()), http.StatusBadRequest)
+            return
+        }
+        *p.value = d
+    }
+    
+    mode := r.URL.Query().Get("codeshares")
+    switch mode {
+    case "":
+        mode = models.CodeshareCollapse
+    case models.CodeshareCollapse, models.CodeshareExpand:
+    default:
+        jsonResponse(w, invalidFilter("codeshares", "codeshares must be collapse or expand"), http.StatusBadRequest)
+        return
+    }
+    
+    now := time.Now().UTC()
+    board := models.Board{
+        Direction:   direction,
+        Airport:     airport.IATACode,
+        AirportName: airport.Name,
+        City:        airport.City,
+        Timezone:    loc.String(),
+        GeneratedAt: now,
+        WindowFrom:  now.Add(-window.Past),
+        WindowTo:    now.Add(window.Ahead),
+        Codeshares:  mode,
+        Flights:     []models.BoardEntry{},
+    }
+    
+    // Задержанные рейсы и прилеты попадают в окно по ожидаемому времени,
+    // поэтому по плановому вылету выбираем с запасом
+    from := board.WindowFrom.Add(-maxFlightDuration)
+    to := board.WindowTo
+    filter := models.FlightFilter{
+        ScheduledFrom: &from,
+        ScheduledTo:   &to,
+        SortKey:       models.SortScheduled,
+    }
+    if direction == models.BoardArrivals {
+        filter.Destination = airport.IATACode
+    } else {
+        filter.Origin = airport.IATACode
+    }
+    
+    page, err := h.flightRepo.List(r.Context(), filter)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    for _, flight := range page.Flights {
+        entry := models.NewBoardEntry(flight, direction, loc)
+        at := entry.DisplayTime()
+        if at.Before(board.WindowFrom) || at.After(board.WindowTo) {
+            continue
+        }
+        if mode == models.CodeshareExpand && len(flight.Codeshares) > 0 {
+            board.Flights = append(board.Flights, models.CodeshareEntries(entry, flight, data)...)
+            entry.Codeshares = nil
+        }
+        board.Flights = append(board.Flights, entry)
+    }
+    // Строки коммерческих номеров идут сразу после выполняемого рейса
+    sort.SliceStable(board.Flights, func(i, j int) bool {
+        a, b := board.Flights[i], board.Flights[j]
+        if !a.DisplayTime().Equal(b.DisplayTime()) {
+            return a.DisplayTime().Before(b.DisplayTime())
+        }
+        if a.OperatingFlightNumber() != b.OperatingFlightNumber() {
+            return a.OperatingFlightNumber() < b.OperatingFlightNumber()
+        }
+        if (a.OperatedBy == "") != (b.OperatedBy == "") {
+            return a.OperatedBy == ""
+        }
+        return a.FlightNumber < b.FlightNumber
+    })
+    
+    jsonResponse(w, board, http.StatusOK)
+}
@@ -2,30 +2,90 @@ package handlers
 
 import (
     "encoding/json"
+    "errors"
     "net/http"
+    "strconv"
     "time"
     "skyflow/internal/database"
+    "skyflow/internal/events"
     "skyflow/internal/models"
     "github.com/go-chi/chi/v5"
 )
 
 type FlightHandler struct {
-    flightRepo *database.FlightRepository
+    flightRepo database.FlightStore
+    // nil, если изменения публикует FlightChangeListener (LISTEN/NOTIFY)
+    events *events.Broker
+    // nil в демо-режиме без базы
+    history *database.FlightEventRepository
+    // справочники для проверки кодов
+    reference *database.ReferenceCache
+    // nil в демо-режиме без базы
+    schedules *database.ScheduleRepository
+    // код аэропорта, относительно которого считаются вылеты и прилеты
+    homeAirport string
+    // с какой задержки вылета рейс получает статус delayed (0 — не выводить)
+    delayThreshold time.Duration
 }
 
-func NewFlightHandler(flightRepo *database.FlightRepository) *FlightHandler {
-    return &FlightHandler{flightRepo: flightRepo}
+func NewFlightHandler(flightRepo database.FlightStore, broker *events.Broker, history *database.FlightEventRepository, reference *database.ReferenceCache, schedules *database.ScheduleRepository, homeAirport string, delayThreshold time.Duration) *FlightHandler {
+    return &FlightHandler{
+        flightRepo:     flightRepo,
+        events:         broker,
+        history:        history,
+        reference:      reference,
+        schedules:      schedules,
+        homeAirport:    homeAirport,
+        delayThreshold: delayThreshold,
+    }
+}
+
+// Автор изменения для журнала — пользователь из JWT. Запись пишется
+// хранилищем в одной транзакции с изменением: без нее изменение не проходит
+func flightAuthor(r *http.Request) *models.FlightAuthor {
+    author := &models.FlightAuthor{}
+    if user, ok := r.Context().Value("user").(*models.User); ok {
+        author.UserID = user.ID
+        author.Username = user.Username
+    }
+    return author
 }
 
-// Получить все рейсы
+func (h *FlightHandler) publish(eventType events.EventType, flight models.Flight) {
+    if h.events != nil {
+        h.events.Publish(eventType, flight)
+    }
+}
+
+// Список рейсов с фильтрами (см. flightFilter), постранично при limit или cursor.
+// Тело — массив рейсов; всего подходящих — в X-Total-Count,
+// курсор следующей страницы — в X-Next-Cursor и Link rel="next"
 func (h *FlightHandler) GetAllFlights(w http.ResponseWriter, r *http.Request) {
-    flights, err := h.flightRepo.GetAll(r.Context())
+    query := r.URL.Query()
+    filter, errResp := h.flightFilter(query)
+    if errResp == nil {
+        errResp = pageParams(query, &filter)
+    }
+    if errResp != nil {
+        jsonResponse(w, errResp, http.StatusBadRequest)
+        return
+    }
+    
+    page, err := h.flightRepo.List(r.Context(), filter)
     if err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
     
-    jsonResponse(w, flights, http.StatusOK)
+    w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+    if page.NextCursor != "" {
+        next := *r.URL
+        query.Set("cursor", page.NextCursor)
+        next.RawQuery = query.Encode()
+        w.Header().Set("X-Next-Cursor", page.NextCursor)
+        w.Header().Set("Link", `<`+next.RequestURI()+`>; rel="next"`)
+    }
+    jsonResponse(w, page.Flights, http.StatusOK)
 }
 
 // Получить рейс по ID
@@ -72,31 +132,76 @@ func (h *FlightHandler) CreateFlight(w http.ResponseWriter, r *http.Request) {
         return
     }
     
-    // Парсим время
-    scheduled, err := time.Parse(time.RFC3339, req.Scheduled)
-    if err != nil {
-        http.Error(w, "Invalid scheduled time format", http.StatusBadRequest)
+    flight, errResp := h.newFlight(req)
+    if errResp != nil {
+        jsonResponse(w, errResp, http.StatusBadRequest)
+        return
+    }
+    
+    if errResp := checkAirlineScope(r, flight.Airline); errResp != nil {
+        jsonResponse(w, errResp, http.StatusForbidden)
         return
     }
     
+    if err := h.flightRepo.Create(r.Context(), flight, flightAuthor(r)); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    h.publish(events.FlightCreated, *flight)
+    jsonResponse(w, flight, http.StatusCreated)
+}
+
+// Рейс из запроса: коды приводятся к справочным, время без смещения
+// считается местным временем аэропортов вылета и прилета
+func (h *FlightHandler) newFlight(req models.FlightRequest) (*models.Flight, *errorResponse) {
     flight := &models.Flight{
-        FlightNumber: req.FlightNumber,
+        FlightNumber: models.NormalizeFlightNumber(req.FlightNumber),
         Airline:      req.Airline,
         From:         req.From,
         To:           req.To,
-        Scheduled:    scheduled,
-        Actual:       scheduled, // по умолчанию совпадает с запланированным
+        Aircraft:     req.Aircraft,
         Terminal:     req.Terminal,
         Gate:         req.Gate,
         Status:       string(models.StatusScheduled),
+        Codeshares:   req.Codeshares,
     }
     
-    if err := h.flightRepo.Create(r.Context(), flight); err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
-        return
+    // Коды приводятся к справочным до проверки прав ключа на авиакомпанию
+    if errResp := resolveFlightCodes(h.reference.Data(), flight); errResp != nil {
+        return nil, errResp
+    }
+    if errResp := resolveCodeshares(h.reference.Data(), flight); errResp != nil {
+        return nil, errResp
     }
     
-    jsonResponse(w, flight, http.StatusCreated)
+    scheduled, err := h.parseTime(req.Scheduled, flight.From)
+    if err != nil {
+        return nil, invalidTime("scheduled", "Invalid scheduled time format")
+    }
+    flight.Scheduled = scheduled
+    
+    if req.ScheduledArrival != "" {
+        arrival, err := h.parseTime(req.ScheduledArrival, flight.To)
+        if err != nil {
+            return nil, invalidTime("scheduledArrival", "Invalid scheduled arrival time format")
+        }
+        flight.ScheduledArrival = &arrival
+    }
+    if errResp := checkArrivalTime(*flight); errResp != nil {
+        return nil, errResp
+    }
+    
+    flight.ComputeDelay()
+    return flight, nil
+}
+
+func invalidTime(field, message string) *errorResponse {
+    return &errorResponse{
+        Error:   "invalid_time",
+        Message: message,
+        Details: map[string]interface{}{"field": field},
+    }
 }
 
 // Обновить рейс
@@ -115,14 +220,44 @@ func (h *FlightHandler) UpdateFlight(w http.ResponseWriter, r *http.Request) {
         return
     }
     
+    if errResp := checkAirlineScope(r, flight.Airline); errResp != nil {
+        jsonResponse(w, errResp, http.StatusForbidden)
+        return
+    }
+    
+    before := *flight
+    
     var updates map[string]interface{}
     if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
         http.Error(w, "Invalid request body", http.StatusBadRequest)
         return
     }
     
+    // Агент на выходе может менять только выход и начинать посадку
+    if user, ok := r.Context().Value("user").(*models.User); ok && !user.Can(models.PermFlightsUpdate) {
+        if errResp := checkGateAgentUpdate(updates); errResp != nil {
+            jsonResponse(w, errResp, http.StatusForbidden)
+            return
+        }
+    }
+    
     // Обновляем поля
     if status, ok := updates["status"].(string); ok {
+        next := models.FlightStatus(status)
+        if !next.Valid() {
+            jsonResponse(w, errorResponse{
+                Error:   "unknown_status",
+                Message: "Unknown flight status: " + status,
+                Details: map[string]interface{}{"allowed": models.AllFlightStatuses()},
+            }, http.StatusBadRequest)
+            return
+        }
+        
+        if err := models.ValidateStatusTransition(models.FlightStatus(flight.Status), next); err != nil {
+            statusConflict(w, err)
+            return
+        }
+        
         flight.Status = status
     }
     if delayReason, ok := updates["delayReason"].(string); ok {
@@ -134,35 +269,416 @@ func (h *FlightHandler) UpdateFlight(w http.ResponseWriter, r *http.Request) {
     if terminal, ok := updates["terminal"].(string); ok {
         flight.Terminal = terminal
     }
-    if actualTime, ok := updates["actualTime"].(string); ok {
-        if t, err := time.Parse(time.RFC3339, actualTime); err == nil {
-            flight.Actual = t
+    if arrival, ok := updates["scheduledArrival"]; ok {
+        // null или пустая строка убирают время прилета
+        flight.ScheduledArrival = nil
+        if value, _ := arrival.(string); value != "" {
+            t, err := h.parseTime(value, flight.To)
+            if err != nil {
+                http.Error(w, "Invalid scheduled arrival time format", http.StatusBadRequest)
+                return
+            }
+            flight.ScheduledArrival = &t
+        }
+        if errResp := checkArrivalTime(*flight); errResp != nil {
+            jsonResponse(w, errResp, http.StatusBadRequest)
+            return
+        }
+    }
+    if aircraft, ok := updates["aircraft"].(string); ok {
+        flight.Aircraft = aircraft
+        if errResp := resolveAircraft(h.reference.Data(), flight); errResp != nil {
+            jsonResponse(w, errResp, http.StatusBadRequest)
+            return
+        }
+    }
+    if value, ok := updates["codeshares"]; ok {
+        codeshares, err := decodeCodeshares(value)
+        if err != nil {
+            jsonResponse(w, errorResponse{
+                Error:   "invalid_codeshare",
+                Message: err.Error(),
+                Details: map[string]interface{}{"field": "codeshares"},
+            }, http.StatusBadRequest)
+            return
+        }
+        flight.Codeshares = codeshares
+        if errResp := resolveCodeshares(h.reference.Data(), flight); errResp != nil {
+            jsonResponse(w, errResp, http.StatusBadRequest)
+            return
+        }
+    }
+    
+    // Оценочные и фактические времена этапов; null или пустая строка убирают
+    // время. actualTime — прежнее имя estimatedOffBlock
+    if value, ok := updates["actualTime"]; ok {
+        if _, set := updates["estimatedOffBlock"]; !set {
+            updates["estimatedOffBlock"] = value
+        }
+    }
+    for _, stage := range flight.StageTimes() {
+        value, ok := updates[stage.Name]
+        if !ok {
+            continue
+        }
+        t, errResp := h.stageTime(value, stage, *flight)
+        if errResp != nil {
+            jsonResponse(w, errResp, http.StatusBadRequest)
+            return
+        }
+        *stage.Value = t
+    }
+    if value, ok := updates["delayCodes"]; ok {
+        codes, err := decodeDelayCodes(value)
+        if err != nil {
+            jsonResponse(w, errorResponse{
+                Error:   "invalid_delay_code",
+                Message: err.Error(),
+                Details: map[string]interface{}{"field": "delayCodes"},
+            }, http.StatusBadRequest)
+            return
         }
+        flight.DelayCodes = codes
     }
     
-    if err := h.flightRepo.Update(r.Context(), flight); err != nil {
+    // Задержка пересчитывается по временам; статус delayed выводится из нее,
+    // если статус не задан в запросе явно
+    flight.ComputeDelay()
+    if _, ok := updates["status"]; !ok {
+        flight.DeriveDelayedStatus(h.delayThreshold)
+    }
+    if errResp := checkFlightDelay(*flight); errResp != nil {
+        jsonResponse(w, errResp, http.StatusBadRequest)
+        return
+    }
+    
+    // Рейс из расписания, измененный вручную, генератор больше не трогает
+    changes := models.DiffFlights(before, *flight)
+    if flight.ScheduleID != "" && len(changes) > 0 {
+        flight.ScheduleOverride = true
+    }
+    
+    if err := h.flightRepo.Update(r.Context(), flight, flightAuthor(r)); err != nil {
+        if errors.Is(err, database.ErrFlightNotFound) {
+            http.Error(w, "Flight not found", http.StatusNotFound)
+            return
+        }
+        // Статус успели сменить параллельным запросом
+        var transitionErr *models.StatusTransitionError
+        if errors.As(err, &transitionErr) {
+            statusConflict(w, transitionErr)
+            return
+        }
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
     
+    h.publish(events.FlightUpdated, *flight)
     jsonResponse(w, flight, http.StatusOK)
 }
 
+func statusConflict(w http.ResponseWriter, err error) {
+    jsonResponse(w, errorResponse{
+        Error:   "invalid_status_transition",
+        Message: err.Error(),
+        Details: err,
+    }, http.StatusConflict)
+}
+
+// Проверяет коды рейса по справочникам и приводит их к основным:
+// авиакомпания и аэропорты — IATA, тип ВС — ICAO
+func resolveFlightCodes(data *models.ReferenceData, flight *models.Flight) *errorResponse {
+    airline, ok := data.Airline(flight.Airline)
+    if !ok {
+        return &errorResponse{
+            Error:   "unknown_airline",
+            Message: "Unknown airline: " + flight.Airline,
+            Details: map[string]interface{}{"airline": flight.Airline},
+        }
+    }
+    flight.Airline = airline.IATACode
+    
+    for _, code := range []*string{&flight.From, &flight.To} {
+        airport, ok := data.Airport(*code)
+        if !ok {
+            return &errorResponse{
+                Error:   "unknown_airport",
+                Message: "Unknown airport: " + *code,
+                Details: map[string]interface{}{"airport": *code},
+            }
+        }
+        *code = airport.IATACode
+    }
+    if flight.From == flight.To {
+        return &errorResponse{
+            Error:   "same_airport",
+            Message: "Origin and destination must differ",
+            Details: map[string]interface{}{"airport": flight.From},
+        }
+    }
+    
+    return resolveAircraft(data, flight)
+}
+
+// Тип ВС необязателен, но если указан — должен быть в справочнике
+func resolveAircraft(data *models.ReferenceData, flight *models.Flight) *errorResponse {
+    if flight.Aircraft = models.NormalizeCode(flight.Aircraft); flight.Aircraft == "" {
+        return nil
+    }
+    
+    aircraft, ok := data.Aircraft(flight.Aircraft)
+    if !ok {
+        return &errorResponse{
+            Error:   "unknown_aircraft",
+            Message: "Unknown aircraft type: " + flight.Aircraft,
+            Details: map[string]interface{}{"aircraft": flight.Aircraft},
+        }
+    }
+    flight.Aircraft = aircraft.ICAOCode
+    return nil
+}
+
+// Коммерческие номера приводятся к единому виду, их авиакомпании — к IATA
+func resolveCodeshares(data *models.ReferenceData, flight *models.Flight) *errorResponse {
+    codeshares, err := models.NormalizeCodeshares(flight.FlightNumber, flight.Codeshares)
+    if err != nil {
+        return &errorResponse{
+            Error:   "invalid_codeshare",
+            Message: err.Error(),
+            Details: err,
+        }
+    }
+    
+    for i, c := range codeshares {
+        airline, ok := data.Airline(c.Airline)
+        if !ok {
+            return &errorResponse{
+                Error:   "unknown_airline",
+                Message: "Unknown codeshare airline: " + c.Airline,
+                Details: map[string]interface{}{"airline": c.Airline, "field": "codeshares"},
+            }
+        }
+        codeshares[i].Airline = airline.IATACode
+    }
+    
+    flight.Codeshares = nil
+    if len(codeshares) > 0 {
+        flight.Codeshares = codeshares
+    }
+    return nil
+}
+
+// Коммерческие номера: массив [{"flightNumber":"SU 1234","airline":"SU"}],
+// строка "SU 1234, AF 567" или null (убрать все)
+func decodeCodeshares(value interface{}) ([]models.Codeshare, error) {
+    switch v := value.(type) {
+    case nil:
+        return nil, nil
+    case string:
+        return models.ParseCodeshares(v), nil
+    }
+    
+    data, _ := json.Marshal(value)
+    var codeshares []models.Codeshare
+    if err := json.Unmarshal(data, &codeshares); err != nil {
+        return nil, errors.New(`codeshares must be an array of {"flightNumber", "airline"} or a string like "SU 1234, AF 567"`)
+    }
+    return codeshares, nil
+}
+
+// Время рейса в поясе аэропорта (если в строке нет смещения)
+func (h *FlightHandler) parseTime(value, airport string) (time.Time, error) {
+    loc, _ := h.reference.Data().Location(airport)
+    return models.ParseFlightTime(value, loc)
+}
+
+func checkArrivalTime(flight models.Flight) *errorResponse {
+    if flight.ScheduledArrival == nil || flight.ScheduledArrival.After(flight.Scheduled) {
+        return nil
+    }
+    return &errorResponse{
+        Error:   "invalid_arrival_time",
+        Message: "Scheduled arrival must be after scheduled departure",
+        Details: map[string]interface{}{
+            "scheduled":        flight.Scheduled,
+            "scheduledArrival": flight.ScheduledArrival,
+        },
+    }
+}
+
+// Время этапа из тела запроса; null или пустая строка — nil.
+// Время без смещения — по часам аэропорта вылета или прилета
+func (h *FlightHandler) stageTime(value interface{}, stage models.StageTime, flight models.Flight) (*time.Time, *errorResponse) {
+    raw, ok := value.(string)
+    if value != nil && !ok {
+        return nil, invalidTime(stage.Name, stage.Name+" must be a string")
+    }
+    if raw == "" {
+        return nil, nil
+    }
+    
+    airport := flight.From
+    if stage.Arrival {
+        airport = flight.To
+    }
+    t, err := h.parseTime(raw, airport)
+    if err != nil {
+        return nil, invalidTime(stage.Name, "Invalid "+stage.Name+" time format")
+    }
+    return &t, nil
+}
+
+// Коды задержки: массив [{"code":"93","minutes":25}], строка "93/25 81/10"
+// или null (убрать коды)
+func decodeDelayCodes(value interface{}) ([]models.FlightDelay, error) {
+    switch v := value.(type) {
+    case nil:
+        return nil, nil
+    case string:
+        return models.ParseDelayCodes(v)
+    }
+    
+    data, _ := json.Marshal(value)
+    var delays []models.FlightDelay
+    if err := json.Unmarshal(data, &delays); err != nil {
+        return nil, errors.New(`delayCodes must be an array of {"code", "minutes"} or a string like "93/25 81/10"`)
+    }
+    return models.NormalizeFlightDelays(delays)
+}
+
+// Этапы идут по порядку, минут по кодам задержки не больше самой задержки
+func checkFlightDelay(flight models.Flight) *errorResponse {
+    if field, ok := flight.CheckStageOrder(); !ok {
+        return invalidTime(field, field+" is earlier than the previous stage of the flight")
+    }
+    
+    if coded := models.DelayCodeMinutes(flight.DelayCodes); coded > flight.DelayMinutes {
+        return &errorResponse{
+            Error:   "delay_codes_exceed_delay",
+            Message: "Delay codes account for more minutes than the flight is delayed",
+            Details: map[string]interface{}{
+                "field":        "delayCodes",
+                "delayMinutes": flight.DelayMinutes,
+                "codedMinutes": coded,
+            },
+        }
+    }
+    return nil
+}
+
+func checkGateAgentUpdate(updates map[string]interface{}) *errorResponse {
+    for field := range updates {
+        allowed := false
+        for _, f := range models.GateAgentFields {
+            if field == f {
+                allowed = true
+                break
+            }
+        }
+        if !allowed {
+            return &errorResponse{
+                Error:   "forbidden_field",
+                Message: "Your role cannot change field " + field,
+                Details: map[string]interface{}{"allowed": models.GateAgentFields},
+            }
+        }
+    }
+    
+    if status, ok := updates["status"].(string); ok {
+        for _, s := range models.GateAgentStatuses {
+            if models.FlightStatus(status) == s {
+                return nil
+            }
+        }
+        return &errorResponse{
+            Error:   "forbidden_status",
+            Message: "Your role cannot set status " + status,
+            Details: map[string]interface{}{"allowed": models.GateAgentStatuses},
+        }
+    }
+    
+    return nil
+}
+
+// Ключ API, привязанный к авиакомпании, не может менять чужие рейсы
+func checkAirlineScope(r *http.Request, airline string) *errorResponse {
+    user, ok := r.Context().Value("user").(*models.User)
+    if !ok || user.APIKey == nil || user.APIKey.CanManageAirline(airline) {
+        return nil
+    }
+    
+    return &errorResponse{
+        Error:   "forbidden_airline",
+        Message: "This API key can only manage flights of " + user.APIKey.Airline,
+        Details: map[string]interface{}{"airline": user.APIKey.Airline},
+    }
+}
+
 // Удалить рейс
 func (h *FlightHandler) DeleteFlight(w http.ResponseWriter, r *http.Request) {
     flightID := chi.URLParam(r, "id")
     
-    if err := h.flightRepo.Delete(r.Context(), flightID); err != nil {
+    // Запоминаем рейс, чтобы клиенты знали, что именно удалено
+    flight, err := h.flightRepo.GetByID(r.Context(), flightID)
+    if err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
     
+    if flight == nil {
+        http.Error(w, "Flight not found", http.StatusNotFound)
+        return
+    }
+    
+    if errResp := checkAirlineScope(r, flight.Airline); errResp != nil {
+        jsonResponse(w, errResp, http.StatusForbidden)
+        return
+    }
+    
+    // Удаленный рейс расписания не должен появиться снова при генерации
+    if h.schedules != nil && flight.ScheduleID != "" && flight.ServiceDate != "" {
+        if err := h.schedules.AddException(r.Context(), flight.ScheduleID, flight.ServiceDate); err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+    }
+    
+    if err := h.flightRepo.Delete(r.Context(), flightID, flightAuthor(r)); err != nil {
+        if errors.Is(err, database.ErrFlightNotFound) {
+            http.Error(w, "Flight not found", http.StatusNotFound)
+            return
+        }
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    h.publish(events.FlightDeleted, *flight)
     w.WriteHeader(http.StatusNoContent)
 }
 
+// История изменений рейса (в том числе удаленного)
+func (h *FlightHandler) GetFlightHistory(w http.ResponseWriter, r *http.Request) {
+    flightID := chi.URLParam(r, "id")
+    
+    history, err := h.history.GetByFlightID(r.Context(), flightID)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, history, http.StatusOK)
+}
+
+// Машиночитаемая ошибка API
+type errorResponse struct {
+    Error   string      `json:"error"`
+    Message string      `json:"message"`
+    Details interface{} `json:"details,omitempty"`
+}
+
 // Вспомогательная функция для JSON ответов
 func jsonResponse(w http.ResponseWriter, data interface{}, statusCode int) {
     w.Header().Set("Content-Type", "application/json")
     w.WriteHeader(statusCode)
     json.NewEncoder(w).Encode(data)
-}
\ No newline at end of file
+}
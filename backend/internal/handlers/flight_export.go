@@ -0,0 +1,146 @@
+package handlers
+
+import (
+    "encoding/csv"
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+    "skyflow/internal/models"
+    "skyflow/internal/xlsx"
+)
+
+// Столбцы выгрузки. Имена полей импорта совпадают, поэтому выгрузку
+// можно загрузить обратно без сопоставления столбцов
+var exportColumns = []string{
+    "id", "flightNumber", "airline", "airlineName",
+    "from", "fromCity", "to", "toCity",
+    "scheduled", "scheduledLocal", "scheduledArrival", "scheduledArrivalLocal",
+    "actual", "actualLocal",
+    "estimatedOffBlock", "estimatedTakeoff", "estimatedLanding", "estimatedInBlock",
+    "actualOffBlock", "actualTakeoff", "actualLanding", "actualInBlock",
+    "delayMinutes", "arrivalDelayMinutes", "delayCodes",
+    "terminal", "gate", "status", "delayReason", "aircraft", "codeshares",
+}
+
+// Больше строк в одну выгрузку не отдается: фильтр нужно сузить
+const maxExportRows = 10000
+
+// Выгрузка рейсов: format=csv|json|xlsx (по умолчанию csv).
+// Фильтры и сортировка — как у списка, но без постраничной разбивки
+func (h *FlightHandler) ExportFlights(w http.ResponseWriter, r *http.Request) {
+    format := r.URL.Query().Get("format")
+    if format == "" {
+        format = "csv"
+    }
+    if format != "csv" && format != "json" && format != "xlsx" {
+        jsonResponse(w, errorResponse{
+            Error:   "unsupported_format",
+            Message: "Unsupported export format: " + format,
+            Details: map[string]interface{}{"allowed": []string{"csv", "json", "xlsx"}},
+        }, http.StatusBadRequest)
+        return
+    }
+    
+    filter, errResp := h.flightFilter(r.URL.Query())
+    if errResp != nil {
+        jsonResponse(w, errResp, http.StatusBadRequest)
+        return
+    }
+    
+    filter.Limit = maxExportRows
+    page, err := h.flightRepo.List(r.Context(), filter)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if page.NextCursor != "" {
+        jsonResponse(w, errorResponse{
+            Error:   "export_too_large",
+            Message: "Too many flights to export, narrow the filter",
+            Details: map[string]interface{}{"total": page.Total, "limit": maxExportRows},
+        }, http.StatusBadRequest)
+        return
+    }
+    flights := page.Flights
+    
+    filename := fmt.Sprintf("flights-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+    w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+    
+    switch format {
+    case "json":
+        jsonResponse(w, flights, http.StatusOK)
+    case "csv":
+        w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+        // BOM — чтобы Excel распознал UTF-8
+        w.Write([]byte("\xef\xbb\xbf"))
+        if err := csv.NewWriter(w).WriteAll(exportRows(flights)); err != nil {
+            log.Printf("Failed to write flights export: %v", err)
+        }
+    case "xlsx":
+        w.Header().Set("Content-Type", xlsx.ContentType)
+        if err := xlsx.Write(w, "Flights", exportRows(flights)); err != nil {
+            log.Printf("Failed to write flights export: %v", err)
+        }
+    }
+}
+
+// Таблица выгрузки с заголовком
+func exportRows(flights []models.Flight) [][]string {
+    rows := make([][]string, 0, len(flights)+1)
+    rows = append(rows, exportColumns)
+    for _, f := range flights {
+        var local models.FlightLocalTimes
+        if f.Local != nil {
+            local = *f.Local
+        }
+        arrival := ""
+        if f.ScheduledArrival != nil {
+            arrival = f.ScheduledArrival.UTC().Format(time.RFC3339)
+        }
+        row := []string{
+            f.ID, f.FlightNumber, f.Airline, f.AirlineName,
+            f.From, f.FromCity, f.To, f.ToCity,
+            f.Scheduled.UTC().Format(time.RFC3339), local.Scheduled, arrival, local.ScheduledArrival,
+            f.Actual.UTC().Format(time.RFC3339), local.Actual,
+        }
+        for _, stage := range f.StageTimes() {
+            value := ""
+            if t := *stage.Value; t != nil {
+                value = t.UTC().Format(time.RFC3339)
+            }
+            row = append(row, value)
+        }
+        row = append(row,
+            strconv.Itoa(f.DelayMinutes), strconv.Itoa(f.ArrivalDelayMinutes), models.FormatDelayCodes(f.DelayCodes),
+            f.Terminal, f.Gate, f.Status, f.DelayReason, f.Aircraft, models.FormatCodeshares(f.Codeshares),
+        )
+        for i := range row {
+            row[i] = escapeCell(row[i])
+        }
+        rows = append(rows, row)
+    }
+    return rows
+}
+
+// Текст, который табличный редактор принял бы за формулу, выгружается
+// с апострофом впереди. Числа (в том числе отрицательные) не трогаем
+func escapeCell(value string) string {
+    if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+        return value
+    }
+    if _, err := strconv.ParseFloat(value, 64); err == nil {
+        return value
+    }
+    return "'" + value
+}
+
+// Обратное к escapeCell: выгрузку можно загрузить обратно как есть
+func unescapeCell(value string) string {
+    if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+        return value[1:]
+    }
+    return value
+}
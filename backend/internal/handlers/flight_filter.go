@@ -0,0 +1,173 @@
+package handlers
+
+import (
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+    "skyflow/internal/models"
+)
+
+const (
+    defaultFlightPageSize = 100
+    maxFlightPageSize     = 500
+)
+
+// Фильтр списка рейсов из параметров запроса (общий для списка и выгрузки):
+//
+//	direction=departures|arrivals  вылеты из домашнего аэропорта или прилеты в него
+//	dateFrom, dateTo               даты плановых вылетов включительно (2006-01-02,
+//	                               по часам домашнего аэропорта) или моменты RFC3339
+//	airline                        код IATA/ICAO или название
+//	status=boarding,delayed        один или несколько статусов
+//	terminal, gate
+//	q                              подстрока номера рейса или город/аэропорт
+//	archived=true|all              только архивные или все (по умолчанию без архива)
+//	sort=scheduled|-scheduled      ключ сортировки, "-" — по убыванию
+//	limit, cursor                  размер страницы и курсор из X-Next-Cursor
+//	                               (без них — весь список одним ответом)
+func (h *FlightHandler) flightFilter(query url.Values) (models.FlightFilter, *errorResponse) {
+    data := h.reference.Data()
+    home := h.homeAirport
+    if a, ok := data.Airport(home); ok {
+        home = a.IATACode
+    }
+    
+    var filter models.FlightFilter
+    
+    if direction := query.Get("direction"); direction != "" {
+        if home == "" {
+            return filter, invalidFilter("direction", "Home airport is not configured")
+        }
+        switch direction {
+        case "departures":
+            filter.Origin = home
+        case "arrivals":
+            filter.Destination = home
+        default:
+            return filter, invalidFilter("direction", "direction must be departures or arrivals")
+        }
+    }
+    
+    loc, _ := data.Location(home)
+    if value := query.Get("dateFrom"); value != "" {
+        t, err := parseFilterTime(value, loc, false)
+        if err != nil {
+            return filter, invalidFilter("dateFrom", "dateFrom must be a date (2006-01-02) or RFC3339 time")
+        }
+        filter.ScheduledFrom = &t
+    }
+    if value := query.Get("dateTo"); value != "" {
+        t, err := parseFilterTime(value, loc, true)
+        if err != nil {
+            return filter, invalidFilter("dateTo", "dateTo must be a date (2006-01-02) or RFC3339 time")
+        }
+        filter.ScheduledTo = &t
+    }
+    
+    if value := query.Get("airline"); value != "" {
+        airline, ok := data.Airline(value)
+        if !ok {
+            return filter, &errorResponse{
+                Error:   "unknown_airline",
+                Message: "Unknown airline: " + value,
+                Details: map[string]interface{}{"airline": value},
+            }
+        }
+        filter.Airline = airline.IATACode
+    }
+    
+    for _, value := range query["status"] {
+        for _, status := range strings.Split(value, ",") {
+            status = strings.ToLower(strings.TrimSpace(status))
+            if status == "" {
+                continue
+            }
+            if !models.FlightStatus(status).Valid() {
+                return filter, &errorResponse{
+                    Error:   "invalid_filter",
+                    Message: "Unknown flight status: " + status,
+                    Details: map[string]interface{}{"field": "status", "allowed": models.AllFlightStatuses()},
+                }
+            }
+            filter.Statuses = append(filter.Statuses, status)
+        }
+    }
+    
+    switch query.Get("archived") {
+    case "", "false":
+    case "true":
+        filter.Archived = models.ArchivedOnly
+    case "all":
+        filter.Archived = models.ArchivedInclude
+    default:
+        return filter, invalidFilter("archived", "archived must be true, false or all")
+    }
+    
+    filter.Terminal = strings.TrimSpace(query.Get("terminal"))
+    filter.Gate = strings.TrimSpace(query.Get("gate"))
+    
+    if q := strings.TrimSpace(query.Get("q")); q != "" {
+        filter.Search = q
+        filter.SearchAirports = data.FindAirports(q)
+    }
+    
+    filter.SortKey = models.SortScheduled
+    if value := query.Get("sort"); value != "" {
+        filter.SortKey = strings.TrimPrefix(value, "-")
+        filter.SortDesc = strings.HasPrefix(value, "-")
+        if !models.ValidFlightSortKey(filter.SortKey) {
+            return filter, &errorResponse{
+                Error:   "invalid_filter",
+                Message: "Unknown sort key: " + filter.SortKey,
+                Details: map[string]interface{}{"field": "sort", "allowed": models.FlightSortKeys()},
+            }
+        }
+    }
+    
+    return filter, nil
+}
+
+// Размер страницы и курсор; курсор действителен только для той же сортировки.
+// Без limit и cursor список не делится на страницы, как было до курсоров
+func pageParams(query url.Values, filter *models.FlightFilter) *errorResponse {
+    if query.Get("cursor") != "" {
+        filter.Limit = defaultFlightPageSize
+    }
+    if value := query.Get("limit"); value != "" {
+        limit, err := strconv.Atoi(value)
+        if err != nil || limit < 1 || limit > maxFlightPageSize {
+            return invalidFilter("limit", "limit must be between 1 and "+strconv.Itoa(maxFlightPageSize))
+        }
+        filter.Limit = limit
+    }
+    
+    if value := query.Get("cursor"); value != "" {
+        cursor, err := models.DecodeFlightCursor(value, filter.SortKey, filter.SortDesc)
+        if err != nil {
+            return invalidFilter("cursor", "Invalid cursor or cursor from a different sort order")
+        }
+        filter.After = cursor
+    }
+    return nil
+}
+
+// Дата — начало дня в поясе loc (для конца диапазона — начало следующего дня),
+// иначе время RFC3339 или местное время без смещения
+func parseFilterTime(value string, loc *time.Location, end bool) (time.Time, error) {
+    if day, err := time.ParseInLocation(models.DateLayout, value, loc); err == nil {
+        if end {
+            day = day.AddDate(0, 0, 1)
+        }
+        return day.UTC(), nil
+    }
+    return models.ParseFlightTime(value, loc)
+}
+
+func invalidFilter(field, message string) *errorResponse {
+    return &errorResponse{
+        Error:   "invalid_filter",
+        Message: message,
+        Details: map[string]interface{}{"field": field},
+    }
+}
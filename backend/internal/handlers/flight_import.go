@@ -0,0 +1,442 @@
+package handlers
+
+import (
+    "bytes"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "mime"
+    "net/http"
+    "strings"
+    "unicode/utf8"
+    "skyflow/internal/events"
+    "skyflow/internal/models"
+)
+
+const (
+    // Ограничения одного импорта
+    maxImportBytes = 16 << 20
+    maxImportRows  = 5000
+)
+
+// Массовое создание рейсов из CSV (с заголовком) или JSON-массива объектов.
+//
+// Параметры запроса:
+//
+//	format=csv|json   по умолчанию по Content-Type
+//	mapping={...}     JSON: поле рейса -> имя столбца в файле,
+//	                  например {"flightNumber":"Рейс","scheduled":"STD"};
+//	                  не указанные поля ищутся по своему имени без учета регистра
+//	delimiter=tab     разделитель CSV: символ или comma|semicolon|tab;
+//	                  по умолчанию определяется по строке заголовка
+//	dryRun=true       только проверить
+//
+// Все или ничего: при ошибке хотя бы в одной строке возвращается 422
+// с отчетом по строкам и ничего не создается
+func (h *FlightHandler) ImportFlights(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query()
+    
+    format := strings.ToLower(query.Get("format"))
+    if format == "" {
+        format = importFormat(r.Header.Get("Content-Type"))
+    }
+    if format != "csv" && format != "json" {
+        jsonResponse(w, errorResponse{
+            Error:   "unsupported_format",
+            Message: "Send text/csv or application/json, or set format=csv|json",
+        }, http.StatusUnsupportedMediaType)
+        return
+    }
+    
+    mapping := map[string]string{}
+    if raw := query.Get("mapping"); raw != "" {
+        if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+            http.Error(w, "Invalid mapping: expected a JSON object", http.StatusBadRequest)
+            return
+        }
+        for field := range mapping {
+            if !isImportField(field) {
+                jsonResponse(w, errorResponse{
+                    Error:   "unknown_field",
+                    Message: "Unknown flight field in mapping: " + field,
+                    Details: map[string]interface{}{"allowed": models.FlightImportFields},
+                }, http.StatusBadRequest)
+                return
+            }
+        }
+    }
+    
+    body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImportBytes))
+    if err != nil {
+        http.Error(w, "Request body is too large", http.StatusRequestEntityTooLarge)
+        return
+    }
+    
+    var records []importRecord
+    if format == "csv" {
+        records, err = readCSVRecords(body, query.Get("delimiter"))
+    } else {
+        records, err = readJSONRecords(body)
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    if len(records) == 0 {
+        http.Error(w, "No rows to import", http.StatusBadRequest)
+        return
+    }
+    if len(records) > maxImportRows {
+        http.Error(w, fmt.Sprintf("Too many rows: at most %d per import", maxImportRows), http.StatusRequestEntityTooLarge)
+        return
+    }
+    
+    report := models.FlightImportReport{
+        Format: format,
+        DryRun: query.Get("dryRun") == "true",
+        Rows:   len(records),
+        Errors: []models.FlightImportError{},
+    }
+    
+    flights := make([]*models.Flight, 0, len(records))
+    seen := make(map[string]int)
+    for _, rec := range records {
+        row := rec.row(mapping)
+        
+        flight, rowErr := h.importFlight(r, row)
+        if rowErr != nil {
+            rowErr.Row = rec.line
+            report.Errors = append(report.Errors, *rowErr)
+            continue
+        }
+        
+        // Один и тот же рейс дважды в файле — скорее всего ошибка выгрузки
+        key := flight.FlightNumber + "|" + flight.Scheduled.Format("2006-01-02T15:04")
+        if first, dup := seen[key]; dup {
+            report.Errors = append(report.Errors, models.FlightImportError{
+                Row:     rec.line,
+                Field:   "flightNumber",
+                Error:   "duplicate_flight",
+                Message: fmt.Sprintf("Flight %s at this time already appears in row %d", flight.FlightNumber, first),
+            })
+            continue
+        }
+        seen[key] = rec.line
+        flights = append(flights, flight)
+    }
+    
+    if len(report.Errors) > 0 {
+        jsonResponse(w, report, http.StatusUnprocessableEntity)
+        return
+    }
+    if report.DryRun {
+        jsonResponse(w, report, http.StatusOK)
+        return
+    }
+    
+    if err := h.flightRepo.CreateMany(r.Context(), flights, flightAuthor(r)); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    report.Created = len(flights)
+    report.Flights = make([]models.Flight, 0, len(flights))
+    for _, flight := range flights {
+        h.publish(events.FlightCreated, *flight)
+        report.Flights = append(report.Flights, *flight)
+    }
+    
+    jsonResponse(w, report, http.StatusCreated)
+}
+
+// Проверяет строку так же, как CreateFlight, и дополнительно
+// времена этапов, статус и задержку
+func (h *FlightHandler) importFlight(r *http.Request, row models.FlightImportRow) (*models.Flight, *models.FlightImportError) {
+    fields := map[string]string{
+        "flightNumber": row.FlightNumber,
+        "airline":      row.Airline,
+        "from":         row.From,
+        "to":           row.To,
+        "scheduled":    row.Scheduled,
+    }
+    for _, field := range models.FlightImportRequired {
+        if fields[field] == "" {
+            return nil, &models.FlightImportError{Field: field, Error: "required", Message: field + " is required"}
+        }
+    }
+    
+    flight, errResp := h.newFlight(row.FlightRequest)
+    if errResp == nil {
+        errResp = checkAirlineScope(r, flight.Airline)
+    }
+    if errResp != nil {
+        return nil, &models.FlightImportError{
+            Field:   importErrorField(errResp, row),
+            Error:   errResp.Error,
+            Message: errResp.Message,
+        }
+    }
+    
+    if row.Actual != "" && row.StageTimes["estimatedOffBlock"] == "" && row.StageTimes["actualOffBlock"] == "" {
+        actual, err := h.parseTime(row.Actual, flight.From)
+        if err != nil {
+            return nil, &models.FlightImportError{Field: "actual", Error: "invalid_time", Message: "Invalid actual time format"}
+        }
+        if !actual.Equal(flight.Scheduled) {
+            flight.EstimatedOffBlock = &actual
+        }
+    }
+    for _, stage := range flight.StageTimes() {
+        t, errResp := h.stageTime(row.StageTimes[stage.Name], stage, *flight)
+        if errResp != nil {
+            return nil, &models.FlightImportError{Field: stage.Name, Error: errResp.Error, Message: errResp.Message}
+        }
+        if t != nil {
+            *stage.Value = t
+        }
+    }
+    if row.DelayCodes != "" {
+        codes, err := models.ParseDelayCodes(row.DelayCodes)
+        if err != nil {
+            return nil, &models.FlightImportError{Field: "delayCodes", Error: "invalid_delay_code", Message: err.Error()}
+        }
+        flight.DelayCodes = codes
+    }
+    
+    flight.ComputeDelay()
+    if row.Status != "" {
+        status := models.FlightStatus(strings.ToLower(row.Status))
+        if !status.Valid() {
+            return nil, &models.FlightImportError{Field: "status", Error: "invalid_status", Message: "Unknown flight status: " + row.Status}
+        }
+        flight.Status = string(status)
+    } else {
+        flight.DeriveDelayedStatus(h.delayThreshold)
+    }
+    flight.DelayReason = row.DelayReason
+    
+    if errResp := checkFlightDelay(*flight); errResp != nil {
+        field, _ := errResp.Details.(map[string]interface{})["field"].(string)
+        return nil, &models.FlightImportError{Field: field, Error: errResp.Error, Message: errResp.Message}
+    }
+    return flight, nil
+}
+
+// Поле строки, к которому относится ошибка проверки рейса
+func importErrorField(errResp *errorResponse, row models.FlightImportRow) string {
+    details, _ := errResp.Details.(map[string]interface{})
+    switch errResp.Error {
+    case "unknown_airline", "forbidden_airline":
+        if details["field"] == "codeshares" {
+            return "codeshares"
+        }
+        return "airline"
+    case "invalid_codeshare":
+        return "codeshares"
+    case "unknown_airport":
+        if details["airport"] == row.From {
+            return "from"
+        }
+        return "to"
+    case "same_airport":
+        return "to"
+    case "unknown_aircraft":
+        return "aircraft"
+    case "invalid_arrival_time":
+        return "scheduledArrival"
+    case "invalid_time":
+        field, _ := details["field"].(string)
+        return field
+    }
+    return ""
+}
+
+func importFormat(contentType string) string {
+    mediaType, _, _ := mime.ParseMediaType(contentType)
+    switch mediaType {
+    case "text/csv", "application/csv":
+        return "csv"
+    case "application/json":
+        return "json"
+    }
+    return ""
+}
+
+func isImportField(field string) bool {
+    for _, f := range models.FlightImportFields {
+        if f == field {
+            return true
+        }
+    }
+    return false
+}
+
+// Запись файла: значения по именам столбцов (в нижнем регистре)
+type importRecord struct {
+    line   int
+    values map[string]string
+}
+
+func (rec importRecord) get(field string, mapping map[string]string) string {
+    column := field
+    if mapped, ok := mapping[field]; ok {
+        column = mapped
+    }
+    return unescapeCell(strings.TrimSpace(rec.values[strings.ToLower(strings.TrimSpace(column))]))
+}
+
+func (rec importRecord) row(mapping map[string]string) models.FlightImportRow {
+    get := func(field string) string { return rec.get(field, mapping) }
+    stages := make(map[string]string)
+    for _, stage := range new(models.Flight).StageTimes() {
+        if value := get(stage.Name); value != "" {
+            stages[stage.Name] = value
+        }
+    }
+    return models.FlightImportRow{
+        FlightRequest: models.FlightRequest{
+            FlightNumber:     get("flightNumber"),
+            Airline:          get("airline"),
+            From:             get("from"),
+            To:               get("to"),
+            Scheduled:        get("scheduled"),
+            ScheduledArrival: get("scheduledArrival"),
+            Terminal:         get("terminal"),
+            Gate:             get("gate"),
+            Aircraft:         get("aircraft"),
+            Codeshares:       models.ParseCodeshares(get("codeshares")),
+        },
+        Actual:      get("actual"),
+        Status:      get("status"),
+        DelayReason: get("delayReason"),
+        StageTimes:  stages,
+        DelayCodes:  get("delayCodes"),
+    }
+}
+
+func readCSVRecords(body []byte, delimiter string) ([]importRecord, error) {
+    // Excel сохраняет UTF-8 с BOM
+    body = bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))
+    
+    reader := csv.NewReader(bytes.NewReader(body))
+    reader.FieldsPerRecord = -1
+    reader.TrimLeadingSpace = true
+    comma, err := csvDelimiter(body, delimiter)
+    if err != nil {
+        return nil, err
+    }
+    reader.Comma = comma
+    
+    header, err := reader.Read()
+    if err == io.EOF {
+        return nil, fmt.Errorf("CSV file is empty")
+    }
+    if err != nil {
+        return nil, fmt.Errorf("invalid CSV header: %v", err)
+    }
+    for i := range header {
+        header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+    }
+    
+    var records []importRecord
+    for {
+        fields, err := reader.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("invalid CSV: %v", err)
+        }
+        line, _ := reader.FieldPos(0)
+        if len(fields) == 1 && strings.TrimSpace(fields[0]) == "" {
+            continue
+        }
+        
+        rec := importRecord{line: line, values: make(map[string]string, len(header))}
+        for i, name := range header {
+            if i < len(fields) {
+                rec.values[name] = fields[i]
+            }
+        }
+        records = append(records, rec)
+    }
+    return records, nil
+}
+
+// Разделитель из параметра (символ или comma|semicolon|tab); без параметра —
+// тот из , ; и табуляции, что чаще встречается в строке заголовка
+func csvDelimiter(body []byte, name string) (rune, error) {
+    switch name {
+    case "":
+        header := string(body)
+        if i := strings.IndexByte(header, '\n'); i >= 0 {
+            header = header[:i]
+        }
+        best, count := ',', strings.Count(header, ",")
+        for _, d := range []rune{';', '\t'} {
+            if n := strings.Count(header, string(d)); n > count {
+                best, count = d, n
+            }
+        }
+        return best, nil
+    case "comma":
+        return ',', nil
+    case "semicolon":
+        return ';', nil
+    case "tab", `\t`:
+        return '\t', nil
+    }
+    
+    d, size := utf8.DecodeRuneInString(name)
+    if size != len(name) || d == '"' || d == '\n' || d == '\r' || d == utf8.RuneError {
+        return 0, fmt.Errorf("invalid CSV delimiter %q", name)
+    }
+    return d, nil
+}
+
+func readJSONRecords(body []byte) ([]importRecord, error) {
+    decoder := json.NewDecoder(bytes.NewReader(body))
+    decoder.UseNumber()
+    
+    var items []map[string]interface{}
+    if err := decoder.Decode(&items); err != nil {
+        return nil, fmt.Errorf("invalid JSON: expected an array of objects")
+    }
+    
+    records := make([]importRecord, 0, len(items))
+    for i, item := range items {
+        rec := importRecord{line: i + 1, values: make(map[string]string, len(item))}
+        for key, value := range item {
+            switch v := value.(type) {
+            case nil:
+            case string:
+                rec.values[strings.ToLower(key)] = v
+            case json.Number, bool:
+                rec.values[strings.ToLower(key)] = fmt.Sprint(v)
+            case []interface{}:
+                // Коды задержки и коммерческие номера в том же виде, что в выдаче рейса
+                switch strings.ToLower(key) {
+                case "delaycodes":
+                    codes, err := decodeDelayCodes(v)
+                    if err != nil {
+                        return nil, fmt.Errorf("invalid JSON: element %d: %v", i+1, err)
+                    }
+                    rec.values["delaycodes"] = models.FormatDelayCodes(codes)
+                case "codeshares":
+                    codeshares, err := decodeCodeshares(v)
+                    if err != nil {
+                        return nil, fmt.Errorf("invalid JSON: element %d: %v", i+1, err)
+                    }
+                    rec.values["codeshares"] = models.FormatCodeshares(codeshares)
+                default:
+                    return nil, fmt.Errorf("invalid JSON: element %d, field %q must be a scalar", i+1, key)
+                }
+            default:
+                return nil, fmt.Errorf("invalid JSON: element %d, field %q must be a scalar", i+1, key)
+            }
+        }
+        records = append(records, rec)
+    }
+    return records, nil
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "skyflow/internal/database"
+    "skyflow/internal/models"
+    "skyflow/reference"
+    "github.com/go-chi/chi/v5"
+)
+
+// Маршруты рейсов поверх хранилища в памяти и встроенных справочников.
+// Права не проверяются: запросы идут от имени администратора
+func newTestFlightRouter(t *testing.T) (http.Handler, database.FlightStore) {
+    t.Helper()
+    
+    ctx := context.Background()
+    dataset, err := reference.Load()
+    if err != nil {
+        t.Fatal(err)
+    }
+    referenceStore := database.NewMemoryReferenceStore()
+    if err := referenceStore.Seed(ctx, dataset); err != nil {
+        t.Fatal(err)
+    }
+    cache := database.NewReferenceCache(referenceStore)
+    if err := cache.Reload(ctx); err != nil {
+        t.Fatal(err)
+    }
+    
+    store := database.NewEnrichedFlightStore(database.NewMemoryFlightStore(), cache)
+    h := NewFlightHandler(store, nil, nil, cache, nil, "SVO", 0)
+    
+    admin := &models.User{ID: "1", Username: "admin", Role: "admin"}
+    r := chi.NewRouter()
+    r.Use(func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", admin)))
+        })
+    })
+    r.Route("/api/flights", func(r chi.Router) {
+        r.Get("/", h.GetAllFlights)
+        r.Get("/number/{number}", h.GetFlightByNumber)
+        r.Get("/{id}", h.GetFlight)
+        r.Post("/", h.CreateFlight)
+        r.Put("/{id}", h.UpdateFlight)
+        r.Delete("/{id}", h.DeleteFlight)
+    })
+    return r, store
+}
+
+func doRequest(router http.Handler, method, path, body string) *httptest.ResponseRecorder {
+    req := httptest.NewRequest(method, path, strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    rec := httptest.NewRecorder()
+    router.ServeHTTP(rec, req)
+    return rec
+}
+
+// Создает рейс через API и возвращает его
+func createTestFlight(t *testing.T, router http.Handler, body string) models.Flight {
+    t.Helper()
+    
+    rec := doRequest(router, http.MethodPost, "/api/flights", body)
+    if rec.Code != http.StatusCreated {
+        t.Fatalf("create: status %d: %s", rec.Code, rec.Body)
+    }
+    var flight models.Flight
+    if err := json.NewDecoder(rec.Body).Decode(&flight); err != nil {
+        t.Fatal(err)
+    }
+    return flight
+}
+
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+    t.Helper()
+    
+    var resp errorResponse
+    if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+        return ""
+    }
+    return resp.Error
+}
+
+const testFlightBody = `{"flightNumber": "su100", "airline": "AFL", "from": "UUEE", "to": "LED",
+    "scheduled": "2026-11-02T12:00", "scheduledArrival": "2026-11-02T13:30", "terminal": "B", "gate": "12"}`
+
+func TestCreateFlight(t *testing.T) {
+    tests := []struct {
+        name   string
+        body   string
+        status int
+        error  string
+    }{
+        {name: "valid", body: testFlightBody, status: http.StatusCreated},
+        {name: "malformed body", body: `{"flightNumber":`, status: http.StatusBadRequest},
+        {
+            name:   "unknown airline",
+            body:   `{"flightNumber": "XX 1", "airline": "NOPE", "from": "SVO", "to": "LED", "scheduled": "2026-11-02T12:00"}`,
+            status: http.StatusBadRequest, error: "unknown_airline",
+        },
+        {
+            name:   "unknown airport",
+            body:   `{"flightNumber": "SU 1", "airline": "SU", "from": "SVO", "to": "QQQ", "scheduled": "2026-11-02T12:00"}`,
+            status: http.StatusBadRequest, error: "unknown_airport",
+        },
+        {
+            name:   "same airport",
+            body:   `{"flightNumber": "SU 1", "airline": "SU", "from": "SVO", "to": "UUEE", "scheduled": "2026-11-02T12:00"}`,
+            status: http.StatusBadRequest, error: "same_airport",
+        },
+        {
+            name:   "invalid time",
+            body:   `{"flightNumber": "SU 1", "airline": "SU", "from": "SVO", "to": "LED", "scheduled": "tomorrow"}`,
+            status: http.StatusBadRequest, error: "invalid_time",
+        },
+        {
+            name: "arrival before departure",
+            body: `{"flightNumber": "SU 1", "airline": "SU", "from": "SVO", "to": "LED",
+                "scheduled": "2026-11-02T12:00", "scheduledArrival": "2026-11-02T11:00"}`,
+            status: http.StatusBadRequest, error: "invalid_arrival_time",
+        },
+        {
+            name:   "unknown aircraft",
+            body:   `{"flightNumber": "SU 1", "airline": "SU", "from": "SVO", "to": "LED", "scheduled": "2026-11-02T12:00", "aircraft": "ZZZZ"}`,
+            status: http.StatusBadRequest, error: "unknown_aircraft",
+        },
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            router, _ := newTestFlightRouter(t)
+            rec := doRequest(router, http.MethodPost, "/api/flights", tt.body)
+            if rec.Code != tt.status {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+            }
+            if tt.error != "" {
+                if got := errorCode(t, rec); got != tt.error {
+                    t.Errorf("error = %q, want %q", got, tt.error)
+                }
+            }
+        })
+    }
+}
+
+func TestCreateFlightNormalizesCodes(t *testing.T) {
+    router, _ := newTestFlightRouter(t)
+    flight := createTestFlight(t, router, testFlightBody)
+    
+    if flight.ID == "" {
+        t.Error("ID is empty")
+    }
+    if flight.FlightNumber != "SU 100" || flight.Airline != "SU" || flight.From != "SVO" || flight.To != "LED" {
+        t.Errorf("flight = %s %s %s-%s, want SU 100 SU SVO-LED", flight.FlightNumber, flight.Airline, flight.From, flight.To)
+    }
+    if flight.Status != string(models.StatusScheduled) {
+        t.Errorf("status = %s, want scheduled", flight.Status)
+    }
+    // Время без смещения — местное время аэропорта вылета (Москва, UTC+3)
+    if got := flight.Scheduled.UTC().Format("15:04"); got != "09:00" {
+        t.Errorf("scheduled = %s UTC, want 09:00", got)
+    }
+}
+
+func TestGetFlight(t *testing.T) {
+    router, _ := newTestFlightRouter(t)
+    flight := createTestFlight(t, router, testFlightBody)
+    
+    tests := []struct {
+        name   string
+        path   string
+        status int
+    }{
+        {name: "by id", path: "/api/flights/" + flight.ID, status: http.StatusOK},
+        {name: "unknown id", path: "/api/flights/missing", status: http.StatusNotFound},
+        {name: "by number", path: "/api/flights/number/SU%20100", status: http.StatusOK},
+        {name: "by number without space", path: "/api/flights/number/su100", status: http.StatusOK},
+        {name: "unknown number", path: "/api/flights/number/SU999", status: http.StatusNotFound},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rec := doRequest(router, http.MethodGet, tt.path, "")
+            if rec.Code != tt.status {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+            }
+            if tt.status != http.StatusOK {
+                return
+            }
+            var got models.Flight
+            if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+                t.Fatal(err)
+            }
+            if got.ID != flight.ID {
+                t.Errorf("ID = %s, want %s", got.ID, flight.ID)
+            }
+        })
+    }
+}
+
+func TestGetAllFlights(t *testing.T) {
+    router, _ := newTestFlightRouter(t)
+    for _, number := range []string{"SU 100", "SU 101", "SU 102"} {
+        createTestFlight(t, router, strings.Replace(testFlightBody, "su100", number, 1))
+    }
+    
+    tests := []struct {
+        name   string
+        query  string
+        count  int
+        next   bool
+        status int
+    }{
+        {name: "whole list without limit", query: "", count: 3, status: http.StatusOK},
+        {name: "first page", query: "?limit=2", count: 2, next: true, status: http.StatusOK},
+        {name: "search", query: "?q=su101", count: 1, status: http.StatusOK},
+        {name: "invalid limit", query: "?limit=0", status: http.StatusBadRequest},
+        {name: "invalid cursor", query: "?cursor=garbage", status: http.StatusBadRequest},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            rec := doRequest(router, http.MethodGet, "/api/flights"+tt.query, "")
+            if rec.Code != tt.status {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+            }
+            if tt.status != http.StatusOK {
+                return
+            }
+            var flights []models.Flight
+            if err := json.NewDecoder(rec.Body).Decode(&flights); err != nil {
+                t.Fatal(err)
+            }
+            if len(flights) != tt.count {
+                t.Errorf("got %d flights, want %d", len(flights), tt.count)
+            }
+            if next := rec.Header().Get("X-Next-Cursor") != ""; next != tt.next {
+                t.Errorf("X-Next-Cursor set = %v, want %v", next, tt.next)
+            }
+        })
+    }
+}
+
+func TestUpdateFlight(t *testing.T) {
+    tests := []struct {
+        name   string
+        body   string
+        status int
+        error  string
+        check  func(t *testing.T, f models.Flight)
+    }{
+        {
+            name: "gate and terminal", body: `{"gate": "14", "terminal": "C"}`, status: http.StatusOK,
+            check: func(t *testing.T, f models.Flight) {
+                if f.Gate != "14" || f.Terminal != "C" {
+                    t.Errorf("gate %s terminal %s, want 14 C", f.Gate, f.Terminal)
+                }
+            },
+        },
+        {
+            name: "allowed transition", body: `{"status": "boarding"}`, status: http.StatusOK,
+            check: func(t *testing.T, f models.Flight) {
+                if f.Status != string(models.StatusBoarding) || f.StatusSource != models.StatusSourceManual {
+                    t.Errorf("status %s source %s, want boarding manual", f.Status, f.StatusSource)
+                }
+            },
+        },
+        {name: "forbidden transition", body: `{"status": "arrived"}`, status: http.StatusConflict, error: "invalid_status_transition"},
+        {name: "unknown status", body: `{"status": "flying"}`, status: http.StatusBadRequest, error: "unknown_status"},
+        {name: "unknown aircraft", body: `{"aircraft": "ZZZZ"}`, status: http.StatusBadRequest, error: "unknown_aircraft"},
+        {name: "arrival before departure", body: `{"scheduledArrival": "2026-11-02T10:00"}`, status: http.StatusBadRequest, error: "invalid_arrival_time"},
+        {name: "malformed body", body: `{"gate":`, status: http.StatusBadRequest},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            router, store := newTestFlightRouter(t)
+            flight := createTestFlight(t, router, testFlightBody)
+            
+            rec := doRequest(router, http.MethodPut, "/api/flights/"+flight.ID, tt.body)
+            if rec.Code != tt.status {
+                t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+            }
+            if tt.error != "" {
+                if got := errorCode(t, rec); got != tt.error {
+                    t.Errorf("error = %q, want %q", got, tt.error)
+                }
+            }
+            
+            stored, err := store.GetByID(context.Background(), flight.ID)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if tt.check != nil {
+                tt.check(t, *stored)
+            } else if stored.Status != flight.Status || stored.Gate != flight.Gate || stored.Aircraft != flight.Aircraft {
+                t.Error("rejected update changed the stored flight")
+            }
+        })
+    }
+    
+    t.Run("unknown flight", func(t *testing.T) {
+        router, _ := newTestFlightRouter(t)
+        rec := doRequest(router, http.MethodPut, "/api/flights/missing", `{"gate": "1"}`)
+        if rec.Code != http.StatusNotFound {
+            t.Errorf("status = %d, want 404", rec.Code)
+        }
+    })
+}
+
+func TestDeleteFlight(t *testing.T) {
+    router, _ := newTestFlightRouter(t)
+    flight := createTestFlight(t, router, testFlightBody)
+    
+    steps := []struct {
+        method string
+        path   string
+        status int
+    }{
+        {http.MethodDelete, "/api/flights/" + flight.ID, http.StatusNoContent},
+        {http.MethodGet, "/api/flights/" + flight.ID, http.StatusNotFound},
+        {http.MethodDelete, "/api/flights/" + flight.ID, http.StatusNotFound},
+        {http.MethodGet, "/api/flights/number/SU100", http.StatusNotFound},
+    }
+    
+    for _, step := range steps {
+        if rec := doRequest(router, step.method, step.path, ""); rec.Code != step.status {
+            t.Errorf("%s %s: status = %d, want %d", step.method, step.path, rec.Code, step.status)
+        }
+    }
+}
@@ -0,0 +1,21 @@
+package handlers
+
+import (
+    "net/http"
+    "skyflow/internal/jwtkeys"
+)
+
+type JWKSHandler struct {
+    keys *jwtkeys.KeySet
+}
+
+func NewJWKSHandler(keys *jwtkeys.KeySet) *JWKSHandler {
+    return &JWKSHandler{keys: keys}
+}
+
+// Открытые ключи подписи токенов для других сервисов
+func (h *JWKSHandler) Serve(w http.ResponseWriter, r *http.Request) {
+    // Новый ключ публикуется заранее (задержка активации), короткий кэш его не скроет
+    w.Header().Set("Cache-Control", "public, max-age=60")
+    jsonResponse(w, h.keys.JWKS(), http.StatusOK)
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "log"
+    "net/http"
+    "skyflow/internal/database"
+    "skyflow/internal/models"
+    "github.com/go-chi/chi/v5"
+)
+
+// CRUD справочников аэропортов, авиакомпаний и типов ВС
+type ReferenceHandler struct {
+    cache *database.ReferenceCache
+}
+
+func NewReferenceHandler(cache *database.ReferenceCache) *ReferenceHandler {
+    return &ReferenceHandler{cache: cache}
+}
+
+// Коды задержки IATA AHM 730 (справочник встроенный, не редактируется)
+func (h *ReferenceHandler) ListDelayCodes(w http.ResponseWriter, r *http.Request) {
+    jsonResponse(w, models.DelayCodes(), http.StatusOK)
+}
+
+// Аэропорты
+func (h *ReferenceHandler) ListAirports(w http.ResponseWriter, r *http.Request) {
+    airports, err := h.cache.Store().ListAirports(r.Context())
+    h.respondList(w, airports, err)
+}
+
+func (h *ReferenceHandler) GetAirport(w http.ResponseWriter, r *http.Request) {
+    airport, ok := h.cache.Data().Airport(chi.URLParam(r, "code"))
+    h.respondEntry(w, airport, ok)
+}
+
+func (h *ReferenceHandler) CreateAirport(w http.ResponseWriter, r *http.Request) {
+    var airport models.Airport
+    if !decodeEntry(w, r, &airport) {
+        return
+    }
+    h.respondWrite(w, r, &airport, airport.Normalize(), func() error {
+        return h.cache.Store().CreateAirport(r.Context(), &airport)
+    }, http.StatusCreated)
+}
+
+func (h *ReferenceHandler) UpdateAirport(w http.ResponseWriter, r *http.Request) {
+    var airport models.Airport
+    if !decodeEntry(w, r, &airport) {
+        return
+    }
+    airport.IATACode = chi.URLParam(r, "code")
+    h.respondWrite(w, r, &airport, airport.Normalize(), func() error {
+        return h.cache.Store().UpdateAirport(r.Context(), &airport)
+    }, http.StatusOK)
+}
+
+func (h *ReferenceHandler) DeleteAirport(w http.ResponseWriter, r *http.Request) {
+    h.respondDelete(w, r, h.cache.Store().DeleteAirport(r.Context(), models.NormalizeCode(chi.URLParam(r, "code"))))
+}
+
+// Авиакомпании
+func (h *ReferenceHandler) ListAirlines(w http.ResponseWriter, r *http.Request) {
+    airlines, err := h.cache.Store().ListAirlines(r.Context())
+    h.respondList(w, airlines, err)
+}
+
+func (h *ReferenceHandler) GetAirline(w http.ResponseWriter, r *http.Request) {
+    airline, ok := h.cache.Data().Airline(chi.URLParam(r, "code"))
+    h.respondEntry(w, airline, ok)
+}
+
+func (h *ReferenceHandler) CreateAirline(w http.ResponseWriter, r *http.Request) {
+    var airline models.Airline
+    if !decodeEntry(w, r, &airline) {
+        return
+    }
+    h.respondWrite(w, r, &airline, airline.Normalize(), func() error {
+        return h.cache.Store().CreateAirline(r.Context(), &airline)
+    }, http.StatusCreated)
+}
+
+func (h *ReferenceHandler) UpdateAirline(w http.ResponseWriter, r *http.Request) {
+    var airline models.Airline
+    if !decodeEntry(w, r, &airline) {
+        return
+    }
+    airline.IATACode = chi.URLParam(r, "code")
+    h.respondWrite(w, r, &airline, airline.Normalize(), func() error {
+        return h.cache.Store().UpdateAirline(r.Context(), &airline)
+    }, http.StatusOK)
+}
+
+func (h *ReferenceHandler) DeleteAirline(w http.ResponseWriter, r *http.Request) {
+    h.respondDelete(w, r, h.cache.Store().DeleteAirline(r.Context(), models.NormalizeCode(chi.URLParam(r, "code"))))
+}
+
+// Типы воздушных судов
+func (h *ReferenceHandler) ListAircraftTypes(w http.ResponseWriter, r *http.Request) {
+    types, err := h.cache.Store().ListAircraftTypes(r.Context())
+    h.respondList(w, types, err)
+}
+
+func (h *ReferenceHandler) GetAircraftType(w http.ResponseWriter, r *http.Request) {
+    aircraft, ok := h.cache.Data().Aircraft(chi.URLParam(r, "code"))
+    h.respondEntry(w, aircraft, ok)
+}
+
+func (h *ReferenceHandler) CreateAircraftType(w http.ResponseWriter, r *http.Request) {
+    var aircraft models.AircraftType
+    if !decodeEntry(w, r, &aircraft) {
+        return
+    }
+    h.respondWrite(w, r, &aircraft, aircraft.Normalize(), func() error {
+        return h.cache.Store().CreateAircraftType(r.Context(), &aircraft)
+    }, http.StatusCreated)
+}
+
+func (h *ReferenceHandler) UpdateAircraftType(w http.ResponseWriter, r *http.Request) {
+    var aircraft models.AircraftType
+    if !decodeEntry(w, r, &aircraft) {
+        return
+    }
+    aircraft.ICAOCode = chi.URLParam(r, "code")
+    h.respondWrite(w, r, &aircraft, aircraft.Normalize(), func() error {
+        return h.cache.Store().UpdateAircraftType(r.Context(), &aircraft)
+    }, http.StatusOK)
+}
+
+func (h *ReferenceHandler) DeleteAircraftType(w http.ResponseWriter, r *http.Request) {
+    h.respondDelete(w, r, h.cache.Store().DeleteAircraftType(r.Context(), models.NormalizeCode(chi.URLParam(r, "code"))))
+}
+
+func decodeEntry(w http.ResponseWriter, r *http.Request, entry interface{}) bool {
+    if err := json.NewDecoder(r.Body).Decode(entry); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return false
+    }
+    return true
+}
+
+func (h *ReferenceHandler) respondList(w http.ResponseWriter, list interface{}, err error) {
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    jsonResponse(w, list, http.StatusOK)
+}
+
+func (h *ReferenceHandler) respondEntry(w http.ResponseWriter, entry interface{}, ok bool) {
+    if !ok {
+        http.Error(w, "Not found", http.StatusNotFound)
+        return
+    }
+    jsonResponse(w, entry, http.StatusOK)
+}
+
+// Проверка записи, сохранение и обновление кэша справочников
+func (h *ReferenceHandler) respondWrite(w http.ResponseWriter, r *http.Request, entry interface{}, validationErr error, save func() error, status int) {
+    var invalid *models.ReferenceValidationError
+    if errors.As(validationErr, &invalid) {
+        jsonResponse(w, errorResponse{
+            Error:   "invalid_reference",
+            Message: invalid.Error(),
+            Details: invalid,
+        }, http.StatusBadRequest)
+        return
+    }
+    
+    err := save()
+    if errors.Is(err, database.ErrReferenceExists) {
+        http.Error(w, "Entry with this code already exists", http.StatusConflict)
+        return
+    }
+    if errors.Is(err, database.ErrReferenceNotFound) {
+        http.Error(w, "Not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    h.reload(r)
+    jsonResponse(w, entry, status)
+}
+
+func (h *ReferenceHandler) respondDelete(w http.ResponseWriter, r *http.Request, err error) {
+    if errors.Is(err, database.ErrReferenceNotFound) {
+        http.Error(w, "Not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    h.reload(r)
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ReferenceHandler) reload(r *http.Request) {
+    if err := h.cache.Reload(r.Context()); err != nil {
+        log.Printf("Failed to reload reference data: %v", err)
+    }
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "log"
+    "net/http"
+    "time"
+    "skyflow/internal/database"
+    "skyflow/internal/models"
+    "skyflow/internal/ssim"
+    "github.com/go-chi/chi/v5"
+)
+
+// Сезонные расписания и рейсы, созданные по ним
+type ScheduleHandler struct {
+    schedules *database.ScheduleRepository
+    generator *database.ScheduleGenerator
+    reference *database.ReferenceCache
+    importer  *ssim.Importer
+}
+
+func NewScheduleHandler(schedules *database.ScheduleRepository, generator *database.ScheduleGenerator, reference *database.ReferenceCache) *ScheduleHandler {
+    return &ScheduleHandler{
+        schedules: schedules,
+        generator: generator,
+        reference: reference,
+        importer:  ssim.NewImporter(schedules, generator, reference),
+    }
+}
+
+// Максимальный размер файла SSIM (сезон крупной авиакомпании — несколько МБ)
+const maxSSIMSize = 32 << 20
+
+// Импорт расписаний из файла SSIM (тело запроса).
+// ?mode=schedules|flights, ?dryRun=true — только отчет без изменений
+func (h *ScheduleHandler) ImportSSIM(w http.ResponseWriter, r *http.Request) {
+    opts := ssim.Options{
+        Mode:   r.URL.Query().Get("mode"),
+        DryRun: r.URL.Query().Get("dryRun") == "true",
+    }
+    if opts.Mode != "" && opts.Mode != ssim.ModeSchedules && opts.Mode != ssim.ModeFlights {
+        jsonResponse(w, errorResponse{
+            Error:   "unknown_import_mode",
+            Message: "Unknown import mode: " + opts.Mode,
+            Details: map[string]interface{}{"allowed": []string{ssim.ModeSchedules, ssim.ModeFlights}},
+        }, http.StatusBadRequest)
+        return
+    }
+    if user, ok := r.Context().Value("user").(*models.User); ok && user.APIKey != nil {
+        opts.Airline = user.APIKey.Airline
+    }
+    opts.RemoveFlights = canDeleteFlights(r)
+    opts.Author = flightAuthor(r)
+    
+    report, err := h.importer.Import(r.Context(), http.MaxBytesReader(w, r.Body, maxSSIMSize), opts)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, report, http.StatusOK)
+}
+
+// Список расписаний
+func (h *ScheduleHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+    schedules, err := h.schedules.List(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, schedules, http.StatusOK)
+}
+
+// Расписание по ID
+func (h *ScheduleHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+    schedule, ok := h.load(w, r)
+    if !ok {
+        return
+    }
+    
+    jsonResponse(w, schedule, http.StatusOK)
+}
+
+// Создать расписание и сразу сгенерировать рейсы на ближайшие дни
+func (h *ScheduleHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+    var schedule models.FlightSchedule
+    if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    if !h.validate(w, r, &schedule) {
+        return
+    }
+    
+    if err := h.schedules.Create(r.Context(), &schedule); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    h.generate(r, &schedule)
+    jsonResponse(w, schedule, http.StatusCreated)
+}
+
+// Изменить расписание. Будущие рейсы, которые не правились вручную,
+// приводятся к новому расписанию
+func (h *ScheduleHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+    existing, ok := h.load(w, r)
+    if !ok {
+        return
+    }
+    if errResp := checkAirlineScope(r, existing.Airline); errResp != nil {
+        jsonResponse(w, errResp, http.StatusForbidden)
+        return
+    }
+    
+    var schedule models.FlightSchedule
+    if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    schedule.ID = existing.ID
+    schedule.SourceKey = existing.SourceKey
+    schedule.CreatedAt = existing.CreatedAt
+    
+    if !h.validate(w, r, &schedule) {
+        return
+    }
+    
+    err := h.schedules.Update(r.Context(), &schedule)
+    if errors.Is(err, database.ErrScheduleNotFound) {
+        http.Error(w, "Schedule not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    h.generate(r, &schedule)
+    jsonResponse(w, schedule, http.StatusOK)
+}
+
+// Удалить расписание вместе с будущими рейсами, которые не правились вручную
+func (h *ScheduleHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+    schedule, ok := h.load(w, r)
+    if !ok {
+        return
+    }
+    if errResp := checkAirlineScope(r, schedule.Airline); errResp != nil {
+        jsonResponse(w, errResp, http.StatusForbidden)
+        return
+    }
+    
+    if _, err := h.generator.Retire(r.Context(), schedule, flightAuthor(r)); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    err := h.schedules.Delete(r.Context(), schedule.ID)
+    if errors.Is(err, database.ErrScheduleNotFound) {
+        http.Error(w, "Schedule not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// Рейсы расписания начиная с сегодняшнего дня (или с ?from=2006-01-02, до ?to=)
+func (h *ScheduleHandler) ListScheduleFlights(w http.ResponseWriter, r *http.Request) {
+    schedule, ok := h.load(w, r)
+    if !ok {
+        return
+    }
+    
+    loc, _ := h.reference.Data().Location(schedule.From)
+    from := time.Now().In(loc)
+    if value := r.URL.Query().Get("from"); value != "" {
+        date, err := time.Parse(models.DateLayout, value)
+        if err != nil {
+            http.Error(w, "Invalid from date, expected 2006-01-02", http.StatusBadRequest)
+            return
+        }
+        from = date
+    }
+    var to time.Time
+    if value := r.URL.Query().Get("to"); value != "" {
+        date, err := time.Parse(models.DateLayout, value)
+        if err != nil {
+            http.Error(w, "Invalid to date, expected 2006-01-02", http.StatusBadRequest)
+            return
+        }
+        to = date
+    }
+    
+    flights, err := h.schedules.ListInstances(r.Context(), schedule.ID, from, to)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    data := h.reference.Data()
+    for i := range flights {
+        flights[i] = data.Enrich(flights[i])
+    }
+    jsonResponse(w, flights, http.StatusOK)
+}
+
+// Запустить генерацию рейсов расписания вручную
+func (h *ScheduleHandler) GenerateSchedule(w http.ResponseWriter, r *http.Request) {
+    schedule, ok := h.load(w, r)
+    if !ok {
+        return
+    }
+    if errResp := checkAirlineScope(r, schedule.Airline); errResp != nil {
+        jsonResponse(w, errResp, http.StatusForbidden)
+        return
+    }
+    
+    result, err := h.generator.Generate(r.Context(), schedule, canDeleteFlights(r), flightAuthor(r))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, result, http.StatusOK)
+}
+
+// Отменить рейс расписания на одну дату, не затрагивая остальные
+func (h *ScheduleHandler) CancelScheduleDate(w http.ResponseWriter, r *http.Request) {
+    schedule, ok := h.load(w, r)
+    if !ok {
+        return
+    }
+    if errResp := checkAirlineScope(r, schedule.Airline); errResp != nil {
+        jsonResponse(w, errResp, http.StatusForbidden)
+        return
+    }
+    
+    serviceDate := chi.URLParam(r, "date")
+    date, err := time.Parse(models.DateLayout, serviceDate)
+    if err != nil {
+        http.Error(w, "Invalid date, expected 2006-01-02", http.StatusBadRequest)
+        return
+    }
+    if len(schedule.Dates(date, date)) == 0 {
+        jsonResponse(w, errorResponse{
+            Error:   "date_not_in_schedule",
+            Message: "Schedule does not operate on " + serviceDate,
+            Details: map[string]interface{}{
+                "validFrom":  schedule.ValidFrom,
+                "validTo":    schedule.ValidTo,
+                "daysOfWeek": schedule.DaysOfWeek.String(),
+            },
+        }, http.StatusBadRequest)
+        return
+    }
+    
+    flight, err := h.generator.Cancel(r.Context(), schedule, serviceDate, flightAuthor(r))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if flight == nil {
+        // Рейс еще не создан — он просто не появится
+        w.WriteHeader(http.StatusNoContent)
+        return
+    }
+    
+    jsonResponse(w, h.reference.Enrich(*flight), http.StatusOK)
+}
+
+func (h *ScheduleHandler) load(w http.ResponseWriter, r *http.Request) (*models.FlightSchedule, bool) {
+    schedule, err := h.schedules.GetByID(r.Context(), chi.URLParam(r, "id"))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return nil, false
+    }
+    if schedule == nil {
+        http.Error(w, "Schedule not found", http.StatusNotFound)
+        return nil, false
+    }
+    return schedule, true
+}
+
+// Проверяет расписание и приводит коды к справочным
+func (h *ScheduleHandler) validate(w http.ResponseWriter, r *http.Request, schedule *models.FlightSchedule) bool {
+    if err := schedule.Normalize(); err != nil {
+        jsonResponse(w, errorResponse{
+            Error:   "invalid_schedule",
+            Message: err.Error(),
+            Details: err,
+        }, http.StatusBadRequest)
+        return false
+    }
+    
+    data := h.reference.Data()
+    codes := models.Flight{Airline: schedule.Airline, From: schedule.From, To: schedule.To, Aircraft: schedule.Aircraft}
+    if errResp := resolveFlightCodes(data, &codes); errResp != nil {
+        jsonResponse(w, errResp, http.StatusBadRequest)
+        return false
+    }
+    schedule.Airline, schedule.From, schedule.To, schedule.Aircraft = codes.Airline, codes.From, codes.To, codes.Aircraft
+    
+    if errResp := checkAirlineScope(r, schedule.Airline); errResp != nil {
+        jsonResponse(w, errResp, http.StatusForbidden)
+        return false
+    }
+    
+    // Прилет должен быть позже вылета с учетом часовых поясов
+    if schedule.ArrivalTime != "" {
+        fromZone, _ := data.Location(schedule.From)
+        toZone, _ := data.Location(schedule.To)
+        date, _ := time.Parse(models.DateLayout, schedule.ValidFrom)
+        flight, err := schedule.Instance(date, fromZone, toZone)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return false
+        }
+        if errResp := checkArrivalTime(flight); errResp != nil {
+            jsonResponse(w, errResp, http.StatusBadRequest)
+            return false
+        }
+    }
+    
+    return true
+}
+
+// Генерация после изменения расписания. Ошибка не отменяет сохранение:
+// фоновый генератор повторит попытку
+func (h *ScheduleHandler) generate(r *http.Request, schedule *models.FlightSchedule) {
+    if _, err := h.generator.Generate(r.Context(), schedule, canDeleteFlights(r), flightAuthor(r)); err != nil {
+        log.Printf("Failed to generate flights for schedule %s: %v", schedule.ID, err)
+    }
+}
+
+// Лишние рейсы расписания убираются только за того, кому можно удалять рейсы
+func canDeleteFlights(r *http.Request) bool {
+    user, ok := r.Context().Value("user").(*models.User)
+    return ok && user.Can(models.PermFlightsDelete)
+}
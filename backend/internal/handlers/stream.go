@@ -0,0 +1,102 @@
+package handlers
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "time"
+    "skyflow/internal/events"
+)
+
+const sseHeartbeatInterval = 15 * time.Second
+
+type StreamHandler struct {
+    broker *events.Broker
+}
+
+func NewStreamHandler(broker *events.Broker) *StreamHandler {
+    return &StreamHandler{broker: broker}
+}
+
+// Поток изменений табло (Server-Sent Events)
+func (h *StreamHandler) FlightStream(w http.ResponseWriter, r *http.Request) {
+    flusher, ok := w.(http.Flusher)
+    if !ok {
+        http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+        return
+    }
+    
+    // EventSource присылает Last-Event-ID при переподключении,
+    // для первого подключения можно передать ?lastEventId=
+    lastEventID := r.Header.Get("Last-Event-ID")
+    if lastEventID == "" {
+        lastEventID = r.URL.Query().Get("lastEventId")
+    }
+    var (
+        epoch  string
+        lastID uint64
+    )
+    if lastEventID != "" {
+        var err error
+        epoch, lastID, err = events.ParseStreamID(lastEventID)
+        if err != nil {
+            http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+            return
+        }
+    }
+    
+    sub, missed, complete := h.broker.Subscribe(epoch, lastID)
+    defer sub.Close()
+    
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Header().Set("Connection", "keep-alive")
+    w.Header().Set("X-Accel-Buffering", "no") // nginx не должен буферизовать поток
+    w.WriteHeader(http.StatusOK)
+    
+    fmt.Fprintf(w, "retry: 3000\n\n")
+    
+    // Журнал не покрывает пропуск — клиент должен перечитать /api/flights
+    if !complete {
+        fmt.Fprintf(w, "event: reset\ndata: {}\n\n")
+    }
+    for _, event := range missed {
+        if err := writeSSEEvent(w, event); err != nil {
+            return
+        }
+    }
+    flusher.Flush()
+    
+    heartbeat := time.NewTicker(sseHeartbeatInterval)
+    defer heartbeat.Stop()
+    
+    for {
+        select {
+        case <-r.Context().Done():
+            return
+        case event, ok := <-sub.C:
+            if !ok {
+                // Отключены брокером как медленный клиент
+                return
+            }
+            if err := writeSSEEvent(w, event); err != nil {
+                return
+            }
+            flusher.Flush()
+        case <-heartbeat.C:
+            if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
+                return
+            }
+            flusher.Flush()
+        }
+    }
+}
+
+func writeSSEEvent(w http.ResponseWriter, event events.Event) error {
+    data, err := json.Marshal(event)
+    if err != nil {
+        return err
+    }
+    _, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.StreamID(), event.Type, data)
+    return err
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "log"
+    "net/http"
+    "time"
+    "skyflow/internal/database"
+    "skyflow/internal/models"
+    "skyflow/internal/totp"
+    "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+    // Имя сервиса в приложении-аутентификаторе
+    totpIssuer = "SkyFlow"
+    // Допуск на расхождение часов: ±1 шаг (30 секунд)
+    totpSkew = 1
+    // Сколько действует токен между вводом пароля и кода
+    challengeTTL  = 5 * time.Minute
+    challengeType = "2fa_challenge"
+)
+
+// Промежуточный токен после проверки пароля. Не принимается JWTAuth
+func (h *AuthHandler) respondWithChallenge(w http.ResponseWriter, user *models.User) {
+    jti, err := database.RandomToken(16)
+    if err != nil {
+        http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+        return
+    }
+    
+    challenge, err := h.keys.Sign(jwt.MapClaims{
+        "typ":     challengeType,
+        "jti":     jti,
+        "user_id": user.ID,
+        "exp":     time.Now().Add(challengeTTL).Unix(),
+    })
+    if err != nil {
+        http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, models.TwoFactorChallenge{
+        TwoFactorRequired: true,
+        ChallengeToken:    challenge,
+        ExpiresIn:         int64(challengeTTL / time.Second),
+    }, http.StatusOK)
+}
+
+// ID пользователя из промежуточного токена
+func (h *AuthHandler) parseChallenge(challenge string) (string, error) {
+    claims, err := h.keys.Parse(challenge)
+    if err != nil || claims["typ"] != challengeType {
+        return "", errors.New("invalid challenge token")
+    }
+    
+    userID, _ := claims["user_id"].(string)
+    if userID == "" {
+        return "", errors.New("invalid challenge token")
+    }
+    return userID, nil
+}
+
+// Второй шаг логина: промежуточный токен + код TOTP или код восстановления
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+    var req models.TwoFactorLoginRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    userID, err := h.parseChallenge(req.ChallengeToken)
+    if err != nil {
+        http.Error(w, "Invalid or expired challenge token", http.StatusUnauthorized)
+        return
+    }
+    
+    user, err := h.userRepo.FindByID(r.Context(), userID)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if user == nil || user.Disabled {
+        http.Error(w, "Account is disabled", http.StatusForbidden)
+        return
+    }
+    
+    ip := clientIP(r)
+    
+    // Коды перебираются так же, как пароли — те же лимиты
+    wait, err := h.throttle.RetryAfter(r.Context(), user.Username, ip)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if wait > 0 {
+        tooManyAttempts(w, wait)
+        return
+    }
+    
+    ok, err := h.checkSecondFactor(r, user, req.Code, req.RecoveryCode)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if !ok {
+        wait, err := h.throttle.RecordFailure(r.Context(), user.Username, ip)
+        if err != nil {
+            log.Printf("Failed to record login failure: %v", err)
+        }
+        if wait > 0 {
+            tooManyAttempts(w, wait)
+            return
+        }
+        http.Error(w, "Invalid authentication code", http.StatusUnauthorized)
+        return
+    }
+    
+    if err := h.throttle.RecordSuccess(r.Context(), user.Username); err != nil {
+        log.Printf("Failed to reset login failures: %v", err)
+    }
+    
+    h.respondWithToken(w, r, user)
+}
+
+// Проверка кода TOTP (каждый принимается один раз) или кода восстановления
+func (h *AuthHandler) checkSecondFactor(r *http.Request, user *models.User, code, recoveryCode string) (bool, error) {
+    if recoveryCode != "" {
+        return h.userRepo.UseRecoveryCode(r.Context(), user.ID, recoveryCode)
+    }
+    
+    secret, enabled, err := h.userRepo.GetTOTPSecret(r.Context(), user.ID)
+    if errors.Is(err, database.ErrTwoFactorNotSetUp) {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+    if !enabled {
+        return false, nil
+    }
+    
+    step, ok := totp.Verify(secret, code, time.Now(), totpSkew)
+    if !ok {
+        return false, nil
+    }
+    return h.userRepo.UseTOTPStep(r.Context(), user.ID, step)
+}
+
+// Состояние 2FA текущего пользователя
+func (h *AuthHandler) TwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+    user, ok := r.Context().Value("user").(*models.User)
+    if !ok {
+        http.Error(w, "User not found in context", http.StatusUnauthorized)
+        return
+    }
+    
+    _, enabled, err := h.userRepo.GetTOTPSecret(r.Context(), user.ID)
+    if err != nil && !errors.Is(err, database.ErrTwoFactorNotSetUp) {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    status := models.TwoFactorStatus{Enabled: enabled}
+    if enabled {
+        status.RecoveryCodesLeft, err = h.userRepo.CountRecoveryCodes(r.Context(), user.ID)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+    }
+    
+    jsonResponse(w, status, http.StatusOK)
+}
+
+// Начало настройки: новый секрет и otpauth URI для QR-кода.
+// 2FA включится только после подтверждения кодом
+func (h *AuthHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+    user, ok := r.Context().Value("user").(*models.User)
+    if !ok {
+        http.Error(w, "User not found in context", http.StatusUnauthorized)
+        return
+    }
+    
+    _, enabled, err := h.userRepo.GetTOTPSecret(r.Context(), user.ID)
+    if err != nil && !errors.Is(err, database.ErrTwoFactorNotSetUp) {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if enabled {
+        http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+        return
+    }
+    
+    secret, err := totp.GenerateSecret()
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    if err := h.userRepo.SetPendingTOTPSecret(r.Context(), user.ID, secret); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, models.TwoFactorSetupResponse{
+        Secret:     secret,
+        OTPAuthURI: totp.URI(totpIssuer, user.Username, secret),
+    }, http.StatusOK)
+}
+
+// Подтверждение настройки первым кодом. Возвращает коды восстановления
+func (h *AuthHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+    user, ok := r.Context().Value("user").(*models.User)
+    if !ok {
+        http.Error(w, "User not found in context", http.StatusUnauthorized)
+        return
+    }
+    
+    var req models.TwoFactorCodeRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    secret, enabled, err := h.userRepo.GetTOTPSecret(r.Context(), user.ID)
+    if errors.Is(err, database.ErrTwoFactorNotSetUp) {
+        http.Error(w, "Start two-factor setup first", http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if enabled {
+        http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+        return
+    }
+    
+    step, ok := totp.Verify(secret, req.Code, time.Now(), totpSkew)
+    if !ok {
+        http.Error(w, "Invalid authentication code", http.StatusBadRequest)
+        return
+    }
+    
+    if err := h.userRepo.EnableTOTP(r.Context(), user.ID, step); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    h.issueRecoveryCodes(w, r, user.ID)
+}
+
+// Новый набор кодов восстановления (старые перестают действовать)
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+    user, ok := r.Context().Value("user").(*models.User)
+    if !ok {
+        http.Error(w, "User not found in context", http.StatusUnauthorized)
+        return
+    }
+    
+    var req models.TwoFactorCodeRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    check := func() (bool, error) {
+        return h.checkSecondFactor(r, user, req.Code, "")
+    }
+    if !h.throttledCheck(w, r, user.Username, check, http.StatusForbidden, "Invalid authentication code") {
+        return
+    }
+    
+    h.issueRecoveryCodes(w, r, user.ID)
+}
+
+// Отключение 2FA: нужен пароль и код (или код восстановления)
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+    current, ok := r.Context().Value("user").(*models.User)
+    if !ok {
+        http.Error(w, "User not found in context", http.StatusUnauthorized)
+        return
+    }
+    
+    var req models.DisableTwoFactorRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    // Пароль и код проверяются одной попыткой: если бы верный пароль
+    // сбрасывал счетчик, коды можно было бы перебирать без ограничений
+    var user *models.User
+    check := func() (bool, error) {
+        matched, ok, err := h.passwordMatches(r, current.Username, req.Password)
+        if err != nil || !ok {
+            return false, err
+        }
+        user = matched
+        return h.checkSecondFactor(r, user, req.Code, req.RecoveryCode)
+    }
+    if !h.throttledCheck(w, r, current.Username, check, http.StatusForbidden, "Password or authentication code is incorrect") {
+        return
+    }
+    
+    if err := h.userRepo.DisableTOTP(r.Context(), user.ID); err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) issueRecoveryCodes(w http.ResponseWriter, r *http.Request, userID string) {
+    codes, err := h.userRepo.ReplaceRecoveryCodes(r.Context(), userID)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, models.RecoveryCodesResponse{RecoveryCodes: codes}, http.StatusOK)
+}
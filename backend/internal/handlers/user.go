@@ -0,0 +1,191 @@
+package handlers
+
+import (
+    "encoding/json"
+    "errors"
+    "net/http"
+    "strings"
+    "skyflow/internal/database"
+    "skyflow/internal/models"
+    "github.com/go-chi/chi/v5"
+)
+
+type UserHandler struct {
+    userRepo  *database.UserRepository
+    tokenRepo *database.TokenRepository
+    denylist  *database.TokenDenylist
+}
+
+func NewUserHandler(userRepo *database.UserRepository, tokenRepo *database.TokenRepository, denylist *database.TokenDenylist) *UserHandler {
+    return &UserHandler{userRepo: userRepo, tokenRepo: tokenRepo, denylist: denylist}
+}
+
+// Список сотрудников
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+    users, err := h.userRepo.List(r.Context())
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, users, http.StatusOK)
+}
+
+// Создать сотрудника с ролью и временным паролем
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+    var req models.CreateUserRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    req.Username = strings.TrimSpace(req.Username)
+    if req.Username == "" {
+        http.Error(w, "Username is required", http.StatusBadRequest)
+        return
+    }
+    if !models.Role(req.Role).Valid() {
+        jsonResponse(w, errorResponse{
+            Error:   "unknown_role",
+            Message: "Unknown role: " + req.Role,
+            Details: map[string]interface{}{"allowed": models.AllRoles()},
+        }, http.StatusBadRequest)
+        return
+    }
+    if err := validatePassword(req.Password); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    
+    user, err := h.userRepo.Create(r.Context(), req.Username, req.Password, req.Role)
+    if errors.Is(err, database.ErrUsernameTaken) {
+        http.Error(w, "Username already taken", http.StatusConflict)
+        return
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    jsonResponse(w, user, http.StatusCreated)
+}
+
+// Сменить роль сотрудника
+func (h *UserHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+    userID := chi.URLParam(r, "id")
+    
+    var req models.UpdateRoleRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    if !models.Role(req.Role).Valid() {
+        jsonResponse(w, errorResponse{
+            Error:   "unknown_role",
+            Message: "Unknown role: " + req.Role,
+            Details: map[string]interface{}{"allowed": models.AllRoles()},
+        }, http.StatusBadRequest)
+        return
+    }
+    
+    // Админ не может сам лишить себя прав
+    current, ok := r.Context().Value("user").(*models.User)
+    self := ok && current.ID == userID
+    if self && req.Role != string(models.RoleAdmin) {
+        http.Error(w, "You cannot change your own role", http.StatusConflict)
+        return
+    }
+    
+    // Роль записана в токене доступа: прежние сессии завершаются, чтобы
+    // старые права не действовали до истечения токена. Себе роль не меняется
+    err := h.userRepo.UpdateRole(r.Context(), userID, req.Role)
+    if err == nil && !self {
+        err = h.revokeSessions(r, userID)
+    }
+    h.respondAfterUpdate(w, r, userID, err)
+}
+
+// Заблокировать учетную запись
+func (h *UserHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+    userID := chi.URLParam(r, "id")
+    
+    if current, ok := r.Context().Value("user").(*models.User); ok && current.ID == userID {
+        http.Error(w, "You cannot disable your own account", http.StatusConflict)
+        return
+    }
+    
+    err := h.userRepo.SetDisabled(r.Context(), userID, true)
+    if err == nil {
+        err = h.revokeSessions(r, userID)
+    }
+    h.respondAfterUpdate(w, r, userID, err)
+}
+
+// Разблокировать учетную запись
+func (h *UserHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+    userID := chi.URLParam(r, "id")
+    h.respondAfterUpdate(w, r, userID, h.userRepo.SetDisabled(r.Context(), userID, false))
+}
+
+// Сбросить пароль. Сотрудник должен будет сменить его при входе
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+    userID := chi.URLParam(r, "id")
+    
+    var req models.ResetPasswordRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "Invalid request body", http.StatusBadRequest)
+        return
+    }
+    
+    if err := validatePassword(req.Password); err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    
+    err := h.userRepo.SetPassword(r.Context(), userID, req.Password, true)
+    if err == nil {
+        err = h.revokeSessions(r, userID)
+    }
+    h.respondAfterUpdate(w, r, userID, err)
+}
+
+// Сброс 2FA сотрудника, потерявшего телефон и коды восстановления
+func (h *UserHandler) ResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+    userID := chi.URLParam(r, "id")
+    h.respondAfterUpdate(w, r, userID, h.userRepo.DisableTOTP(r.Context(), userID))
+}
+
+// Немедленно завершает все сессии пользователя
+func (h *UserHandler) revokeSessions(r *http.Request, userID string) error {
+    revoked, err := h.tokenRepo.RevokeUserTokens(r.Context(), userID)
+    if err != nil {
+        return err
+    }
+    h.denylist.Add(revoked...)
+    return nil
+}
+
+// Отвечает обновленным пользователем или ошибкой обновления
+func (h *UserHandler) respondAfterUpdate(w http.ResponseWriter, r *http.Request, userID string, err error) {
+    if errors.Is(err, database.ErrUserNotFound) {
+        http.Error(w, "User not found", http.StatusNotFound)
+        return
+    }
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    user, err := h.userRepo.FindByID(r.Context(), userID)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    if user == nil {
+        http.Error(w, "User not found", http.StatusNotFound)
+        return
+    }
+    
+    jsonResponse(w, user, http.StatusOK)
+}
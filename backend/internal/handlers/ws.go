@@ -0,0 +1,147 @@
+package handlers
+
+import (
+    "encoding/json"
+    "log"
+    "net/http"
+    "strings"
+    "time"
+    "skyflow/internal/database"
+    "skyflow/internal/events"
+    "github.com/gorilla/websocket"
+)
+
+const (
+    wsWriteWait      = 10 * time.Second
+    wsPongWait       = 60 * time.Second
+    wsPingPeriod     = 50 * time.Second
+    wsMaxMessageSize = 4096
+)
+
+var upgrader = websocket.Upgrader{
+    ReadBufferSize:  1024,
+    WriteBufferSize: 1024,
+    // Табло открывается с любых устройств в сети аэропорта, как и остальной API (CORS *)
+    CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Команда клиента: {"action":"subscribe","flightNumbers":["S7 123"]}
+type wsCommand struct {
+    Action        string   `json:"action"`
+    FlightNumbers []string `json:"flightNumbers"`
+}
+
+type WSHandler struct {
+    flightRepo database.FlightStore
+    hub        *events.Hub
+}
+
+func NewWSHandler(flightRepo database.FlightStore, hub *events.Hub) *WSHandler {
+    return &WSHandler{flightRepo: flightRepo, hub: hub}
+}
+
+// WebSocket с подписками на отдельные рейсы
+func (h *WSHandler) Serve(w http.ResponseWriter, r *http.Request) {
+    conn, err := upgrader.Upgrade(w, r, nil)
+    if err != nil {
+        // Upgrade уже ответил клиенту ошибкой
+        return
+    }
+    
+    client := h.hub.NewClient()
+    go h.writePump(conn, client)
+    
+    // Подписка сразу из URL: /api/ws?flight=S7%20123&flight=SU%20456
+    for _, number := range r.URL.Query()["flight"] {
+        h.subscribe(r, client, number)
+    }
+    
+    h.readPump(r, conn, client)
+}
+
+func (h *WSHandler) readPump(r *http.Request, conn *websocket.Conn, client *events.HubClient) {
+    defer func() {
+        h.hub.Remove(client)
+        conn.Close()
+    }()
+    
+    conn.SetReadLimit(wsMaxMessageSize)
+    conn.SetReadDeadline(time.Now().Add(wsPongWait))
+    conn.SetPongHandler(func(string) error {
+        return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+    })
+    
+    for {
+        _, data, err := conn.ReadMessage()
+        if err != nil {
+            if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+                log.Printf("WebSocket read error: %v", err)
+            }
+            return
+        }
+        
+        var cmd wsCommand
+        if err := json.Unmarshal(data, &cmd); err != nil {
+            h.hub.Notify(client, events.FlightMessage{Type: "error", Error: "Invalid message"})
+            continue
+        }
+        
+        switch cmd.Action {
+        case "subscribe":
+            for _, number := range cmd.FlightNumbers {
+                h.subscribe(r, client, number)
+            }
+        case "unsubscribe":
+            for _, number := range cmd.FlightNumbers {
+                h.hub.Unsubscribe(client, strings.TrimSpace(number))
+            }
+        default:
+            h.hub.Notify(client, events.FlightMessage{Type: "error", Error: "Unknown action"})
+        }
+    }
+}
+
+func (h *WSHandler) subscribe(r *http.Request, client *events.HubClient, number string) {
+    number = strings.TrimSpace(number)
+    
+    flight, err := h.flightRepo.GetByFlightNumber(r.Context(), number)
+    if err != nil {
+        h.hub.Notify(client, events.FlightMessage{Type: "error", FlightNumber: number, Error: "Failed to load flight"})
+        return
+    }
+    
+    if flight == nil {
+        h.hub.Notify(client, events.FlightMessage{Type: "error", FlightNumber: number, Error: "Flight not found"})
+        return
+    }
+    
+    h.hub.Subscribe(client, number, *flight)
+}
+
+func (h *WSHandler) writePump(conn *websocket.Conn, client *events.HubClient) {
+    ticker := time.NewTicker(wsPingPeriod)
+    defer func() {
+        ticker.Stop()
+        conn.Close()
+    }()
+    
+    for {
+        select {
+        case msg, ok := <-client.Send:
+            conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+            if !ok {
+                // Хаб отключил клиента (медленный потребитель или выход)
+                conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, ""))
+                return
+            }
+            if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+                return
+            }
+        case <-ticker.C:
+            conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+            if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+                return
+            }
+        }
+    }
+}
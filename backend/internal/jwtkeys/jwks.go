@@ -0,0 +1,55 @@
+package jwtkeys
+
+import (
+    "crypto/ed25519"
+    "crypto/rsa"
+    "encoding/base64"
+    "math/big"
+)
+
+// JSON Web Key (RFC 7517) — открытая часть ключа подписи
+type JWK struct {
+    Kty string `json:"kty"`
+    Kid string `json:"kid"`
+    Use string `json:"use"`
+    Alg string `json:"alg"`
+    // RSA
+    N string `json:"n,omitempty"`
+    E string `json:"e,omitempty"`
+    // Ed25519 (RFC 8037)
+    Crv string `json:"crv,omitempty"`
+    X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+    Keys []JWK `json:"keys"`
+}
+
+// Открытые ключи для /.well-known/jwks.json: по ним другие сервисы
+// проверяют наши токены. Включает и выведенные из ротации ключи
+func (s *KeySet) JWKS() JWKSet {
+    set := JWKSet{Keys: []JWK{}}
+    for _, key := range s.Keys() {
+        jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Alg}
+        
+        switch pub := key.Public.(type) {
+        case *rsa.PublicKey:
+            jwk.Kty = "RSA"
+            jwk.N = b64(pub.N.Bytes())
+            jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+        case ed25519.PublicKey:
+            jwk.Kty = "OKP"
+            jwk.Crv = "Ed25519"
+            jwk.X = b64(pub)
+        default:
+            continue
+        }
+        
+        set.Keys = append(set.Keys, jwk)
+    }
+    return set
+}
+
+func b64(data []byte) string {
+    return base64.RawURLEncoding.EncodeToString(data)
+}
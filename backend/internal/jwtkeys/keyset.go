@@ -0,0 +1,382 @@
+// Ключи подписи JWT: RS256/EdDSA, несколько действующих ключей по kid,
+// загрузка из каталога и ротация без отзыва уже выданных токенов
+package jwtkeys
+
+import (
+    "context"
+    "crypto"
+    "crypto/ed25519"
+    "crypto/rand"
+    "crypto/rsa"
+    "crypto/x509"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+    AlgRS256 = "RS256"
+    AlgEdDSA = "EdDSA"
+    
+    minRSABits = 2048
+    keyFileExt = ".pem"
+)
+
+var (
+    ErrNoSigningKey = errors.New("no signing key available")
+    ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// Ключ из файла <kid>.pem. Private == nil — ключ только для проверки
+// (выведен из ротации, но выданные им токены еще действуют)
+type Key struct {
+    ID        string
+    Alg       string
+    Private   crypto.Signer
+    Public    crypto.PublicKey
+    CreatedAt time.Time // время изменения файла
+}
+
+func (k *Key) method() jwt.SigningMethod {
+    if k.Alg == AlgEdDSA {
+        return jwt.SigningMethodEdDSA
+    }
+    return jwt.SigningMethodRS256
+}
+
+// Набор ключей из каталога. Подписывает самым новым закрытым ключом,
+// проверяет любым из загруженных.
+//
+// Ротация: положить в каталог новый ключ (skyflow keys generate). Он начнет
+// использоваться для подписи через activationDelay — к этому времени все
+// реплики перечитают каталог и будут принимать его подписи. Старый ключ
+// удаляется (или заменяется открытой частью) после истечения последних
+// выданных им access-токенов
+type KeySet struct {
+    dir             string
+    activationDelay time.Duration
+    
+    mu      sync.RWMutex
+    keys    map[string]*Key
+    signing *Key
+}
+
+func NewKeySet(dir string, activationDelay time.Duration) *KeySet {
+    return &KeySet{dir: dir, activationDelay: activationDelay, keys: make(map[string]*Key)}
+}
+
+// Перечитывает каталог. Если в нем нет ни одного закрытого ключа,
+// создает новый Ed25519-ключ (первый запуск)
+func (s *KeySet) Reload() error {
+    return s.load(true)
+}
+
+// Перечитывает каталог без создания ключей
+func (s *KeySet) Load() error {
+    return s.load(false)
+}
+
+// kid ключа, которым сейчас подписываются токены
+func (s *KeySet) SigningKeyID() string {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    
+    if s.signing == nil {
+        return ""
+    }
+    return s.signing.ID
+}
+
+func (s *KeySet) load(generate bool) error {
+    keys, err := loadDir(s.dir)
+    if err != nil {
+        return err
+    }
+    
+    if generate && !hasPrivate(keys) {
+        key, err := Generate(s.dir, AlgEdDSA)
+        if err != nil {
+            return err
+        }
+        log.Printf("Generated JWT signing key %s in %s", key.ID, s.dir)
+        keys[key.ID] = key
+    }
+    
+    signing := pickSigning(keys, time.Now().Add(-s.activationDelay))
+    if signing == nil && generate {
+        return ErrNoSigningKey
+    }
+    
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    
+    if s.signing != nil && signing != nil && s.signing.ID != signing.ID {
+        log.Printf("JWT signing key rotated: %s -> %s", s.signing.ID, signing.ID)
+    }
+    s.keys = keys
+    s.signing = signing
+    return nil
+}
+
+// Периодически перечитывает каталог до отмены ctx
+func (s *KeySet) Run(ctx context.Context, interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := s.Reload(); err != nil {
+                log.Printf("Failed to reload JWT keys: %v", err)
+            }
+        }
+    }
+}
+
+// Подписывает claims текущим ключом; kid попадает в заголовок
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+    s.mu.RLock()
+    key := s.signing
+    s.mu.RUnlock()
+    
+    if key == nil {
+        return "", ErrNoSigningKey
+    }
+    
+    token := jwt.NewWithClaims(key.method(), claims)
+    token.Header["kid"] = key.ID
+    return token.SignedString(key.Private)
+}
+
+// Проверяет подпись и срок действия. Алгоритм должен совпадать
+// с алгоритмом ключа из kid — HS256, none и подмена RS/EdDSA отклоняются
+func (s *KeySet) Parse(tokenString string) (jwt.MapClaims, error) {
+    claims := jwt.MapClaims{}
+    _, err := jwt.ParseWithClaims(tokenString, claims, s.keyfunc,
+        jwt.WithValidMethods([]string{AlgRS256, AlgEdDSA}))
+    if err != nil {
+        return nil, err
+    }
+    return claims, nil
+}
+
+func (s *KeySet) keyfunc(token *jwt.Token) (interface{}, error) {
+    kid, _ := token.Header["kid"].(string)
+    
+    s.mu.RLock()
+    key, ok := s.keys[kid]
+    s.mu.RUnlock()
+    
+    if !ok {
+        return nil, ErrUnknownKey
+    }
+    if token.Method.Alg() != key.Alg {
+        return nil, fmt.Errorf("unexpected signing method %s for key %s", token.Method.Alg(), kid)
+    }
+    return key.Public, nil
+}
+
+// Все загруженные ключи, для JWKS
+func (s *KeySet) Keys() []*Key {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    
+    keys := make([]*Key, 0, len(s.keys))
+    for _, key := range s.keys {
+        keys = append(keys, key)
+    }
+    sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+    return keys
+}
+
+// Создает новый закрытый ключ в каталоге. kid — время создания
+func Generate(dir, alg string) (*Key, error) {
+    var (
+        private crypto.Signer
+        err     error
+    )
+    switch alg {
+    case AlgEdDSA:
+        _, private, err = ed25519.GenerateKey(rand.Reader)
+    case AlgRS256:
+        private, err = rsa.GenerateKey(rand.Reader, 3072)
+    default:
+        return nil, fmt.Errorf("unsupported algorithm %q", alg)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to generate key: %w", err)
+    }
+    
+    der, err := x509.MarshalPKCS8PrivateKey(private)
+    if err != nil {
+        return nil, err
+    }
+    
+    if err := os.MkdirAll(dir, 0o700); err != nil {
+        return nil, err
+    }
+    
+    now := time.Now().UTC()
+    kid := now.Format("20060102T150405Z")
+    path := filepath.Join(dir, kid+keyFileExt)
+    
+    // O_EXCL: не перезаписываем ключ, если он уже есть
+    f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    
+    if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+        return nil, err
+    }
+    
+    return &Key{ID: kid, Alg: alg, Private: private, Public: private.Public(), CreatedAt: now}, nil
+}
+
+func loadDir(dir string) (map[string]*Key, error) {
+    entries, err := os.ReadDir(dir)
+    if errors.Is(err, os.ErrNotExist) {
+        return map[string]*Key{}, nil
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to read key directory: %w", err)
+    }
+    
+    keys := make(map[string]*Key)
+    for _, entry := range entries {
+        if entry.IsDir() || !strings.HasSuffix(entry.Name(), keyFileExt) {
+            continue
+        }
+        
+        key, err := loadFile(filepath.Join(dir, entry.Name()))
+        if err != nil {
+            return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+        }
+        keys[key.ID] = key
+    }
+    return keys, nil
+}
+
+func loadFile(path string) (*Key, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    info, err := os.Stat(path)
+    if err != nil {
+        return nil, err
+    }
+    
+    block, _ := pem.Decode(data)
+    if block == nil {
+        return nil, errors.New("no PEM data")
+    }
+    
+    key := &Key{
+        ID:        strings.TrimSuffix(filepath.Base(path), keyFileExt),
+        CreatedAt: info.ModTime(),
+    }
+    
+    var parsed interface{}
+    switch block.Type {
+    case "PRIVATE KEY":
+        parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+    case "RSA PRIVATE KEY":
+        parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+    case "PUBLIC KEY":
+        parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+    default:
+        return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+    }
+    if err != nil {
+        return nil, err
+    }
+    
+    switch k := parsed.(type) {
+    case *rsa.PrivateKey:
+        key.Private, key.Public = k, &k.PublicKey
+    case ed25519.PrivateKey:
+        key.Private, key.Public = k, k.Public()
+    case *rsa.PublicKey, ed25519.PublicKey:
+        key.Public = k
+    default:
+        return nil, fmt.Errorf("unsupported key type %T", parsed)
+    }
+    
+    switch pub := key.Public.(type) {
+    case *rsa.PublicKey:
+        if pub.N.BitLen() < minRSABits {
+            return nil, fmt.Errorf("RSA key must be at least %d bits", minRSABits)
+        }
+        key.Alg = AlgRS256
+    case ed25519.PublicKey:
+        key.Alg = AlgEdDSA
+    }
+    
+    return key, nil
+}
+
+func hasPrivate(keys map[string]*Key) bool {
+    for _, key := range keys {
+        if key.Private != nil {
+            return true
+        }
+    }
+    return false
+}
+
+// Самый новый закрытый ключ, созданный не позже activeBefore.
+// Если таких нет (все ключи свежие) — самый старый из закрытых
+func pickSigning(keys map[string]*Key, activeBefore time.Time) *Key {
+    var newestActive, oldest *Key
+    for _, key := range keys {
+        if key.Private == nil {
+            continue
+        }
+        if !key.CreatedAt.After(activeBefore) && (newestActive == nil || key.CreatedAt.After(newestActive.CreatedAt)) {
+            newestActive = key
+        }
+        if oldest == nil || key.CreatedAt.Before(oldest.CreatedAt) {
+            oldest = key
+        }
+    }
+    if newestActive != nil {
+        return newestActive
+    }
+    return oldest
+}
+
+// Выводит ключ из ротации: закрытая часть удаляется, открытая остается
+// для проверки еще действующих токенов
+func Retire(dir, kid string) error {
+    path := filepath.Join(dir, kid+keyFileExt)
+    key, err := loadFile(path)
+    if err != nil {
+        return err
+    }
+    if key.Private == nil {
+        return nil
+    }
+    
+    der, err := x509.MarshalPKIXPublicKey(key.Public)
+    if err != nil {
+        return err
+    }
+    
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, path)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+    "context"
+    "log"
+    "net/http"
+    "skyflow/internal/models"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// Проверка ключа API. Для неизвестного, отозванного или истекшего ключа
+// возвращает nil без ошибки
+type APIKeyVerifier interface {
+    Verify(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+// Принимает ключ из X-API-Key, иначе передает запрос в fallback (JWTAuth).
+// Владелец ключа кладется в контекст как "user" с заполненным APIKey,
+// так что RequirePermission проверяет права ключа
+func APIKeyAuth(keys APIKeyVerifier, fallback func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        withJWT := fallback(next)
+        
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            presented := r.Header.Get(APIKeyHeader)
+            if presented == "" || keys == nil {
+                withJWT.ServeHTTP(w, r)
+                return
+            }
+            
+            key, err := keys.Verify(r.Context(), presented)
+            if err != nil {
+                log.Printf("API key verification failed: %v", err)
+                http.Error(w, "Failed to verify API key", http.StatusInternalServerError)
+                return
+            }
+            if key == nil {
+                http.Error(w, "Invalid API key", http.StatusUnauthorized)
+                return
+            }
+            
+            user := &models.User{
+                ID:       key.ID,
+                Username: "api-key:" + key.Name,
+                APIKey:   key,
+            }
+            next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "user", user)))
+        })
+    }
+}
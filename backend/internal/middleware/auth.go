@@ -4,11 +4,18 @@ import (
     "context"
     "net/http"
     "strings"
+    "time"
+    "skyflow/internal/jwtkeys"
     "skyflow/internal/models"
-    "github.com/golang-jwt/jwt/v5"
 )
 
-func JWTAuth(jwtSecret string) func(http.Handler) http.Handler {
+// Проверка отзыва токена по jti
+type TokenDenylist interface {
+    IsRevoked(jti string) bool
+}
+
+// denylist может быть nil (демо-режим без базы)
+func JWTAuth(keys *jwtkeys.KeySet, denylist TokenDenylist) func(http.Handler) http.Handler {
     return func(next http.Handler) http.Handler {
         return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
             authHeader := r.Header.Get("Authorization")
@@ -23,29 +30,54 @@ func JWTAuth(jwtSecret string) func(http.Handler) http.Handler {
                 return
             }
             
-            token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-                return []byte(jwtSecret), nil
-            })
-            
-            if err != nil || !token.Valid {
+            claims, err := keys.Parse(tokenString)
+            if err != nil {
                 http.Error(w, "Invalid token", http.StatusUnauthorized)
                 return
             }
             
-            claims, ok := token.Claims.(jwt.MapClaims)
-            if !ok {
-                http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+            // Промежуточные токены (например, 2FA) не дают доступа к API
+            if typ, ok := claims["typ"]; ok && typ != "" {
+                http.Error(w, "Invalid token type", http.StatusUnauthorized)
+                return
+            }
+            
+            // Без jti токен нельзя отозвать — такие не принимаем
+            jti, _ := claims["jti"].(string)
+            if denylist != nil && (jti == "" || denylist.IsRevoked(jti)) {
+                http.Error(w, "Token revoked", http.StatusUnauthorized)
                 return
             }
             
+            var expiresAt time.Time
+            if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+                expiresAt = exp.Time
+            }
+            
             user := &models.User{
                 ID:       claims["user_id"].(string),
                 Username: claims["username"].(string),
                 Role:     claims["role"].(string),
             }
+            user.MustChangePassword, _ = claims["pwd_change"].(bool)
             
             ctx := context.WithValue(r.Context(), "user", user)
+            ctx = context.WithValue(ctx, "token", models.AccessToken{JTI: jti, ExpiresAt: expiresAt})
             next.ServeHTTP(w, r.WithContext(ctx))
         })
     }
+}
+
+// Не пускает пользователей, которым нужно сменить выданный пароль.
+// Должен стоять после JWTAuth
+func RequirePasswordChanged(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        user, ok := r.Context().Value("user").(*models.User)
+        if ok && user.MustChangePassword {
+            http.Error(w, "Password change required", http.StatusForbidden)
+            return
+        }
+        
+        next.ServeHTTP(w, r)
+    })
 }
\ No newline at end of file
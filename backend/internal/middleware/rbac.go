@@ -0,0 +1,50 @@
+package middleware
+
+import (
+    "net/http"
+    "skyflow/internal/models"
+)
+
+// Пропускает только пользователей с одной из указанных ролей.
+// Должен стоять после JWTAuth
+func RequireRole(roles ...models.Role) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            user, ok := r.Context().Value("user").(*models.User)
+            if !ok {
+                http.Error(w, "User not found in context", http.StatusUnauthorized)
+                return
+            }
+            
+            for _, role := range roles {
+                if models.Role(user.Role) == role {
+                    next.ServeHTTP(w, r)
+                    return
+                }
+            }
+            
+            http.Error(w, "Insufficient permissions", http.StatusForbidden)
+        })
+    }
+}
+
+// Пропускает только пользователей, чья роль дает право perm.
+// Должен стоять после JWTAuth
+func RequirePermission(perm models.Permission) func(http.Handler) http.Handler {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            user, ok := r.Context().Value("user").(*models.User)
+            if !ok {
+                http.Error(w, "User not found in context", http.StatusUnauthorized)
+                return
+            }
+            
+            if !user.Can(perm) {
+                http.Error(w, "Insufficient permissions", http.StatusForbidden)
+                return
+            }
+            
+            next.ServeHTTP(w, r)
+        })
+    }
+}
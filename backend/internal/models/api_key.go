@@ -0,0 +1,68 @@
+package models
+
+import (
+    "strings"
+    "time"
+)
+
+// Ключ API для внешних систем (системы авиакомпаний, плееры табло).
+// Сам ключ показывается один раз при создании, в базе хранится хэш
+type APIKey struct {
+    ID     string       `json:"id" db:"id"`
+    Name   string       `json:"name" db:"name"`
+    Prefix string       `json:"prefix" db:"prefix"` // открытая часть ключа для поиска и отображения
+    Scopes []Permission `json:"scopes" db:"scopes"`
+    // Если задано — ключ может менять только рейсы этой авиакомпании
+    Airline    string     `json:"airline,omitempty" db:"airline"`
+    CreatedBy  string     `json:"createdBy" db:"created_by"`
+    CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+    ExpiresAt  *time.Time `json:"expiresAt" db:"expires_at"`
+    LastUsedAt *time.Time `json:"lastUsedAt" db:"last_used_at"`
+    RevokedAt  *time.Time `json:"revokedAt" db:"revoked_at"`
+}
+
+// Права, которые можно выдать ключу. Управление пользователями и ключами — только людям
+var apiKeyScopes = []Permission{
+    PermFlightsRead, PermFlightsCreate, PermFlightsUpdate, PermFlightsGate,
+    PermFlightsDelete, PermFlightsHistory, PermSchedulesManage,
+}
+
+func AllAPIKeyScopes() []Permission {
+    return append([]Permission{}, apiKeyScopes...)
+}
+
+func ValidAPIKeyScope(perm Permission) bool {
+    for _, p := range apiKeyScopes {
+        if p == perm {
+            return true
+        }
+    }
+    return false
+}
+
+func (k *APIKey) Can(perm Permission) bool {
+    for _, p := range k.Scopes {
+        if p == perm {
+            return true
+        }
+    }
+    return false
+}
+
+// Может ли ключ менять рейс авиакомпании airline
+func (k *APIKey) CanManageAirline(airline string) bool {
+    return k.Airline == "" || strings.EqualFold(strings.TrimSpace(airline), k.Airline)
+}
+
+type CreateAPIKeyRequest struct {
+    Name      string     `json:"name" validate:"required"`
+    Scopes    []string   `json:"scopes" validate:"required"`
+    Airline   string     `json:"airline"`
+    ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// Ответ на создание ключа: единственный раз, когда виден сам ключ
+type CreatedAPIKey struct {
+    APIKey
+    Key string `json:"key"`
+}
@@ -0,0 +1,150 @@
+package models
+
+import "time"
+
+const (
+    BoardDepartures = "departures"
+    BoardArrivals   = "arrivals"
+)
+
+// Окно показа табло относительно текущего момента: рейсы со временем
+// (ожидаемым, если есть, иначе плановым) от now-Past до now+Ahead
+type BoardWindow struct {
+    Past  time.Duration
+    Ahead time.Duration
+}
+
+// Табло вылетов или прилетов домашнего аэропорта
+type Board struct {
+    Direction   string    `json:"direction"`
+    Airport     string    `json:"airport"`
+    AirportName string    `json:"airportName,omitempty"`
+    City        string    `json:"city,omitempty"`
+    Timezone    string    `json:"timezone"`
+    GeneratedAt time.Time `json:"generatedAt"`
+    WindowFrom  time.Time `json:"windowFrom"`
+    WindowTo    time.Time `json:"windowTo"`
+    // Режим показа код-шеринга: collapse или expand
+    Codeshares string       `json:"codeshares"`
+    Flights    []BoardEntry `json:"flights"`
+}
+
+// Строка табло. Время — вылета для табло вылетов и прилета для табло прилетов;
+// *Local — то же время по часам домашнего аэропорта
+type BoardEntry struct {
+    FlightID       string `json:"flightId"`
+    FlightNumber   string `json:"flightNumber"`
+    Airline        string `json:"airline"`
+    AirlineName    string `json:"airlineName,omitempty"`
+    AirlineLogoURL string `json:"airlineLogoUrl,omitempty"`
+    // Аэропорт на другом конце: назначения для вылетов, вылета для прилетов
+    Airport string `json:"airport"`
+    City    string `json:"city,omitempty"`
+    
+    // Коммерческие номера рейса (collapse). В режиме expand у строки
+    // коммерческого номера — номер и авиакомпания выполняющего перевозчика
+    Codeshares        []string `json:"codeshares,omitempty"`
+    OperatedBy        string   `json:"operatedBy,omitempty"`
+    OperatedByAirline string   `json:"operatedByAirline,omitempty"`
+    
+    Scheduled      time.Time  `json:"scheduled"`
+    ScheduledLocal string     `json:"scheduledLocal"`
+    Estimated      *time.Time `json:"estimated,omitempty"`
+    EstimatedLocal string     `json:"estimatedLocal,omitempty"`
+    
+    // Задержка вылета или прилета в минутах и ее причины
+    DelayMinutes int           `json:"delayMinutes,omitempty"`
+    DelayCodes   []FlightDelay `json:"delayCodes,omitempty"`
+    
+    Terminal    string `json:"terminal"`
+    Gate        string `json:"gate"`
+    Status      string `json:"status"`
+    DelayReason string `json:"delayReason,omitempty"`
+    Aircraft    string `json:"aircraft,omitempty"`
+}
+
+// Номер выполняемого рейса, к которому относится строка
+func (e BoardEntry) OperatingFlightNumber() string {
+    if e.OperatedBy != "" {
+        return e.OperatedBy
+    }
+    return e.FlightNumber
+}
+
+// Время, по которому строка попадает в окно и сортируется
+func (e BoardEntry) DisplayTime() time.Time {
+    if e.Estimated != nil {
+        return *e.Estimated
+    }
+    return e.Scheduled
+}
+
+// Строка табло для рейса (рейс уже с названиями из справочников).
+// Для прилета без планового времени прилета берется время вылета.
+// Ожидаемое время — уход со стоянки для вылетов и постановка на стоянку
+// для прилетов (см. DepartureTime и ArrivalTime)
+func NewBoardEntry(f Flight, direction string, loc *time.Location) BoardEntry {
+    e := BoardEntry{
+        FlightID:       f.ID,
+        FlightNumber:   f.FlightNumber,
+        Airline:        f.Airline,
+        AirlineName:    f.AirlineName,
+        AirlineLogoURL: f.AirlineLogoURL,
+        Codeshares:     f.CodeshareNumbers(),
+        DelayCodes:     f.DelayCodes,
+        Terminal:       f.Terminal,
+        Gate:           f.Gate,
+        Status:         f.Status,
+        DelayReason:    f.DelayReason,
+        Aircraft:       f.Aircraft,
+    }
+    
+    estimated := f.DepartureTime()
+    if direction == BoardArrivals {
+        e.Airport, e.City = f.From, f.FromCity
+        e.Scheduled = f.Scheduled
+        if f.ScheduledArrival != nil {
+            e.Scheduled = *f.ScheduledArrival
+            e.DelayMinutes = f.ArrivalDelayMinutes
+        } else {
+            e.DelayMinutes = f.DelayMinutes
+        }
+        if arrival, ok := f.ArrivalTime(); ok {
+            estimated = arrival
+        }
+    } else {
+        e.Airport, e.City = f.To, f.ToCity
+        e.Scheduled = f.Scheduled
+        e.DelayMinutes = f.DelayMinutes
+    }
+    if !estimated.Equal(e.Scheduled) {
+        e.Estimated = &estimated
+    }
+    
+    e.ScheduledLocal = e.Scheduled.In(loc).Format(time.RFC3339)
+    if e.Estimated != nil {
+        e.EstimatedLocal = e.Estimated.In(loc).Format(time.RFC3339)
+    }
+    return e
+}
+
+// Строки коммерческих номеров рейса для режима expand: время, выход и статус
+// те же, что у строки выполняемого рейса, номер и авиакомпания — свои
+func CodeshareEntries(operating BoardEntry, f Flight, data *ReferenceData) []BoardEntry {
+    entries := make([]BoardEntry, 0, len(f.Codeshares))
+    for _, c := range f.Codeshares {
+        e := operating
+        e.FlightNumber = c.FlightNumber
+        e.Airline = c.Airline
+        e.AirlineName, e.AirlineLogoURL = "", ""
+        if a, ok := data.Airline(c.Airline); ok {
+            e.AirlineName = a.Name
+            e.AirlineLogoURL = a.LogoURL
+        }
+        e.Codeshares = nil
+        e.OperatedBy = f.FlightNumber
+        e.OperatedByAirline = f.AirlineName
+        entries = append(entries, e)
+    }
+    return entries
+}
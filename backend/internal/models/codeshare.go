@@ -0,0 +1,148 @@
+package models
+
+import (
+    "fmt"
+    "strings"
+    "time"
+)
+
+// Коммерческий номер (код-шеринг) на рейсе, который выполняет другой
+// перевозчик: пассажир может держать билет на любой из номеров
+type Codeshare struct {
+    FlightNumber string `json:"flightNumber"`
+    Airline      string `json:"airline"`
+}
+
+// Режимы показа код-шеринга на табло: одной строкой с перечнем
+// коммерческих номеров или отдельной строкой на каждый номер
+const (
+    CodeshareCollapse = "collapse"
+    CodeshareExpand   = "expand"
+)
+
+// Номер рейса в едином виде: в верхнем регистре, код авиакомпании отделен
+// пробелом ("su1234" — "SU 1234", "sbi55" — "SBI 55")
+func NormalizeFlightNumber(number string) string {
+    number = strings.ToUpper(strings.Join(strings.Fields(number), " "))
+    if strings.Contains(number, " ") || len(number) <= 2 {
+        return number
+    }
+    // Трехбуквенный код ICAO, иначе двухсимвольный IATA
+    split := 2
+    if len(number) > 3 && isLetter(number[2]) {
+        split = 3
+    }
+    return number[:split] + " " + number[split:]
+}
+
+func isLetter(c byte) bool {
+    return c >= 'A' && c <= 'Z'
+}
+
+// Код авиакомпании из номера рейса: "TK 1234" и "TK1234" — TK
+func FlightNumberDesignator(number string) string {
+    code, _, _ := strings.Cut(NormalizeFlightNumber(number), " ")
+    return code
+}
+
+// Выполняемый или один из коммерческих номеров рейса
+// (номера сравниваются в едином виде)
+func (f Flight) HasFlightNumber(number string) bool {
+    number = NormalizeFlightNumber(number)
+    if NormalizeFlightNumber(f.FlightNumber) == number {
+        return true
+    }
+    for _, c := range f.Codeshares {
+        if c.FlightNumber == number {
+            return true
+        }
+    }
+    return false
+}
+
+// Рейс, вылетевший не раньше стольких часов назад, при поиске по номеру
+// еще считается текущим (он в воздухе или только что прилетел)
+const FlightNumberGrace = 6 * time.Hour
+
+// Какой из рейсов с одним номером отдавать при поиске по номеру: неархивный
+// раньше архивного, затем ближайший из текущих и предстоящих, затем
+// последний из прошедших
+func PreferByFlightNumber(a, b Flight, now time.Time) bool {
+    if (a.ArchivedAt == nil) != (b.ArchivedAt == nil) {
+        return a.ArchivedAt == nil
+    }
+    since := now.Add(-FlightNumberGrace)
+    aPast, bPast := a.Scheduled.Before(since), b.Scheduled.Before(since)
+    if aPast != bPast {
+        return !aPast
+    }
+    if aPast {
+        return a.Scheduled.After(b.Scheduled)
+    }
+    return a.Scheduled.Before(b.Scheduled)
+}
+
+// Коммерческие номера рейса
+func (f Flight) CodeshareNumbers() []string {
+    numbers := make([]string, 0, len(f.Codeshares))
+    for _, c := range f.Codeshares {
+        numbers = append(numbers, c.FlightNumber)
+    }
+    return numbers
+}
+
+// Разбирает коммерческие номера из строки через запятую или точку с запятой:
+// "SU 1234, AF 567". Авиакомпания берется из номера
+func ParseCodeshares(value string) []Codeshare {
+    var codeshares []Codeshare
+    for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+        if number := NormalizeFlightNumber(part); number != "" {
+            codeshares = append(codeshares, Codeshare{FlightNumber: number})
+        }
+    }
+    return codeshares
+}
+
+// Коммерческие номера одной строкой для журнала и выгрузки
+func FormatCodeshares(codeshares []Codeshare) string {
+    numbers := make([]string, 0, len(codeshares))
+    for _, c := range codeshares {
+        numbers = append(numbers, c.FlightNumber)
+    }
+    return strings.Join(numbers, ", ")
+}
+
+// Ошибка в списке коммерческих номеров
+type CodeshareError struct {
+    FlightNumber string `json:"flightNumber"`
+    Reason       string `json:"reason"`
+}
+
+func (e *CodeshareError) Error() string {
+    return fmt.Sprintf("codeshare %q: %s", e.FlightNumber, e.Reason)
+}
+
+// Приводит номера к единому виду и проверяет, что они не пустые,
+// не совпадают с выполняемым номером и не повторяются.
+// Пустая авиакомпания заполняется кодом из номера
+func NormalizeCodeshares(operating string, codeshares []Codeshare) ([]Codeshare, error) {
+    result := make([]Codeshare, 0, len(codeshares))
+    seen := map[string]bool{NormalizeFlightNumber(operating): true}
+    for _, c := range codeshares {
+        number := NormalizeFlightNumber(c.FlightNumber)
+        if number == "" {
+            return nil, &CodeshareError{FlightNumber: c.FlightNumber, Reason: "flight number is required"}
+        }
+        if seen[number] {
+            return nil, &CodeshareError{FlightNumber: number, Reason: "duplicates the operating or another marketing number"}
+        }
+        seen[number] = true
+        
+        airline := strings.TrimSpace(c.Airline)
+        if airline == "" {
+            airline = FlightNumberDesignator(number)
+        }
+        result = append(result, Codeshare{FlightNumber: number, Airline: airline})
+    }
+    return result, nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import "testing"
+
+func TestNormalizeFlightNumber(t *testing.T) {
+    tests := []struct {
+        number string
+        want   string
+    }{
+        {"su1234", "SU 1234"},
+        {"SU 1234", "SU 1234"},
+        {"  su   1234 ", "SU 1234"},
+        {"s7123", "S7 123"},
+        {"sbi55", "SBI 55"},
+        {"SU", "SU"},
+        {"", ""},
+    }
+    
+    for _, tt := range tests {
+        if got := NormalizeFlightNumber(tt.number); got != tt.want {
+            t.Errorf("NormalizeFlightNumber(%q) = %q, want %q", tt.number, got, tt.want)
+        }
+    }
+}
+
+func TestFlightHasFlightNumber(t *testing.T) {
+    flight := Flight{
+        FlightNumber: "SU 1234",
+        Codeshares:   []Codeshare{{FlightNumber: "AF 567", Airline: "AF"}},
+    }
+    
+    tests := []struct {
+        number string
+        want   bool
+    }{
+        {"SU 1234", true},
+        {"su1234", true},
+        {"AF 567", true},
+        {"af567", true},
+        {"SU 123", false},
+        {"KL 567", false},
+    }
+    
+    for _, tt := range tests {
+        if got := flight.HasFlightNumber(tt.number); got != tt.want {
+            t.Errorf("HasFlightNumber(%q) = %v, want %v", tt.number, got, tt.want)
+        }
+    }
+}
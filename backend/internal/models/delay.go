@@ -0,0 +1,313 @@
+package models
+
+import (
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Код задержки IATA (AHM 730): двузначный номер и буквенный код
+type DelayCode struct {
+    Code        string `json:"code"`
+    Alpha       string `json:"alpha"`
+    Group       string `json:"group"`
+    Description string `json:"description"`
+}
+
+// Задержка рейса по коду: сколько минут задержки отнесено на эту причину
+type FlightDelay struct {
+    Code        string `json:"code"`
+    Alpha       string `json:"alpha,omitempty"`
+    Minutes     int    `json:"minutes"`
+    Description string `json:"description,omitempty"`
+}
+
+// Стандартные коды AHM 730. Коды 01–05 авиакомпании назначают сами
+var delayCodes = []DelayCode{
+    {"06", "OA", "Others", "No gate/stand availability due to own airline activity"},
+    {"09", "SG", "Others", "Scheduled ground time less than declared minimum ground time"},
+    
+    {"11", "PD", "Passenger and baggage", "Late check-in, acceptance after deadline"},
+    {"12", "PL", "Passenger and baggage", "Late check-in, congestion in check-in area"},
+    {"13", "PE", "Passenger and baggage", "Check-in error, passenger and baggage"},
+    {"14", "PO", "Passenger and baggage", "Oversales, booking errors"},
+    {"15", "PH", "Passenger and baggage", "Boarding, discrepancies and paging, missing checked-in passenger"},
+    {"16", "PS", "Passenger and baggage", "Commercial publicity/passenger convenience, VIP, press, ground meals and missing personal items"},
+    {"17", "PC", "Passenger and baggage", "Catering order, late or incorrect order given to supplier"},
+    {"18", "PB", "Passenger and baggage", "Baggage processing, sorting etc."},
+    {"19", "PW", "Passenger and baggage", "Reduced mobility, boarding/deboarding of passengers with reduced mobility"},
+    
+    {"21", "CD", "Cargo and mail", "Documentation, errors etc."},
+    {"22", "CP", "Cargo and mail", "Late positioning"},
+    {"23", "CC", "Cargo and mail", "Late acceptance"},
+    {"24", "CI", "Cargo and mail", "Inadequate packing"},
+    {"25", "CO", "Cargo and mail", "Oversales, booking errors"},
+    {"26", "CU", "Cargo and mail", "Late preparation in warehouse"},
+    {"27", "CE", "Cargo and mail", "Mail: documentation, packing etc."},
+    {"28", "CL", "Cargo and mail", "Mail: late positioning"},
+    {"29", "CA", "Cargo and mail", "Mail: late acceptance"},
+    
+    {"31", "GD", "Aircraft and ramp handling", "Aircraft documentation late/inaccurate, weight and balance, general declaration, passenger manifest"},
+    {"32", "GL", "Aircraft and ramp handling", "Loading/unloading, bulky/special load, cabin load, lack of loading staff"},
+    {"33", "GE", "Aircraft and ramp handling", "Loading equipment, lack of or breakdown, lack of operating staff"},
+    {"34", "GS", "Aircraft and ramp handling", "Servicing equipment, lack of or breakdown, lack of staff, e.g. steps"},
+    {"35", "GC", "Aircraft and ramp handling", "Aircraft cleaning"},
+    {"36", "GF", "Aircraft and ramp handling", "Fuelling/defuelling, fuel supplier"},
+    {"37", "GB", "Aircraft and ramp handling", "Catering, late delivery or loading"},
+    {"38", "GU", "Aircraft and ramp handling", "ULD, lack of or serviceability"},
+    {"39", "GT", "Aircraft and ramp handling", "Technical equipment, lack of or breakdown, lack of staff, e.g. pushback"},
+    
+    {"41", "TD", "Technical and aircraft equipment", "Aircraft defects"},
+    {"42", "TM", "Technical and aircraft equipment", "Scheduled maintenance, late release"},
+    {"43", "TN", "Technical and aircraft equipment", "Non-scheduled maintenance, special checks and/or additional works beyond normal maintenance schedule"},
+    {"44", "TS", "Technical and aircraft equipment", "Spares and maintenance equipment, lack of or breakdown"},
+    {"45", "TA", "Technical and aircraft equipment", "AOG spares, to be carried to another station"},
+    {"46", "TC", "Technical and aircraft equipment", "Aircraft change for technical reasons"},
+    {"47", "TL", "Technical and aircraft equipment", "Standby aircraft, lack of planned standby aircraft for technical reasons"},
+    {"48", "TV", "Technical and aircraft equipment", "Scheduled cabin configuration/version adjustments"},
+    
+    {"51", "DF", "Damage to aircraft", "Damage during flight operations, bird or lightning strike, turbulence, heavy or overweight landing"},
+    {"52", "DG", "Damage to aircraft", "Damage during ground operations, collisions, loading/offloading damage, contamination, towing, extreme weather"},
+    {"55", "ED", "EDP/automated equipment failure", "Departure control"},
+    {"56", "EC", "EDP/automated equipment failure", "Cargo preparation/documentation"},
+    {"57", "EF", "EDP/automated equipment failure", "Flight plans"},
+    {"58", "EO", "EDP/automated equipment failure", "Other automated system"},
+    
+    {"61", "FP", "Flight operations and crewing", "Flight plan, late completion or change of flight documentation"},
+    {"62", "FF", "Flight operations and crewing", "Operational requirements, fuel, load alteration"},
+    {"63", "FT", "Flight operations and crewing", "Late crew boarding or departure procedures, other than connection and standby (flight deck or entire crew)"},
+    {"64", "FS", "Flight operations and crewing", "Flight deck crew shortage, sickness, awaiting standby, flight time limitations, valid visa, health documents"},
+    {"65", "FR", "Flight operations and crewing", "Flight deck crew special request, not within operational requirements"},
+    {"66", "FL", "Flight operations and crewing", "Late cabin crew boarding or departure procedures, other than connection and standby"},
+    {"67", "FC", "Flight operations and crewing", "Cabin crew shortage, sickness, awaiting standby, flight time limitations, valid visa, health documents"},
+    {"68", "FA", "Flight operations and crewing", "Cabin crew error or special request, not within operational requirements"},
+    {"69", "FB", "Flight operations and crewing", "Captain request for security check, extraordinary"},
+    
+    {"71", "WO", "Weather", "Departure station"},
+    {"72", "WT", "Weather", "Destination station"},
+    {"73", "WR", "Weather", "En route or alternate"},
+    {"75", "WI", "Weather", "De-icing of aircraft, removal of ice and/or snow, frost prevention"},
+    {"76", "WS", "Weather", "Removal of snow, ice, water and sand from airport"},
+    {"77", "WG", "Weather", "Ground handling impaired by adverse weather conditions"},
+    
+    {"81", "AT", "Air traffic flow management", "ATFM due to ATC en-route demand/capacity"},
+    {"82", "AX", "Air traffic flow management", "ATFM due to ATC staff/equipment en-route, industrial action, military exercise"},
+    {"83", "AE", "Air traffic flow management", "ATFM due to restriction at destination airport"},
+    {"84", "AW", "Air traffic flow management", "ATFM due to weather at destination"},
+    
+    {"85", "AS", "Airport and governmental authorities", "Mandatory security"},
+    {"86", "AG", "Airport and governmental authorities", "Immigration, customs, health"},
+    {"87", "AF", "Airport and governmental authorities", "Airport facilities, parking stands, ramp congestion, lighting, buildings, gate limitations"},
+    {"88", "AD", "Airport and governmental authorities", "Restrictions at airport of destination, airport and/or runway closed, noise abatement, night curfew"},
+    {"89", "AM", "Airport and governmental authorities", "Restrictions at airport of departure, start-up and pushback, airport and/or runway closed, night curfew"},
+    
+    {"91", "RL", "Reactionary", "Load connection, awaiting load from another flight"},
+    {"92", "RT", "Reactionary", "Through check-in error, passenger and baggage"},
+    {"93", "RA", "Reactionary", "Aircraft rotation, late arrival of aircraft from another flight or previous sector"},
+    {"94", "RS", "Reactionary", "Cabin crew rotation, awaiting cabin crew from another flight"},
+    {"95", "RC", "Reactionary", "Crew rotation, awaiting flight deck or entire crew from another flight"},
+    {"96", "RO", "Reactionary", "Operations control, re-routing, diversion, consolidation, aircraft change for non-technical reasons"},
+    
+    {"97", "MI", "Miscellaneous", "Industrial action within own airline"},
+    {"98", "MO", "Miscellaneous", "Industrial action outside own airline, excluding ATS"},
+    {"99", "MX", "Miscellaneous", "Other reason, not matching any code above"},
+}
+
+// Все коды задержки по возрастанию номера
+func DelayCodes() []DelayCode {
+    return append([]DelayCode{}, delayCodes...)
+}
+
+// Код задержки по номеру (93) или буквенному коду (RA).
+// 01–05 — внутренние коды авиакомпании, описания у них нет
+func LookupDelayCode(value string) (DelayCode, bool) {
+    value = strings.ToUpper(strings.TrimSpace(value))
+    if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= 5 {
+        return DelayCode{Code: fmt.Sprintf("%02d", n), Group: "Airline internal"}, true
+    }
+    for _, c := range delayCodes {
+        if c.Code == value || c.Alpha == value || "0"+c.Code == value {
+            return c, true
+        }
+    }
+    return DelayCode{}, false
+}
+
+// Коды задержки рейса: номер, буквенный код и описание берутся из
+// справочника AHM 730. Ошибка — неизвестный код или отрицательные минуты
+func NormalizeFlightDelays(delays []FlightDelay) ([]FlightDelay, error) {
+    result := make([]FlightDelay, 0, len(delays))
+    for _, d := range delays {
+        code, ok := LookupDelayCode(d.Code)
+        if !ok {
+            return nil, fmt.Errorf("unknown delay code %q", d.Code)
+        }
+        if d.Minutes < 0 {
+            return nil, fmt.Errorf("delay minutes for code %s must not be negative", code.Code)
+        }
+        result = append(result, FlightDelay{
+            Code:        code.Code,
+            Alpha:       code.Alpha,
+            Minutes:     d.Minutes,
+            Description: code.Description,
+        })
+    }
+    return result, nil
+}
+
+// Сумма минут по кодам задержки
+func DelayCodeMinutes(delays []FlightDelay) int {
+    total := 0
+    for _, d := range delays {
+        total += d.Minutes
+    }
+    return total
+}
+
+// Коды задержки одной строкой для журнала и выгрузки: "93/25 81/10"
+func FormatDelayCodes(delays []FlightDelay) string {
+    parts := make([]string, 0, len(delays))
+    for _, d := range delays {
+        parts = append(parts, d.Code+"/"+strconv.Itoa(d.Minutes))
+    }
+    return strings.Join(parts, " ")
+}
+
+// Разбирает коды задержки из строки вида "93/25 81/10" (или через запятую);
+// код без минут — "93"
+func ParseDelayCodes(value string) ([]FlightDelay, error) {
+    var delays []FlightDelay
+    for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ' ' || r == ',' || r == ';' }) {
+        code, minutes, found := strings.Cut(part, "/")
+        delay := FlightDelay{Code: code}
+        if found {
+            n, err := strconv.Atoi(minutes)
+            if err != nil {
+                return nil, fmt.Errorf("invalid delay minutes %q for code %s", minutes, code)
+            }
+            delay.Minutes = n
+        }
+        delays = append(delays, delay)
+    }
+    return NormalizeFlightDelays(delays)
+}
+
+// Ожидаемое время ухода со стоянки: фактическое, оценочное, иначе плановое
+func (f Flight) DepartureTime() time.Time {
+    switch {
+    case f.ActualOffBlock != nil:
+        return *f.ActualOffBlock
+    case f.EstimatedOffBlock != nil:
+        return *f.EstimatedOffBlock
+    }
+    return f.Scheduled
+}
+
+// Ожидаемое время постановки на стоянку в аэропорту прилета: фактическое,
+// оценочное, иначе плановое, сдвинутое на задержку вылета.
+// false, если ни одно из них неизвестно
+func (f Flight) ArrivalTime() (time.Time, bool) {
+    switch {
+    case f.ActualInBlock != nil:
+        return *f.ActualInBlock, true
+    case f.EstimatedInBlock != nil:
+        return *f.EstimatedInBlock, true
+    case f.ScheduledArrival != nil:
+        return f.ScheduledArrival.Add(f.DepartureTime().Sub(f.Scheduled)), true
+    }
+    return time.Time{}, false
+}
+
+// Пересчитывает вычисляемые поля по временам этапов: Actual (ожидаемый
+// уход со стоянки) и минуты задержки вылета и прилета (не меньше нуля)
+func (f *Flight) ComputeDelay() {
+    departure := f.DepartureTime()
+    f.Actual = departure
+    f.DelayMinutes = delayMinutes(f.Scheduled, departure)
+    
+    f.ArrivalDelayMinutes = 0
+    if f.ScheduledArrival != nil {
+        if arrival, ok := f.ArrivalTime(); ok {
+            f.ArrivalDelayMinutes = delayMinutes(*f.ScheduledArrival, arrival)
+        }
+    }
+}
+
+func delayMinutes(scheduled, expected time.Time) int {
+    minutes := math.Round(expected.Sub(scheduled).Minutes())
+    if minutes < 0 {
+        return 0
+    }
+    return int(minutes)
+}
+
+// Выводит статус delayed из задержки: рейс в статусе scheduled с задержкой
+// вылета от threshold становится delayed, а delayed, у которого оценка
+// вернулась в пределы порога, — снова scheduled. Другие статусы не меняются.
+// true, если статус изменился
+func (f *Flight) DeriveDelayedStatus(threshold time.Duration) bool {
+    if threshold <= 0 {
+        return false
+    }
+    
+    late := time.Duration(f.DelayMinutes)*time.Minute >= threshold
+    switch FlightStatus(f.Status) {
+    case StatusScheduled:
+        if late {
+            f.Status = string(StatusDelayed)
+            return true
+        }
+    case StatusDelayed:
+        // Без оценки задержка неизвестна — пометку ставили вручную
+        hasEstimate := f.EstimatedOffBlock != nil || f.ActualOffBlock != nil
+        if hasEstimate && !late {
+            f.Status = string(StatusScheduled)
+            return true
+        }
+    }
+    return false
+}
+
+// Время этапа рейса: JSON-имя, указатель на поле рейса и признак того,
+// что время без смещения читается по часам аэропорта прилета
+type StageTime struct {
+    Name    string
+    Value   **time.Time
+    Arrival bool
+}
+
+// Оценочные, затем фактические времена этапов в порядке полета
+func (f *Flight) StageTimes() []StageTime {
+    return []StageTime{
+        {"estimatedOffBlock", &f.EstimatedOffBlock, false},
+        {"estimatedTakeoff", &f.EstimatedTakeoff, false},
+        {"estimatedLanding", &f.EstimatedLanding, true},
+        {"estimatedInBlock", &f.EstimatedInBlock, true},
+        {"actualOffBlock", &f.ActualOffBlock, false},
+        {"actualTakeoff", &f.ActualTakeoff, false},
+        {"actualLanding", &f.ActualLanding, true},
+        {"actualInBlock", &f.ActualInBlock, true},
+    }
+}
+
+// Проверяет порядок этапов: взлет не раньше ухода со стоянки, посадка
+// не раньше взлета, постановка на стоянку не раньше посадки.
+// Возвращает имя поля, нарушающего порядок
+func (f Flight) CheckStageOrder() (string, bool) {
+    for _, set := range [][]StageTime{f.StageTimes()[:4], f.StageTimes()[4:]} {
+        var previous *time.Time
+        for _, stage := range set {
+            t := *stage.Value
+            if t == nil {
+                continue
+            }
+            if previous != nil && t.Before(*previous) {
+                return stage.Name, false
+            }
+            previous = t
+        }
+    }
+    return "", true
+}
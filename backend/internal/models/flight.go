@@ -1,6 +1,7 @@
 package models
 
 import (
+    "fmt"
     "time"
 )
 
@@ -10,33 +11,203 @@ type Flight struct {
     Airline      string    `json:"airline" db:"airline"`
     From         string    `json:"from" db:"origin"`
     To           string    `json:"to" db:"destination"`
-    Scheduled    time.Time `json:"scheduled" db:"scheduled_time"`
-    Actual       time.Time `json:"actual" db:"actual_time"`
-    Terminal     string    `json:"terminal" db:"terminal"`
-    Gate         string    `json:"gate" db:"gate"`
-    Status       string    `json:"status" db:"status"`
-    DelayReason  string    `json:"delayReason" db:"delay_reason"`
-    CreatedAt    time.Time `json:"createdAt" db:"created_at"`
-    UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+    Scheduled    time.Time `json:"scheduled" db:"scheduled_time"` // вылет, UTC
+    // Ожидаемый уход со стоянки (см. DepartureTime); вычисляется, задается
+    // через estimatedOffBlock/actualOffBlock
+    Actual time.Time `json:"actual" db:"actual_time"`
+    // Плановый прилет, UTC; необязателен
+    ScheduledArrival *time.Time `json:"scheduledArrival,omitempty" db:"scheduled_arrival_time"`
+    Terminal         string     `json:"terminal" db:"terminal"`
+    Gate             string     `json:"gate" db:"gate"`
+    Status           string     `json:"status" db:"status"`
+    DelayReason      string     `json:"delayReason" db:"delay_reason"`
+    Aircraft         string     `json:"aircraft,omitempty" db:"aircraft_type"` // ICAO-код типа ВС
+    CreatedAt        time.Time  `json:"createdAt" db:"created_at"`
+    UpdatedAt        time.Time  `json:"updatedAt" db:"updated_at"`
+    
+    // Оценочные и фактические времена этапов, UTC: off-block — уход со
+    // стоянки, takeoff — взлет, landing — посадка, in-block — постановка на стоянку
+    EstimatedOffBlock *time.Time `json:"estimatedOffBlock,omitempty" db:"estimated_off_block"`
+    EstimatedTakeoff  *time.Time `json:"estimatedTakeoff,omitempty" db:"estimated_takeoff"`
+    EstimatedLanding  *time.Time `json:"estimatedLanding,omitempty" db:"estimated_landing"`
+    EstimatedInBlock  *time.Time `json:"estimatedInBlock,omitempty" db:"estimated_in_block"`
+    ActualOffBlock    *time.Time `json:"actualOffBlock,omitempty" db:"actual_off_block"`
+    ActualTakeoff     *time.Time `json:"actualTakeoff,omitempty" db:"actual_takeoff"`
+    ActualLanding     *time.Time `json:"actualLanding,omitempty" db:"actual_landing"`
+    ActualInBlock     *time.Time `json:"actualInBlock,omitempty" db:"actual_in_block"`
+    // Задержка вылета и прилета в минутах; вычисляются (см. ComputeDelay)
+    DelayMinutes        int `json:"delayMinutes" db:"delay_minutes"`
+    ArrivalDelayMinutes int `json:"arrivalDelayMinutes" db:"arrival_delay_minutes"`
+    // Причины задержки по кодам IATA AHM 730
+    DelayCodes []FlightDelay `json:"delayCodes,omitempty" db:"delay_codes"`
+    // Коммерческие номера других авиакомпаний на этом рейсе (код-шеринг);
+    // FlightNumber — номер выполняющего перевозчика
+    Codeshares []Codeshare `json:"codeshares,omitempty" db:"codeshares"`
+    
+    // Рейс из расписания: расписание, местная дата вылета и признак ручной правки
+    // (такие рейсы генератор больше не трогает)
+    ScheduleID       string `json:"scheduleId,omitempty" db:"schedule_id"`
+    ServiceDate      string `json:"serviceDate,omitempty" db:"service_date"`
+    ScheduleOverride bool   `json:"scheduleOverride,omitempty" db:"schedule_override"`
+    
+    // Когда и кем (manual — сотрудник или API, auto — планировщик статусов)
+    // последний раз менялся статус; время архивации завершенного рейса
+    StatusUpdatedAt time.Time  `json:"statusUpdatedAt" db:"status_updated_at"`
+    StatusSource    string     `json:"statusSource,omitempty" db:"status_source"`
+    ArchivedAt      *time.Time `json:"archivedAt,omitempty" db:"archived_at"`
+    
+    // Названия из справочников; не хранятся, заполняются при выдаче
+    FlightDisplay
 }
 
 type FlightStatus string
 
 const (
-    StatusScheduled FlightStatus = "scheduled"
-    StatusBoarding  FlightStatus = "boarding"
-    StatusDelayed   FlightStatus = "delayed"
-    StatusDeparted  FlightStatus = "departed"
-    StatusArrived   FlightStatus = "arrived"
-    StatusCancelled FlightStatus = "cancelled"
+    StatusScheduled  FlightStatus = "scheduled"
+    StatusCheckIn    FlightStatus = "check_in"
+    StatusBoarding   FlightStatus = "boarding"
+    StatusFinalCall  FlightStatus = "final_call"
+    StatusGateClosed FlightStatus = "gate_closed"
+    StatusDelayed    FlightStatus = "delayed"
+    StatusDeparted   FlightStatus = "departed"
+    StatusLanded     FlightStatus = "landed"
+    StatusArrived    FlightStatus = "arrived"
+    StatusCancelled  FlightStatus = "cancelled"
 )
 
+// Кто сменил статус
+const (
+    StatusSourceManual = "manual"
+    StatusSourceAuto   = "auto"
+)
+
+// Допустимые переходы между статусами.
+// arrived и cancelled — конечные состояния
+var statusTransitions = map[FlightStatus][]FlightStatus{
+    StatusScheduled:  {StatusCheckIn, StatusBoarding, StatusDelayed, StatusCancelled},
+    StatusCheckIn:    {StatusBoarding, StatusDelayed, StatusCancelled},
+    StatusDelayed:    {StatusScheduled, StatusCheckIn, StatusBoarding, StatusCancelled},
+    StatusBoarding:   {StatusFinalCall, StatusGateClosed, StatusDeparted, StatusDelayed, StatusCancelled},
+    StatusFinalCall:  {StatusGateClosed, StatusDeparted, StatusDelayed, StatusCancelled},
+    StatusGateClosed: {StatusDeparted, StatusDelayed, StatusCancelled},
+    StatusDeparted:   {StatusLanded, StatusArrived},
+    StatusLanded:     {StatusArrived},
+    StatusArrived:    {},
+    StatusCancelled:  {},
+}
+
+// Порядок статусов в жизненном цикле рейса: планировщик двигает статус
+// только вперед. delayed стоит наравне с scheduled — это пометка, а не этап
+var statusStages = map[FlightStatus]int{
+    StatusScheduled:  0,
+    StatusDelayed:    0,
+    StatusCheckIn:    1,
+    StatusBoarding:   2,
+    StatusFinalCall:  3,
+    StatusGateClosed: 4,
+    StatusDeparted:   5,
+    StatusLanded:     6,
+    StatusArrived:    7,
+}
+
+// Этап статуса в жизненном цикле; false для cancelled и неизвестных статусов
+func (s FlightStatus) Stage() (int, bool) {
+    stage, ok := statusStages[s]
+    return stage, ok
+}
+
+// Рейс завершен: приземлился, прибыл или отменен
+func (s FlightStatus) Completed() bool {
+    return s == StatusLanded || s == StatusArrived || s == StatusCancelled
+}
+
+func (s FlightStatus) Valid() bool {
+    _, ok := statusTransitions[s]
+    return ok
+}
+
+// Статусы, в которые можно перейти из s
+func (s FlightStatus) AllowedTransitions() []FlightStatus {
+    return append([]FlightStatus{}, statusTransitions[s]...)
+}
+
+func (s FlightStatus) CanTransitionTo(next FlightStatus) bool {
+    if !next.Valid() {
+        return false
+    }
+    // Рейсы со старыми произвольными статусами можно перевести в любой корректный
+    if !s.Valid() || s == next {
+        return true
+    }
+    for _, allowed := range statusTransitions[s] {
+        if allowed == next {
+            return true
+        }
+    }
+    return false
+}
+
+// Все известные статусы в порядке жизненного цикла рейса
+func AllFlightStatuses() []FlightStatus {
+    return []FlightStatus{
+        StatusScheduled, StatusDelayed, StatusCheckIn, StatusBoarding, StatusFinalCall,
+        StatusGateClosed, StatusDeparted, StatusLanded, StatusArrived, StatusCancelled,
+    }
+}
+
+// Ошибка недопустимой смены статуса
+type StatusTransitionError struct {
+    From    FlightStatus   `json:"from"`
+    To      FlightStatus   `json:"to"`
+    Allowed []FlightStatus `json:"allowed"`
+}
+
+func (e *StatusTransitionError) Error() string {
+    return fmt.Sprintf("cannot change flight status from %s to %s", e.From, e.To)
+}
+
+// Проверяет переход from -> to
+func ValidateStatusTransition(from, to FlightStatus) error {
+    if from.CanTransitionTo(to) {
+        return nil
+    }
+    return &StatusTransitionError{From: from, To: to, Allowed: from.AllowedTransitions()}
+}
+
+// Форматы времени без смещения — местное время аэропорта
+var localTimeLayouts = []string{
+    "2006-01-02T15:04:05",
+    "2006-01-02T15:04",
+    "2006-01-02 15:04:05",
+    "2006-01-02 15:04",
+}
+
+// Разбирает время рейса. Время со смещением (RFC3339) принимается как есть,
+// без смещения — считается местным временем loc. Результат всегда в UTC
+func ParseFlightTime(value string, loc *time.Location) (time.Time, error) {
+    if t, err := time.Parse(time.RFC3339, value); err == nil {
+        return t.UTC(), nil
+    }
+    for _, layout := range localTimeLayouts {
+        if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+            return t.UTC(), nil
+        }
+    }
+    return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339 or local 2006-01-02T15:04", value)
+}
+
 type FlightRequest struct {
-    FlightNumber string    `json:"flightNumber" validate:"required"`
-    Airline      string    `json:"airline" validate:"required"`
-    From         string    `json:"from" validate:"required"`
-    To           string    `json:"to" validate:"required"`
-    Scheduled    string    `json:"scheduled" validate:"required"`
-    Terminal     string    `json:"terminal"`
-    Gate         string    `json:"gate"`
-}
\ No newline at end of file
+    FlightNumber string `json:"flightNumber" validate:"required"`
+    Airline      string `json:"airline" validate:"required"`
+    From         string `json:"from" validate:"required"`
+    To           string `json:"to" validate:"required"`
+    // RFC3339 или местное время аэропорта вылета без смещения (2006-01-02T15:04)
+    Scheduled string `json:"scheduled" validate:"required"`
+    // Местное время аэропорта прилета, если без смещения
+    ScheduledArrival string `json:"scheduledArrival"`
+    Terminal         string `json:"terminal"`
+    Gate             string `json:"gate"`
+    Aircraft         string `json:"aircraft"`
+    // Коммерческие номера; авиакомпанию можно не указывать — берется из номера
+    Codeshares []Codeshare `json:"codeshares"`
+}
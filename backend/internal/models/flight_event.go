@@ -0,0 +1,129 @@
+package models
+
+import (
+    "time"
+)
+
+type FlightEventAction string
+
+const (
+    FlightEventCreated  FlightEventAction = "created"
+    FlightEventUpdated  FlightEventAction = "updated"
+    FlightEventDeleted  FlightEventAction = "deleted"
+    FlightEventArchived FlightEventAction = "archived"
+)
+
+// Запись журнала изменений рейса
+type FlightEvent struct {
+    ID           string                 `json:"id" db:"id"`
+    FlightID     string                 `json:"flightId" db:"flight_id"`
+    FlightNumber string                 `json:"flightNumber" db:"flight_number"`
+    Action       FlightEventAction      `json:"action" db:"action"`
+    UserID       string                 `json:"userId" db:"user_id"`
+    Username     string                 `json:"username" db:"username"`
+    Changes      map[string]FieldChange `json:"changes" db:"changes"`
+    CreatedAt    time.Time              `json:"createdAt" db:"created_at"`
+}
+
+// Кто меняет рейс. Хранилище пишет запись журнала в той же транзакции,
+// что и само изменение; nil — изменение без записи в журнал
+type FlightAuthor struct {
+    UserID   string
+    Username string
+}
+
+// Запись журнала об изменении рейса от имени автора
+func (a *FlightAuthor) Event(action FlightEventAction, flight Flight, changes map[string]FieldChange) *FlightEvent {
+    return &FlightEvent{
+        FlightID:     flight.ID,
+        FlightNumber: flight.FlightNumber,
+        Action:       action,
+        UserID:       a.UserID,
+        Username:     a.Username,
+        Changes:      changes,
+    }
+}
+
+// Значение поля до и после изменения
+type FieldChange struct {
+    From interface{} `json:"from"`
+    To   interface{} `json:"to"`
+}
+
+// Поля рейса по их JSON-именам
+func flightFields(f Flight) map[string]interface{} {
+    fields := map[string]interface{}{
+        "flightNumber":     f.FlightNumber,
+        "airline":          f.Airline,
+        "from":             f.From,
+        "to":               f.To,
+        "scheduled":        f.Scheduled,
+        "actual":           f.Actual,
+        "scheduledArrival": optionalTime(f.ScheduledArrival),
+        "terminal":         f.Terminal,
+        "gate":             f.Gate,
+        "status":           f.Status,
+        "delayReason":      f.DelayReason,
+        "aircraft":         f.Aircraft,
+        "delayCodes":       FormatDelayCodes(f.DelayCodes),
+        "codeshares":       FormatCodeshares(f.Codeshares),
+    }
+    for _, stage := range f.StageTimes() {
+        fields[stage.Name] = optionalTime(*stage.Value)
+    }
+    return fields
+}
+
+// Изменившиеся поля рейса
+func DiffFlights(before, after Flight) map[string]FieldChange {
+    changes := make(map[string]FieldChange)
+    old := flightFields(before)
+    for name, value := range flightFields(after) {
+        if !fieldEqual(old[name], value) {
+            changes[name] = FieldChange{From: old[name], To: value}
+        }
+    }
+    return changes
+}
+
+// Все заполненные поля рейса как изменения from nil (создание)
+// или to nil (удаление)
+func FlightSnapshot(f Flight, deleted bool) map[string]FieldChange {
+    changes := make(map[string]FieldChange)
+    for name, value := range flightFields(f) {
+        if isZeroField(value) {
+            continue
+        }
+        if deleted {
+            changes[name] = FieldChange{From: value}
+        } else {
+            changes[name] = FieldChange{To: value}
+        }
+    }
+    return changes
+}
+
+func optionalTime(t *time.Time) interface{} {
+    if t == nil {
+        return nil
+    }
+    return *t
+}
+
+func fieldEqual(a, b interface{}) bool {
+    if ta, ok := a.(time.Time); ok {
+        tb, ok := b.(time.Time)
+        return ok && ta.Equal(tb)
+    }
+    return a == b
+}
+
+func isZeroField(v interface{}) bool {
+    switch value := v.(type) {
+    case string:
+        return value == ""
+    case time.Time:
+        return value.IsZero()
+    }
+    return v == nil
+}
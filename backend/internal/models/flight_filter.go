@@ -0,0 +1,241 @@
+package models
+
+import (
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "strings"
+    "time"
+)
+
+// Ключи сортировки списка рейсов
+const (
+    SortScheduled    = "scheduled"
+    SortActual       = "actual"
+    SortFlightNumber = "flightNumber"
+    SortAirline      = "airline"
+    SortFrom         = "from"
+    SortTo           = "to"
+    SortTerminal     = "terminal"
+    SortGate         = "gate"
+    SortStatus       = "status"
+    SortUpdatedAt    = "updatedAt"
+)
+
+func FlightSortKeys() []string {
+    return []string{
+        SortScheduled, SortActual, SortFlightNumber, SortAirline, SortFrom,
+        SortTo, SortTerminal, SortGate, SortStatus, SortUpdatedAt,
+    }
+}
+
+func ValidFlightSortKey(key string) bool {
+    for _, k := range FlightSortKeys() {
+        if k == key {
+            return true
+        }
+    }
+    return false
+}
+
+// Архивные рейсы в выборке
+const (
+    ArchivedExclude = ""
+    ArchivedOnly    = "only"
+    ArchivedInclude = "all"
+)
+
+// Отбор рейсов для списка и выгрузки. Пустые поля не ограничивают выборку
+// (кроме Archived: по умолчанию архивные рейсы не показываются)
+type FlightFilter struct {
+    Archived    string
+    Origin      string
+    Destination string
+    // Плановый вылет в [ScheduledFrom, ScheduledTo)
+    ScheduledFrom *time.Time
+    ScheduledTo   *time.Time
+    Airline       string
+    Statuses      []string
+    Terminal      string
+    Gate          string
+    // Подстрока номера рейса (без учета регистра и пробелов)
+    // или аэропорт вылета/прилета из SearchAirports
+    Search         string
+    SearchAirports []string
+    
+    // Сортировка; при равных значениях — по ID, чтобы порядок был стабильным
+    SortKey  string
+    SortDesc bool
+    // Продолжение после рейса из курсора; nil — с начала
+    After *FlightCursor
+    // 0 — без ограничения
+    Limit int
+}
+
+// Страница списка рейсов
+type FlightPage struct {
+    Flights []Flight
+    // Сколько всего рейсов подходит под фильтр (без учета курсора)
+    Total int
+    // Курсор следующей страницы; пустой — страница последняя
+    NextCursor string
+}
+
+// Позиция в списке: ключ сортировки и значение последнего рейса страницы
+type FlightCursor struct {
+    SortKey  string `json:"k"`
+    SortDesc bool   `json:"d,omitempty"`
+    Value    string `json:"v"`
+    ID       string `json:"id"`
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Время в курсоре: фиксированная ширина, чтобы строки сравнивались как время
+const sortTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// Значение ключа сортировки рейса в виде строки
+func (f Flight) SortValue(key string) string {
+    switch key {
+    case SortActual:
+        return f.Actual.UTC().Format(sortTimeLayout)
+    case SortFlightNumber:
+        return f.FlightNumber
+    case SortAirline:
+        return f.Airline
+    case SortFrom:
+        return f.From
+    case SortTo:
+        return f.To
+    case SortTerminal:
+        return f.Terminal
+    case SortGate:
+        return f.Gate
+    case SortStatus:
+        return f.Status
+    case SortUpdatedAt:
+        return f.UpdatedAt.UTC().Format(sortTimeLayout)
+    }
+    return f.Scheduled.UTC().Format(sortTimeLayout)
+}
+
+// Ключи, значения которых — время
+func IsTimeSortKey(key string) bool {
+    return key == SortScheduled || key == SortActual || key == SortUpdatedAt
+}
+
+// Курсор, указывающий на рейс f при данной сортировке
+func NewFlightCursor(f Flight, key string, desc bool) FlightCursor {
+    return FlightCursor{SortKey: key, SortDesc: desc, Value: f.SortValue(key), ID: f.ID}
+}
+
+func (c FlightCursor) Encode() string {
+    data, _ := json.Marshal(c)
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Значение курсора для ключей-времени
+func (c FlightCursor) Time() (time.Time, error) {
+    return time.Parse(sortTimeLayout, c.Value)
+}
+
+// Разбирает курсор и проверяет, что он выдан для той же сортировки
+func DecodeFlightCursor(value, key string, desc bool) (*FlightCursor, error) {
+    data, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(value))
+    if err != nil {
+        return nil, ErrInvalidCursor
+    }
+    
+    var c FlightCursor
+    if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+        return nil, ErrInvalidCursor
+    }
+    if c.SortKey != key || c.SortDesc != desc {
+        return nil, ErrInvalidCursor
+    }
+    if IsTimeSortKey(key) {
+        if _, err := c.Time(); err != nil {
+            return nil, ErrInvalidCursor
+        }
+    }
+    return &c, nil
+}
+
+// Номер рейса для поиска: без пробелов, в верхнем регистре ("su100" найдет "SU 100")
+func NormalizeFlightSearch(s string) string {
+    return strings.ToUpper(strings.Join(strings.Fields(s), ""))
+}
+
+// Подходит ли рейс под фильтр (без учета курсора и лимита)
+func (ff FlightFilter) Match(f Flight) bool {
+    switch ff.Archived {
+    case ArchivedOnly:
+        if f.ArchivedAt == nil {
+            return false
+        }
+    case ArchivedInclude:
+    default:
+        if f.ArchivedAt != nil {
+            return false
+        }
+    }
+    if ff.Origin != "" && f.From != ff.Origin {
+        return false
+    }
+    if ff.Destination != "" && f.To != ff.Destination {
+        return false
+    }
+    if ff.ScheduledFrom != nil && f.Scheduled.Before(*ff.ScheduledFrom) {
+        return false
+    }
+    if ff.ScheduledTo != nil && !f.Scheduled.Before(*ff.ScheduledTo) {
+        return false
+    }
+    if ff.Airline != "" && f.Airline != ff.Airline {
+        return false
+    }
+    if len(ff.Statuses) > 0 && !containsString(ff.Statuses, f.Status) {
+        return false
+    }
+    if ff.Terminal != "" && !strings.EqualFold(f.Terminal, ff.Terminal) {
+        return false
+    }
+    if ff.Gate != "" && !strings.EqualFold(f.Gate, ff.Gate) {
+        return false
+    }
+    if ff.Search != "" {
+        search := NormalizeFlightSearch(ff.Search)
+        found := strings.Contains(NormalizeFlightSearch(f.FlightNumber), search) ||
+            containsString(ff.SearchAirports, f.From) ||
+            containsString(ff.SearchAirports, f.To)
+        for _, c := range f.Codeshares {
+            found = found || strings.Contains(NormalizeFlightSearch(c.FlightNumber), search)
+        }
+        if !found {
+            return false
+        }
+    }
+    return true
+}
+
+// Идет ли рейс после курсора при сортировке курсора
+func (c FlightCursor) Before(f Flight) bool {
+    value := f.SortValue(c.SortKey)
+    cmp := strings.Compare(c.Value, value)
+    if cmp == 0 {
+        cmp = strings.Compare(c.ID, f.ID)
+    }
+    if c.SortDesc {
+        return cmp > 0
+    }
+    return cmp < 0
+}
+
+func containsString(list []string, s string) bool {
+    for _, item := range list {
+        if item == s {
+            return true
+        }
+    }
+    return false
+}
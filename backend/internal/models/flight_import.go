@@ -0,0 +1,46 @@
+package models
+
+// Поля рейса, которые принимает массовый импорт (в порядке столбцов выгрузки)
+var FlightImportFields = []string{
+    "flightNumber", "airline", "from", "to",
+    "scheduled", "scheduledArrival", "actual",
+    "estimatedOffBlock", "estimatedTakeoff", "estimatedLanding", "estimatedInBlock",
+    "actualOffBlock", "actualTakeoff", "actualLanding", "actualInBlock", "delayCodes",
+    "terminal", "gate", "status", "delayReason", "aircraft", "codeshares",
+}
+
+// Обязательные поля строки импорта
+var FlightImportRequired = []string{"flightNumber", "airline", "from", "to", "scheduled"}
+
+// Строка импорта: то же, что при создании рейса (коммерческие номера —
+// через запятую: "SU 1234, AF 567"), плюс времена этапов, статус и задержка.
+// Actual — прежнее имя estimatedOffBlock
+type FlightImportRow struct {
+    FlightRequest
+    Actual      string `json:"actual"`
+    Status      string `json:"status"`
+    DelayReason string `json:"delayReason"`
+    // Времена этапов по JSON-именам полей рейса (estimatedOffBlock и т. д.)
+    StageTimes map[string]string `json:"stageTimes,omitempty"`
+    // Коды задержки: "93/25 81/10"
+    DelayCodes string `json:"delayCodes"`
+}
+
+// Ошибка в строке импорта. Row — номер строки в файле
+// (для CSV с учетом заголовка, для JSON — номер элемента массива с 1)
+type FlightImportError struct {
+    Row     int    `json:"row"`
+    Field   string `json:"field,omitempty"`
+    Error   string `json:"error"`
+    Message string `json:"message"`
+}
+
+// Результат импорта. При любой ошибке не создается ни один рейс
+type FlightImportReport struct {
+    Format  string              `json:"format"`
+    DryRun  bool                `json:"dryRun"`
+    Rows    int                 `json:"rows"`
+    Created int                 `json:"created"`
+    Errors  []FlightImportError `json:"errors"`
+    Flights []Flight            `json:"flights,omitempty"`
+}
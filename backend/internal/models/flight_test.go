@@ -0,0 +1,92 @@
+package models
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestFlightStatusCanTransitionTo(t *testing.T) {
+    tests := []struct {
+        from FlightStatus
+        to   FlightStatus
+        want bool
+    }{
+        {StatusScheduled, StatusCheckIn, true},
+        {StatusScheduled, StatusBoarding, true},
+        {StatusScheduled, StatusDeparted, false},
+        {StatusCheckIn, StatusScheduled, false},
+        {StatusDelayed, StatusScheduled, true},
+        {StatusBoarding, StatusDeparted, true},
+        {StatusGateClosed, StatusBoarding, false},
+        {StatusDeparted, StatusCancelled, false},
+        {StatusDeparted, StatusArrived, true},
+        {StatusLanded, StatusArrived, true},
+        {StatusArrived, StatusLanded, false},
+        {StatusCancelled, StatusScheduled, false},
+        // Повторная установка того же статуса разрешена
+        {StatusBoarding, StatusBoarding, true},
+        {StatusArrived, StatusArrived, true},
+        // Старые произвольные статусы переводятся в любой корректный
+        {"on time", StatusDeparted, true},
+        {StatusScheduled, "on time", false},
+        {"", "", false},
+    }
+    
+    for _, tt := range tests {
+        if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+            t.Errorf("%q.CanTransitionTo(%q) = %v, want %v", tt.from, tt.to, got, tt.want)
+        }
+    }
+}
+
+func TestFlightStatusTransitionsCoverAllStatuses(t *testing.T) {
+    for _, status := range AllFlightStatuses() {
+        if !status.Valid() {
+            t.Errorf("status %s has no transitions entry", status)
+        }
+        for _, next := range status.AllowedTransitions() {
+            if !next.Valid() {
+                t.Errorf("%s -> %s: unknown target status", status, next)
+            }
+        }
+        // Переходы только вперед по жизненному циклу, кроме delayed
+        stage, ok := status.Stage()
+        if !ok || status == StatusDelayed {
+            continue
+        }
+        for _, next := range status.AllowedTransitions() {
+            if nextStage, ok := next.Stage(); ok && next != StatusDelayed && nextStage <= stage {
+                t.Errorf("%s -> %s goes backwards", status, next)
+            }
+        }
+    }
+    
+    if len(StatusArrived.AllowedTransitions()) != 0 || len(StatusCancelled.AllowedTransitions()) != 0 {
+        t.Error("arrived and cancelled must be final")
+    }
+}
+
+func TestValidateStatusTransition(t *testing.T) {
+    if err := ValidateStatusTransition(StatusBoarding, StatusFinalCall); err != nil {
+        t.Fatalf("boarding -> final_call: %v", err)
+    }
+    
+    err := ValidateStatusTransition(StatusDeparted, StatusBoarding)
+    var transitionErr *StatusTransitionError
+    if !errors.As(err, &transitionErr) {
+        t.Fatalf("departed -> boarding: err = %v, want *StatusTransitionError", err)
+    }
+    if transitionErr.From != StatusDeparted || transitionErr.To != StatusBoarding {
+        t.Errorf("error = %+v", transitionErr)
+    }
+    want := []FlightStatus{StatusLanded, StatusArrived}
+    if len(transitionErr.Allowed) != len(want) || transitionErr.Allowed[0] != want[0] || transitionErr.Allowed[1] != want[1] {
+        t.Errorf("Allowed = %v, want %v", transitionErr.Allowed, want)
+    }
+    
+    // Изменение копии не должно затрагивать таблицу переходов
+    transitionErr.Allowed[0] = StatusCancelled
+    if StatusDeparted.AllowedTransitions()[0] != StatusLanded {
+        t.Error("AllowedTransitions returned the shared slice")
+    }
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+    "time"
+)
+
+const (
+    LockoutByUsername = "user"
+    LockoutByIP       = "ip"
+)
+
+// Счетчик неудачных входов для имени пользователя или IP
+type LoginLockout struct {
+    Kind          string     `json:"kind" db:"kind"`
+    Subject       string     `json:"subject" db:"subject"`
+    Failures      int        `json:"failures" db:"failures"`
+    LastFailureAt time.Time  `json:"lastFailureAt" db:"last_failure_at"`
+    LockedUntil   *time.Time `json:"lockedUntil" db:"locked_until"`
+}
@@ -0,0 +1,301 @@
+package models
+
+import (
+    "fmt"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Аэропорт. Ключ — код IATA
+type Airport struct {
+    IATACode  string    `json:"iataCode" db:"iata_code"`
+    ICAOCode  string    `json:"icaoCode,omitempty" db:"icao_code"`
+    Name      string    `json:"name" db:"name"`
+    City      string    `json:"city" db:"city"`
+    Country   string    `json:"country" db:"country"` // ISO 3166-1 alpha-2
+    Timezone  string    `json:"timezone" db:"timezone"`
+    UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Авиакомпания. Ключ — код IATA
+type Airline struct {
+    IATACode  string    `json:"iataCode" db:"iata_code"`
+    ICAOCode  string    `json:"icaoCode,omitempty" db:"icao_code"`
+    Name      string    `json:"name" db:"name"`
+    Country   string    `json:"country,omitempty" db:"country"`
+    LogoURL   string    `json:"logoUrl,omitempty" db:"logo_url"`
+    UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Тип воздушного судна. Ключ — код ICAO (A320, B738)
+type AircraftType struct {
+    ICAOCode     string    `json:"icaoCode" db:"icao_code"`
+    IATACode     string    `json:"iataCode,omitempty" db:"iata_code"`
+    Name         string    `json:"name" db:"name"`
+    Manufacturer string    `json:"manufacturer" db:"manufacturer"`
+    UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Поля рейса из справочников для табло
+type FlightDisplay struct {
+    AirlineName    string `json:"airlineName,omitempty" db:"-"`
+    AirlineLogoURL string `json:"airlineLogoUrl,omitempty" db:"-"`
+    FromCity       string `json:"fromCity,omitempty" db:"-"`
+    ToCity         string `json:"toCity,omitempty" db:"-"`
+    AircraftName   string `json:"aircraftName,omitempty" db:"-"`
+    // Время рейса по местным часам аэропортов
+    Local *FlightLocalTimes `json:"local,omitempty" db:"-"`
+}
+
+// Время вылета — в поясе аэропорта вылета, прилета — аэропорта прилета.
+// RFC3339 со смещением пояса: 2024-03-20T16:20:00+03:00
+type FlightLocalTimes struct {
+    FromTimezone     string `json:"fromTimezone"`
+    ToTimezone       string `json:"toTimezone"`
+    Scheduled        string `json:"scheduled"`
+    Actual           string `json:"actual"`
+    ScheduledArrival string `json:"scheduledArrival,omitempty"`
+}
+
+var (
+    airportIATAPattern  = regexp.MustCompile(`^[A-Z]{3}$`)
+    airportICAOPattern  = regexp.MustCompile(`^[A-Z]{4}$`)
+    airlineIATAPattern  = regexp.MustCompile(`^[A-Z0-9]{2}$`)
+    airlineICAOPattern  = regexp.MustCompile(`^[A-Z]{3}$`)
+    aircraftICAOPattern = regexp.MustCompile(`^[A-Z0-9]{2,4}$`)
+    aircraftIATAPattern = regexp.MustCompile(`^[A-Z0-9]{3}$`)
+    countryPattern      = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// Ошибка в записи справочника
+type ReferenceValidationError struct {
+    Field   string `json:"field"`
+    Message string `json:"message"`
+}
+
+func (e *ReferenceValidationError) Error() string {
+    return e.Field + ": " + e.Message
+}
+
+func invalid(field, format string, args ...interface{}) error {
+    return &ReferenceValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+func NormalizeCode(code string) string {
+    return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Приводит коды к верхнему регистру и проверяет запись
+func (a *Airport) Normalize() error {
+    a.IATACode = NormalizeCode(a.IATACode)
+    a.ICAOCode = NormalizeCode(a.ICAOCode)
+    a.Country = NormalizeCode(a.Country)
+    a.Name = strings.TrimSpace(a.Name)
+    a.City = strings.TrimSpace(a.City)
+    a.Timezone = strings.TrimSpace(a.Timezone)
+    
+    if !airportIATAPattern.MatchString(a.IATACode) {
+        return invalid("iataCode", "must be 3 letters")
+    }
+    if a.ICAOCode != "" && !airportICAOPattern.MatchString(a.ICAOCode) {
+        return invalid("icaoCode", "must be 4 letters")
+    }
+    if a.Name == "" {
+        return invalid("name", "is required")
+    }
+    if a.City == "" {
+        return invalid("city", "is required")
+    }
+    if a.Country != "" && !countryPattern.MatchString(a.Country) {
+        return invalid("country", "must be an ISO 3166-1 alpha-2 code")
+    }
+    if _, err := time.LoadLocation(a.Timezone); err != nil || a.Timezone == "" {
+        return invalid("timezone", "unknown IANA timezone %q", a.Timezone)
+    }
+    return nil
+}
+
+func (a *Airline) Normalize() error {
+    a.IATACode = NormalizeCode(a.IATACode)
+    a.ICAOCode = NormalizeCode(a.ICAOCode)
+    a.Country = NormalizeCode(a.Country)
+    a.Name = strings.TrimSpace(a.Name)
+    a.LogoURL = strings.TrimSpace(a.LogoURL)
+    
+    if !airlineIATAPattern.MatchString(a.IATACode) {
+        return invalid("iataCode", "must be 2 letters or digits")
+    }
+    if a.ICAOCode != "" && !airlineICAOPattern.MatchString(a.ICAOCode) {
+        return invalid("icaoCode", "must be 3 letters")
+    }
+    if a.Name == "" {
+        return invalid("name", "is required")
+    }
+    if a.Country != "" && !countryPattern.MatchString(a.Country) {
+        return invalid("country", "must be an ISO 3166-1 alpha-2 code")
+    }
+    if a.LogoURL != "" && !strings.HasPrefix(a.LogoURL, "https://") && !strings.HasPrefix(a.LogoURL, "/") {
+        return invalid("logoUrl", "must be an https:// or site-relative URL")
+    }
+    return nil
+}
+
+func (t *AircraftType) Normalize() error {
+    t.ICAOCode = NormalizeCode(t.ICAOCode)
+    t.IATACode = NormalizeCode(t.IATACode)
+    t.Name = strings.TrimSpace(t.Name)
+    t.Manufacturer = strings.TrimSpace(t.Manufacturer)
+    
+    if !aircraftICAOPattern.MatchString(t.ICAOCode) {
+        return invalid("icaoCode", "must be 2-4 letters or digits")
+    }
+    if t.IATACode != "" && !aircraftIATAPattern.MatchString(t.IATACode) {
+        return invalid("iataCode", "must be 3 letters or digits")
+    }
+    if t.Name == "" {
+        return invalid("name", "is required")
+    }
+    return nil
+}
+
+// Снимок справочников с поиском по любому коду
+type ReferenceData struct {
+    airports map[string]Airport
+    airlines map[string]Airline
+    aircraft map[string]AircraftType
+    // Часовые пояса аэропортов по коду IATA
+    zones map[string]*time.Location
+}
+
+func NewReferenceData(airports []Airport, airlines []Airline, aircraft []AircraftType) *ReferenceData {
+    d := &ReferenceData{
+        airports: make(map[string]Airport),
+        airlines: make(map[string]Airline),
+        aircraft: make(map[string]AircraftType),
+        zones:    make(map[string]*time.Location),
+    }
+    
+    // Сначала второстепенные ключи, чтобы основной код всегда побеждал
+    for _, a := range airports {
+        if a.ICAOCode != "" {
+            d.airports[a.ICAOCode] = a
+        }
+    }
+    for _, a := range airports {
+        d.airports[a.IATACode] = a
+        if loc, err := time.LoadLocation(a.Timezone); err == nil {
+            d.zones[a.IATACode] = loc
+        }
+    }
+    
+    for _, a := range airlines {
+        d.airlines[strings.ToLower(a.Name)] = a
+        if a.ICAOCode != "" {
+            d.airlines[a.ICAOCode] = a
+        }
+    }
+    for _, a := range airlines {
+        d.airlines[a.IATACode] = a
+    }
+    
+    for _, t := range aircraft {
+        if t.IATACode != "" {
+            d.aircraft[t.IATACode] = t
+        }
+    }
+    for _, t := range aircraft {
+        d.aircraft[t.ICAOCode] = t
+    }
+    
+    return d
+}
+
+// Аэропорт по коду IATA или ICAO
+func (d *ReferenceData) Airport(code string) (Airport, bool) {
+    a, ok := d.airports[NormalizeCode(code)]
+    return a, ok
+}
+
+// Часовой пояс аэропорта; для неизвестного аэропорта — UTC и false
+func (d *ReferenceData) Location(airportCode string) (*time.Location, bool) {
+    if a, ok := d.Airport(airportCode); ok {
+        if loc, ok := d.zones[a.IATACode]; ok {
+            return loc, true
+        }
+    }
+    return time.UTC, false
+}
+
+// Коды IATA аэропортов, у которых код совпадает с query,
+// а город или название содержат его (без учета регистра)
+func (d *ReferenceData) FindAirports(query string) []string {
+    query = strings.ToLower(strings.TrimSpace(query))
+    if query == "" {
+        return nil
+    }
+    
+    var codes []string
+    for key, a := range d.airports {
+        if key != a.IATACode {
+            continue
+        }
+        if strings.ToLower(a.IATACode) == query || strings.ToLower(a.ICAOCode) == query ||
+            strings.Contains(strings.ToLower(a.City), query) || strings.Contains(strings.ToLower(a.Name), query) {
+            codes = append(codes, a.IATACode)
+        }
+    }
+    sort.Strings(codes)
+    return codes
+}
+
+// Авиакомпания по коду IATA, ICAO или точному названию
+func (d *ReferenceData) Airline(codeOrName string) (Airline, bool) {
+    if a, ok := d.airlines[NormalizeCode(codeOrName)]; ok {
+        return a, true
+    }
+    a, ok := d.airlines[strings.ToLower(strings.TrimSpace(codeOrName))]
+    return a, ok
+}
+
+// Тип ВС по коду ICAO или IATA
+func (d *ReferenceData) Aircraft(code string) (AircraftType, bool) {
+    t, ok := d.aircraft[NormalizeCode(code)]
+    return t, ok
+}
+
+// Заполняет названия из справочников
+func (d *ReferenceData) Enrich(f Flight) Flight {
+    f.FlightDisplay = FlightDisplay{}
+    if a, ok := d.Airline(f.Airline); ok {
+        f.AirlineName = a.Name
+        f.AirlineLogoURL = a.LogoURL
+    }
+    if a, ok := d.Airport(f.From); ok {
+        f.FromCity = a.City
+    }
+    if a, ok := d.Airport(f.To); ok {
+        f.ToCity = a.City
+    }
+    
+    fromZone, fromOK := d.Location(f.From)
+    toZone, toOK := d.Location(f.To)
+    if fromOK && toOK {
+        f.Local = &FlightLocalTimes{
+            FromTimezone: fromZone.String(),
+            ToTimezone:   toZone.String(),
+            Scheduled:    f.Scheduled.In(fromZone).Format(time.RFC3339),
+            Actual:       f.Actual.In(fromZone).Format(time.RFC3339),
+        }
+        if f.ScheduledArrival != nil {
+            f.Local.ScheduledArrival = f.ScheduledArrival.In(toZone).Format(time.RFC3339)
+        }
+    }
+    if f.Aircraft != "" {
+        if t, ok := d.Aircraft(f.Aircraft); ok {
+            f.AircraftName = t.Name
+        }
+    }
+    return f
+}
@@ -0,0 +1,74 @@
+package models
+
+type Role string
+
+const (
+    RoleViewer    Role = "viewer"
+    RoleGateAgent Role = "gate_agent"
+    RoleOperator  Role = "operator"
+    RoleAdmin     Role = "admin"
+)
+
+type Permission string
+
+const (
+    PermFlightsRead     Permission = "flights:read" // чтение, если табло закрыто от анонимов
+    PermFlightsCreate   Permission = "flights:create"
+    PermFlightsUpdate   Permission = "flights:update"      // любые поля рейса
+    PermFlightsGate     Permission = "flights:update_gate" // только выход и посадка
+    PermFlightsDelete   Permission = "flights:delete"
+    PermFlightsHistory  Permission = "flights:history"
+    PermUsersManage     Permission = "users:manage"
+    PermAPIKeysManage   Permission = "api_keys:manage"
+    PermReferenceManage Permission = "reference:manage" // аэропорты, авиакомпании, типы ВС
+    PermSchedulesManage Permission = "schedules:manage" // сезонные расписания
+)
+
+var rolePermissions = map[Role][]Permission{
+    RoleViewer:    {PermFlightsRead, PermFlightsHistory},
+    RoleGateAgent: {PermFlightsRead, PermFlightsGate, PermFlightsHistory},
+    RoleOperator: {
+        PermFlightsRead, PermFlightsCreate, PermFlightsUpdate, PermFlightsGate, PermFlightsHistory,
+        PermSchedulesManage,
+    },
+    RoleAdmin: {
+        PermFlightsRead, PermFlightsCreate, PermFlightsUpdate, PermFlightsGate, PermFlightsDelete,
+        PermFlightsHistory, PermSchedulesManage, PermUsersManage, PermAPIKeysManage, PermReferenceManage,
+    },
+}
+
+// Поля, которые может менять агент на выходе, и допустимые для него статусы
+var (
+    GateAgentFields   = []string{"gate", "status"}
+    GateAgentStatuses = []FlightStatus{StatusBoarding, StatusFinalCall, StatusGateClosed}
+)
+
+func (r Role) Valid() bool {
+    _, ok := rolePermissions[r]
+    return ok
+}
+
+func (r Role) Permissions() []Permission {
+    return append([]Permission{}, rolePermissions[r]...)
+}
+
+func (r Role) Can(perm Permission) bool {
+    for _, p := range rolePermissions[r] {
+        if p == perm {
+            return true
+        }
+    }
+    return false
+}
+
+func AllRoles() []Role {
+    return []Role{RoleViewer, RoleGateAgent, RoleOperator, RoleAdmin}
+}
+
+// Для запросов с ключом API права определяются ключом, а не ролью
+func (u *User) Can(perm Permission) bool {
+    if u.APIKey != nil {
+        return u.APIKey.Can(perm)
+    }
+    return Role(u.Role).Can(perm)
+}
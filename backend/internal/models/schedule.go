@@ -0,0 +1,215 @@
+package models
+
+import (
+    "fmt"
+    "strings"
+    "time"
+)
+
+const (
+    DateLayout  = "2006-01-02"
+    ClockLayout = "15:04"
+)
+
+// Дни недели рейса битовой маской: пн = 1, вт = 2, ср = 4 ... вс = 64
+type Weekdays int
+
+const AllWeekdays Weekdays = 127
+
+func (d Weekdays) Valid() bool {
+    return d >= 1 && d <= AllWeekdays
+}
+
+func (d Weekdays) Has(day time.Weekday) bool {
+    // time.Weekday начинается с воскресенья, маска — с понедельника
+    return d&(1<<((int(day)+6)%7)) != 0
+}
+
+// Маска в виде SSIM: "1.3.5.7" — номера дней или точки
+func (d Weekdays) String() string {
+    var b strings.Builder
+    for i := 0; i < 7; i++ {
+        if d&(1<<i) != 0 {
+            b.WriteByte(byte('1' + i))
+        } else {
+            b.WriteByte('.')
+        }
+    }
+    return b.String()
+}
+
+// Сезонное расписание: по нему генератор создает рейсы на каждый день
+type FlightSchedule struct {
+    ID           string   `json:"id" db:"id"`
+    FlightNumber string   `json:"flightNumber" db:"flight_number"`
+    Airline      string   `json:"airline" db:"airline"`
+    From         string   `json:"from" db:"origin"`
+    To           string   `json:"to" db:"destination"`
+    Aircraft     string   `json:"aircraft,omitempty" db:"aircraft_type"`
+    DaysOfWeek   Weekdays `json:"daysOfWeek" db:"days_of_week"`
+    // Период действия (включительно), даты по местному времени вылета
+    ValidFrom string `json:"validFrom" db:"valid_from"`
+    ValidTo   string `json:"validTo" db:"valid_to"`
+    // Местное время вылета и прилета (15:04); прилет — через arrivalDayOffset суток
+    DepartureTime    string `json:"departureTime" db:"departure_time"`
+    ArrivalTime      string `json:"arrivalTime,omitempty" db:"arrival_time"`
+    ArrivalDayOffset int    `json:"arrivalDayOffset,omitempty" db:"arrival_day_offset"`
+    Terminal         string `json:"terminal" db:"terminal"`
+    Gate             string `json:"gate" db:"gate"`
+    // Источник импорта (например, участок SSIM) — для повторного импорта без дублей
+    SourceKey string    `json:"sourceKey,omitempty" db:"source_key"`
+    CreatedAt time.Time `json:"createdAt" db:"created_at"`
+    UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Ошибка в расписании
+type ScheduleValidationError struct {
+    Field   string `json:"field"`
+    Message string `json:"message"`
+}
+
+func (e *ScheduleValidationError) Error() string {
+    return e.Field + ": " + e.Message
+}
+
+// Приводит поля к каноническому виду и проверяет расписание
+// (коды по справочникам проверяет хендлер)
+func (s *FlightSchedule) Normalize() error {
+    s.FlightNumber = NormalizeFlightNumber(s.FlightNumber)
+    s.ValidFrom = strings.TrimSpace(s.ValidFrom)
+    s.ValidTo = strings.TrimSpace(s.ValidTo)
+    s.DepartureTime = strings.TrimSpace(s.DepartureTime)
+    s.ArrivalTime = strings.TrimSpace(s.ArrivalTime)
+    s.Terminal = strings.TrimSpace(s.Terminal)
+    s.Gate = strings.TrimSpace(s.Gate)
+    
+    if s.FlightNumber == "" {
+        return &ScheduleValidationError{Field: "flightNumber", Message: "is required"}
+    }
+    if !s.DaysOfWeek.Valid() {
+        return &ScheduleValidationError{Field: "daysOfWeek", Message: "must be a bitmask from 1 (Mon) to 127 (every day)"}
+    }
+    
+    from, err := time.Parse(DateLayout, s.ValidFrom)
+    if err != nil {
+        return &ScheduleValidationError{Field: "validFrom", Message: "must be a date in 2006-01-02 format"}
+    }
+    to, err := time.Parse(DateLayout, s.ValidTo)
+    if err != nil {
+        return &ScheduleValidationError{Field: "validTo", Message: "must be a date in 2006-01-02 format"}
+    }
+    if to.Before(from) {
+        return &ScheduleValidationError{Field: "validTo", Message: "must not be before validFrom"}
+    }
+    
+    if _, err := time.Parse(ClockLayout, s.DepartureTime); err != nil {
+        return &ScheduleValidationError{Field: "departureTime", Message: "must be a local time in 15:04 format"}
+    }
+    if s.ArrivalTime != "" {
+        if _, err := time.Parse(ClockLayout, s.ArrivalTime); err != nil {
+            return &ScheduleValidationError{Field: "arrivalTime", Message: "must be a local time in 15:04 format"}
+        }
+    }
+    if s.ArrivalDayOffset < 0 || s.ArrivalDayOffset > 2 {
+        return &ScheduleValidationError{Field: "arrivalDayOffset", Message: "must be between 0 and 2"}
+    }
+    return nil
+}
+
+// Совпадают ли расписания по всем полям, из которых создаются рейсы
+func (s *FlightSchedule) SameAs(other *FlightSchedule) bool {
+    return s.FlightNumber == other.FlightNumber &&
+        s.Airline == other.Airline &&
+        s.From == other.From &&
+        s.To == other.To &&
+        s.Aircraft == other.Aircraft &&
+        s.DaysOfWeek == other.DaysOfWeek &&
+        s.ValidFrom == other.ValidFrom &&
+        s.ValidTo == other.ValidTo &&
+        s.DepartureTime == other.DepartureTime &&
+        s.ArrivalTime == other.ArrivalTime &&
+        s.ArrivalDayOffset == other.ArrivalDayOffset &&
+        s.Terminal == other.Terminal &&
+        s.Gate == other.Gate
+}
+
+// Даты выполнения рейса в промежутке [from, to] с учетом периода и дней недели
+func (s *FlightSchedule) Dates(from, to time.Time) []time.Time {
+    validFrom, err := time.Parse(DateLayout, s.ValidFrom)
+    if err != nil {
+        return nil
+    }
+    validTo, err := time.Parse(DateLayout, s.ValidTo)
+    if err != nil {
+        return nil
+    }
+    
+    from = civilDate(from)
+    to = civilDate(to)
+    if from.Before(validFrom) {
+        from = validFrom
+    }
+    if to.After(validTo) {
+        to = validTo
+    }
+    
+    var dates []time.Time
+    for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+        if s.DaysOfWeek.Has(d.Weekday()) {
+            dates = append(dates, d)
+        }
+    }
+    return dates
+}
+
+// Рейс по расписанию на дату date (местную дату вылета)
+func (s *FlightSchedule) Instance(date time.Time, fromZone, toZone *time.Location) (Flight, error) {
+    departure, err := time.Parse(ClockLayout, s.DepartureTime)
+    if err != nil {
+        return Flight{}, fmt.Errorf("invalid departure time %q", s.DepartureTime)
+    }
+    
+    y, m, d := date.Date()
+    scheduled := time.Date(y, m, d, departure.Hour(), departure.Minute(), 0, 0, fromZone).UTC()
+    
+    flight := Flight{
+        FlightNumber: s.FlightNumber,
+        Airline:      s.Airline,
+        From:         s.From,
+        To:           s.To,
+        Aircraft:     s.Aircraft,
+        Scheduled:    scheduled,
+        Actual:       scheduled,
+        Terminal:     s.Terminal,
+        Gate:         s.Gate,
+        Status:       string(StatusScheduled),
+        ScheduleID:   s.ID,
+        ServiceDate:  date.Format(DateLayout),
+    }
+    
+    if s.ArrivalTime != "" {
+        arrival, err := time.Parse(ClockLayout, s.ArrivalTime)
+        if err != nil {
+            return Flight{}, fmt.Errorf("invalid arrival time %q", s.ArrivalTime)
+        }
+        t := time.Date(y, m, d+s.ArrivalDayOffset, arrival.Hour(), arrival.Minute(), 0, 0, toZone).UTC()
+        flight.ScheduledArrival = &t
+    }
+    
+    return flight, nil
+}
+
+// Дата без времени (полночь UTC) — так сравниваются даты расписаний
+func civilDate(t time.Time) time.Time {
+    y, m, d := t.Date()
+    return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Итог генерации рейсов по расписанию
+type ScheduleGenerateResult struct {
+    ScheduleID string `json:"scheduleId"`
+    Created    int    `json:"created"`
+    Updated    int    `json:"updated"`
+    Removed    int    `json:"removed"`
+    Kept       int    `json:"kept"` // лишние, но оставленные: нет права flights:delete
+}
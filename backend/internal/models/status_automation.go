@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Когда наступают этапы рейса относительно ожидаемого ухода со стоянки
+// (см. DepartureTime). Вылет — в момент ухода со стоянки, посадка —
+// по фактической посадке, иначе по ожидаемому прилету (см. ArrivalTime)
+type StatusTimings struct {
+    CheckInOpen time.Duration // регистрация открывается за столько до вылета
+    Boarding    time.Duration
+    FinalCall   time.Duration
+    GateClosed  time.Duration
+    // Завершенный рейс уходит в архив через столько после завершения
+    ArchiveAfter time.Duration
+}
+
+// Статус, который рейс должен иметь в момент now, и время, когда начался
+// этот этап; false — ни один этап еще не наступил
+func (t StatusTimings) Target(f Flight, now time.Time) (FlightStatus, time.Time, bool) {
+    if f.ActualLanding != nil && !now.Before(*f.ActualLanding) {
+        return StatusLanded, *f.ActualLanding, true
+    }
+    if arrival, ok := f.ArrivalTime(); ok && !now.Before(arrival) {
+        return StatusLanded, arrival, true
+    }
+    
+    departure := f.DepartureTime()
+    stages := []struct {
+        status FlightStatus
+        at     time.Time
+    }{
+        {StatusDeparted, departure},
+        {StatusGateClosed, departure.Add(-t.GateClosed)},
+        {StatusFinalCall, departure.Add(-t.FinalCall)},
+        {StatusBoarding, departure.Add(-t.Boarding)},
+        {StatusCheckIn, departure.Add(-t.CheckInOpen)},
+    }
+    for _, s := range stages {
+        if !now.Before(s.at) {
+            return s.status, s.at, true
+        }
+    }
+    return "", time.Time{}, false
+}
+
+// Когда рейс завершился: посадка (или смена статуса, если прилет неизвестен),
+// для отмененного — не раньше вылета. Вылетевший рейс без известного
+// прилета приземлиться по времени не может, поэтому считается завершенным
+// в момент вылета. false — рейс не завершен
+func (t StatusTimings) CompletedAt(f Flight) (time.Time, bool) {
+    switch FlightStatus(f.Status) {
+    case StatusDeparted:
+        if _, ok := f.ArrivalTime(); !ok {
+            return f.DepartureTime(), true
+        }
+    case StatusLanded, StatusArrived:
+        if arrival, ok := f.ArrivalTime(); ok && arrival.Before(f.StatusUpdatedAt) {
+            return arrival, true
+        }
+        return f.StatusUpdatedAt, true
+    case StatusCancelled:
+        if departure := f.DepartureTime(); departure.After(f.StatusUpdatedAt) {
+            return departure, true
+        }
+        return f.StatusUpdatedAt, true
+    }
+    return time.Time{}, false
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+    "testing"
+    "time"
+)
+
+var testTimings = StatusTimings{
+    CheckInOpen:  3 * time.Hour,
+    Boarding:     40 * time.Minute,
+    FinalCall:    15 * time.Minute,
+    GateClosed:   10 * time.Minute,
+    ArchiveAfter: 2 * time.Hour,
+}
+
+func at(hour, minute int) time.Time {
+    return time.Date(2026, 11, 2, hour, minute, 0, 0, time.UTC)
+}
+
+func timePtr(t time.Time) *time.Time {
+    return &t
+}
+
+func TestStatusTimingsTarget(t *testing.T) {
+    scheduled := at(12, 0)
+    arrival := at(15, 0)
+    
+    tests := []struct {
+        name   string
+        flight Flight
+        now    time.Time
+        want   FlightStatus
+        since  time.Time
+        ok     bool
+    }{
+        {name: "too early", flight: Flight{Scheduled: scheduled}, now: at(8, 59)},
+        {name: "check-in", flight: Flight{Scheduled: scheduled}, now: at(9, 0), want: StatusCheckIn, since: at(9, 0), ok: true},
+        {name: "boarding", flight: Flight{Scheduled: scheduled}, now: at(11, 30), want: StatusBoarding, since: at(11, 20), ok: true},
+        {name: "final call", flight: Flight{Scheduled: scheduled}, now: at(11, 45), want: StatusFinalCall, since: at(11, 45), ok: true},
+        {name: "gate closed", flight: Flight{Scheduled: scheduled}, now: at(11, 55), want: StatusGateClosed, since: at(11, 50), ok: true},
+        {name: "departed", flight: Flight{Scheduled: scheduled}, now: at(12, 0), want: StatusDeparted, since: at(12, 0), ok: true},
+        {
+            name:   "departure estimate moves the stages",
+            flight: Flight{Scheduled: scheduled, EstimatedOffBlock: timePtr(at(13, 0))},
+            now:    at(12, 30), want: StatusBoarding, since: at(12, 20), ok: true,
+        },
+        {
+            name:   "landed at scheduled arrival",
+            flight: Flight{Scheduled: scheduled, ScheduledArrival: &arrival},
+            now:    at(15, 0), want: StatusLanded, since: at(15, 0), ok: true,
+        },
+        {
+            name:   "scheduled arrival shifts with the delay",
+            flight: Flight{Scheduled: scheduled, ScheduledArrival: &arrival, EstimatedOffBlock: timePtr(at(13, 0))},
+            now:    at(15, 30), want: StatusDeparted, since: at(13, 0), ok: true,
+        },
+        {
+            name:   "actual landing wins",
+            flight: Flight{Scheduled: scheduled, ScheduledArrival: &arrival, ActualLanding: timePtr(at(14, 40))},
+            now:    at(14, 45), want: StatusLanded, since: at(14, 40), ok: true,
+        },
+        {
+            name:   "no arrival time never lands",
+            flight: Flight{Scheduled: scheduled},
+            now:    at(23, 0), want: StatusDeparted, since: at(12, 0), ok: true,
+        },
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, since, ok := testTimings.Target(tt.flight, tt.now)
+            if got != tt.want || ok != tt.ok || !since.Equal(tt.since) {
+                t.Errorf("Target() = %s, %s, %v, want %s, %s, %v", got, since, ok, tt.want, tt.since, tt.ok)
+            }
+        })
+    }
+}
+
+func TestStatusTimingsCompletedAt(t *testing.T) {
+    scheduled := at(12, 0)
+    arrival := at(15, 0)
+    
+    tests := []struct {
+        name   string
+        flight Flight
+        want   time.Time
+        ok     bool
+    }{
+        {name: "scheduled", flight: Flight{Status: string(StatusScheduled), Scheduled: scheduled}},
+        {
+            name:   "departed with known arrival",
+            flight: Flight{Status: string(StatusDeparted), Scheduled: scheduled, ScheduledArrival: &arrival},
+        },
+        {
+            name:   "departed without arrival",
+            flight: Flight{Status: string(StatusDeparted), Scheduled: scheduled, ActualOffBlock: timePtr(at(12, 10))},
+            want:   at(12, 10), ok: true,
+        },
+        {
+            name:   "landed on time",
+            flight: Flight{Status: string(StatusLanded), Scheduled: scheduled, ScheduledArrival: &arrival, StatusUpdatedAt: at(15, 5)},
+            want:   at(15, 0), ok: true,
+        },
+        {
+            name:   "landed before the expected arrival",
+            flight: Flight{Status: string(StatusLanded), Scheduled: scheduled, ScheduledArrival: &arrival, StatusUpdatedAt: at(14, 30)},
+            want:   at(14, 30), ok: true,
+        },
+        {
+            name:   "arrived without arrival time",
+            flight: Flight{Status: string(StatusArrived), Scheduled: scheduled, StatusUpdatedAt: at(16, 0)},
+            want:   at(16, 0), ok: true,
+        },
+        {
+            name:   "cancelled in advance",
+            flight: Flight{Status: string(StatusCancelled), Scheduled: scheduled, StatusUpdatedAt: at(8, 0)},
+            want:   at(12, 0), ok: true,
+        },
+        {
+            name:   "cancelled after departure time",
+            flight: Flight{Status: string(StatusCancelled), Scheduled: scheduled, StatusUpdatedAt: at(13, 0)},
+            want:   at(13, 0), ok: true,
+        },
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, ok := testTimings.CompletedAt(tt.flight)
+            if ok != tt.ok || !got.Equal(tt.want) {
+                t.Errorf("CompletedAt() = %s, %v, want %s, %v", got, ok, tt.want, tt.ok)
+            }
+        })
+    }
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+    "time"
+)
+
+// Текущий access-токен запроса (кладется в контекст как "token")
+type AccessToken struct {
+    JTI       string
+    ExpiresAt time.Time
+}
+
+// Отозванный access-токен: в списке до истечения срока действия
+type RevokedToken struct {
+    JTI       string    `json:"jti" db:"jti"`
+    ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
+}
+
+type RefreshRequest struct {
+    RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+type LogoutRequest struct {
+    RefreshToken string `json:"refreshToken"`
+}
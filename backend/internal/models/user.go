@@ -5,11 +5,18 @@ import (
 )
 
 type User struct {
-    ID        string    `json:"id" db:"id"`
-    Username  string    `json:"username" db:"username"`
-    Password  string    `json:"-" db:"password"`
-    Role      string    `json:"role" db:"role"`
-    CreatedAt time.Time `json:"createdAt" db:"created_at"`
+    ID                 string    `json:"id" db:"id"`
+    Username           string    `json:"username" db:"username"`
+    Password           string    `json:"-" db:"password"`
+    Role               string    `json:"role" db:"role"`
+    Disabled           bool      `json:"disabled" db:"disabled"`
+    MustChangePassword bool      `json:"mustChangePassword" db:"must_change_password"`
+    TwoFactorEnabled   bool      `json:"twoFactorEnabled" db:"totp_enabled"`
+    CreatedAt          time.Time `json:"createdAt" db:"created_at"`
+    UpdatedAt          time.Time `json:"updatedAt" db:"updated_at"`
+    
+    // Ключ, которым аутентифицирован запрос (nil для сотрудников с JWT)
+    APIKey *APIKey `json:"-" db:"-"`
 }
 
 type LoginRequest struct {
@@ -18,6 +25,66 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-    Token string `json:"token"`
-    User  User   `json:"user"`
-}
\ No newline at end of file
+    Token        string `json:"token"`
+    RefreshToken string `json:"refreshToken"`
+    ExpiresIn    int64  `json:"expiresIn"` // срок жизни access-токена в секундах
+    User         User   `json:"user"`
+}
+
+type CreateUserRequest struct {
+    Username string `json:"username" validate:"required"`
+    Password string `json:"password" validate:"required"`
+    Role     string `json:"role" validate:"required"`
+}
+
+type UpdateRoleRequest struct {
+    Role string `json:"role" validate:"required"`
+}
+
+type ResetPasswordRequest struct {
+    Password string `json:"password" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+    CurrentPassword string `json:"currentPassword" validate:"required"`
+    NewPassword     string `json:"newPassword" validate:"required"`
+}
+
+// Ответ на логин, если включен второй фактор: токен нужно
+// обменять на пару access/refresh вместе с кодом
+type TwoFactorChallenge struct {
+    TwoFactorRequired bool   `json:"twoFactorRequired"`
+    ChallengeToken    string `json:"challengeToken"`
+    ExpiresIn         int64  `json:"expiresIn"`
+}
+
+// Второй шаг логина: код из приложения или код восстановления
+type TwoFactorLoginRequest struct {
+    ChallengeToken string `json:"challengeToken" validate:"required"`
+    Code           string `json:"code"`
+    RecoveryCode   string `json:"recoveryCode"`
+}
+
+type TwoFactorSetupResponse struct {
+    Secret     string `json:"secret"`
+    OTPAuthURI string `json:"otpauthUri"`
+}
+
+type TwoFactorCodeRequest struct {
+    Code string `json:"code" validate:"required"`
+}
+
+type DisableTwoFactorRequest struct {
+    Password     string `json:"password" validate:"required"`
+    Code         string `json:"code"`
+    RecoveryCode string `json:"recoveryCode"`
+}
+
+type TwoFactorStatus struct {
+    Enabled           bool `json:"enabled"`
+    RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+type RecoveryCodesResponse struct {
+    RecoveryCodes []string `json:"recoveryCodes"`
+}
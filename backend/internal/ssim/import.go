@@ -0,0 +1,226 @@
+package ssim
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "strings"
+    "time"
+    "skyflow/internal/database"
+    "skyflow/internal/models"
+)
+
+// Во что превращаются участки из файла
+const (
+    // Сезонные расписания; рейсы на ближайшие дни создаст генератор
+    ModeSchedules = "schedules"
+    // Расписания и сразу все рейсы сезона
+    ModeFlights = "flights"
+)
+
+type Options struct {
+    Mode   string
+    DryRun bool // только проверить и показать, что изменится
+    // Если задано — участки других авиакомпаний отклоняются (ключ API авиакомпании)
+    Airline string
+    // Удалять рейсы, которых больше нет в расписании (право flights:delete)
+    RemoveFlights bool
+    // От чьего имени изменения рейсов пишутся в журнал
+    Author *models.FlightAuthor
+}
+
+// Что импорт сделал (или сделает) с участком
+type Entry struct {
+    Line     int                            `json:"line"`
+    Action   string                         `json:"action"` // create, update или unchanged
+    Schedule models.FlightSchedule          `json:"schedule"`
+    Flights  *models.ScheduleGenerateResult `json:"flights,omitempty"`
+}
+
+type Report struct {
+    Mode      string      `json:"mode"`
+    DryRun    bool        `json:"dryRun"`
+    Legs      int         `json:"legs"`
+    Created   int         `json:"created"`
+    Updated   int         `json:"updated"`
+    Unchanged int         `json:"unchanged"`
+    Entries   []Entry     `json:"entries"`
+    Errors    []LineError `json:"errors"`
+}
+
+// Импорт расписаний из файлов SSIM. Участки сопоставляются с ранее
+// импортированными по ключу источника, поэтому повторный импорт того же
+// файла ничего не меняет
+type Importer struct {
+    schedules *database.ScheduleRepository
+    generator *database.ScheduleGenerator
+    reference *database.ReferenceCache
+}
+
+func NewImporter(schedules *database.ScheduleRepository, generator *database.ScheduleGenerator, reference *database.ReferenceCache) *Importer {
+    return &Importer{schedules: schedules, generator: generator, reference: reference}
+}
+
+// Импортирует корректные участки; ошибочные строки попадают в отчет
+func (i *Importer) Import(ctx context.Context, r io.Reader, opts Options) (*Report, error) {
+    if opts.Mode == "" {
+        opts.Mode = ModeSchedules
+    }
+    if opts.Mode != ModeSchedules && opts.Mode != ModeFlights {
+        return nil, fmt.Errorf("unknown import mode %q", opts.Mode)
+    }
+    
+    legs, errs, err := Parse(r)
+    if err != nil {
+        return nil, err
+    }
+    
+    report := &Report{
+        Mode:    opts.Mode,
+        DryRun:  opts.DryRun,
+        Legs:    len(legs),
+        Entries: []Entry{},
+        Errors:  errs,
+    }
+    if report.Errors == nil {
+        report.Errors = []LineError{}
+    }
+    
+    data := i.reference.Data()
+    seen := make(map[string]int)
+    for _, leg := range legs {
+        schedule := leg.Schedule()
+        if lineErr := validate(data, &leg, &schedule); lineErr != nil {
+            report.Errors = append(report.Errors, *lineErr)
+            continue
+        }
+        if opts.Airline != "" && !strings.EqualFold(schedule.Airline, opts.Airline) {
+            report.Errors = append(report.Errors, LineError{
+                Line:    leg.Line,
+                Field:   "airline",
+                Message: "only flights of " + opts.Airline + " can be imported",
+            })
+            continue
+        }
+        if first, ok := seen[schedule.SourceKey]; ok {
+            report.Errors = append(report.Errors, LineError{
+                Line:    leg.Line,
+                Message: fmt.Sprintf("duplicate flight leg, already defined on line %d", first),
+            })
+            continue
+        }
+        seen[schedule.SourceKey] = leg.Line
+        
+        entry, err := i.apply(ctx, schedule, leg.Line, opts)
+        if err != nil {
+            return nil, err
+        }
+        
+        switch entry.Action {
+        case "create":
+            report.Created++
+        case "update":
+            report.Updated++
+        default:
+            report.Unchanged++
+        }
+        report.Entries = append(report.Entries, *entry)
+    }
+    
+    return report, nil
+}
+
+// Создает или обновляет расписание участка и генерирует рейсы
+func (i *Importer) apply(ctx context.Context, schedule models.FlightSchedule, line int, opts Options) (*Entry, error) {
+    existing, err := i.schedules.GetBySourceKey(ctx, schedule.SourceKey)
+    if err != nil {
+        return nil, err
+    }
+    
+    entry := &Entry{Line: line, Action: "create"}
+    if existing != nil {
+        // Выход в SSIM не передается — сохраняем назначенный вручную
+        schedule.ID = existing.ID
+        schedule.Gate = existing.Gate
+        schedule.CreatedAt = existing.CreatedAt
+        schedule.UpdatedAt = existing.UpdatedAt
+        entry.Action = "update"
+        if schedule.SameAs(existing) {
+            entry.Action = "unchanged"
+        }
+    }
+    
+    if !opts.DryRun {
+        switch entry.Action {
+        case "create":
+            err = i.schedules.Create(ctx, &schedule)
+        case "update":
+            err = i.schedules.Update(ctx, &schedule)
+        }
+        if err != nil {
+            return nil, err
+        }
+        
+        var result models.ScheduleGenerateResult
+        if opts.Mode == ModeFlights {
+            from, _ := time.Parse(models.DateLayout, schedule.ValidFrom)
+            to, _ := time.Parse(models.DateLayout, schedule.ValidTo)
+            result, err = i.generator.GenerateRange(ctx, &schedule, from, to, opts.RemoveFlights, opts.Author)
+        } else {
+            result, err = i.generator.Generate(ctx, &schedule, opts.RemoveFlights, opts.Author)
+        }
+        if err != nil {
+            return nil, err
+        }
+        entry.Flights = &result
+    }
+    
+    entry.Schedule = schedule
+    return entry, nil
+}
+
+// Проверяет участок и приводит коды к справочным
+func validate(data *models.ReferenceData, leg *Leg, schedule *models.FlightSchedule) *LineError {
+    lineError := func(name, format string, args ...interface{}) *LineError {
+        return &LineError{Line: leg.Line, Field: name, Message: fmt.Sprintf(format, args...)}
+    }
+    
+    if err := schedule.Normalize(); err != nil {
+        var invalid *models.ScheduleValidationError
+        if errors.As(err, &invalid) {
+            return lineError(invalid.Field, "%s", invalid.Message)
+        }
+        return lineError("", "%v", err)
+    }
+    
+    airline, ok := data.Airline(schedule.Airline)
+    if !ok {
+        return lineError("airline", "unknown airline %s", schedule.Airline)
+    }
+    schedule.Airline = airline.IATACode
+    schedule.FlightNumber = airline.IATACode + strings.TrimPrefix(schedule.FlightNumber, leg.Airline)
+    
+    from, ok := data.Airport(schedule.From)
+    if !ok {
+        return lineError("from", "unknown airport %s", schedule.From)
+    }
+    to, ok := data.Airport(schedule.To)
+    if !ok {
+        return lineError("to", "unknown airport %s", schedule.To)
+    }
+    if from.IATACode == to.IATACode {
+        return lineError("to", "origin and destination must differ")
+    }
+    schedule.From, schedule.To = from.IATACode, to.IATACode
+    
+    if schedule.Aircraft != "" {
+        aircraft, ok := data.Aircraft(schedule.Aircraft)
+        if !ok {
+            return lineError("aircraft", "unknown aircraft type %s", schedule.Aircraft)
+        }
+        schedule.Aircraft = aircraft.ICAOCode
+    }
+    
+    return nil
+}
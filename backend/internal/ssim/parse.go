@@ -0,0 +1,364 @@
+package ssim
+
+import (
+    "bufio"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+    "skyflow/internal/models"
+)
+
+// Длина записи SSIM (глава 7)
+const recordLength = 200
+
+// Ошибка в строке файла
+type LineError struct {
+    Line    int    `json:"line"`
+    Field   string `json:"field,omitempty"`
+    Message string `json:"message"`
+}
+
+func (e LineError) Error() string {
+    if e.Field == "" {
+        return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+    }
+    return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Message)
+}
+
+// Участок рейса из записи типа 3. Даты, дни недели и время уже
+// пересчитаны в местное время аэропортов, как в models.FlightSchedule
+type Leg struct {
+    Line         int
+    Season       string
+    Airline      string
+    FlightNumber string // номер в виде "TK 789"
+    // Идентификация участка в файле: номер, суффикс, вариант маршрута, номер участка
+    number      string
+    suffix      string
+    variation   string
+    sequence    string
+    ServiceType string
+    
+    From             string
+    To               string
+    ValidFrom        time.Time
+    ValidTo          time.Time
+    Days             models.Weekdays
+    DepartureTime    string
+    ArrivalTime      string
+    ArrivalDayOffset int
+    Terminal         string
+    Aircraft         string
+}
+
+// Ключ участка для идемпотентного повторного импорта
+func (l *Leg) SourceKey() string {
+    return fmt.Sprintf("ssim:%s:%s:%s%s:%s:%s", l.Season, l.Airline, l.number, l.suffix, l.variation, l.sequence)
+}
+
+// Расписание по участку (коды еще не проверены по справочникам)
+func (l *Leg) Schedule() models.FlightSchedule {
+    return models.FlightSchedule{
+        FlightNumber:     l.FlightNumber,
+        Airline:          l.Airline,
+        From:             l.From,
+        To:               l.To,
+        Aircraft:         l.Aircraft,
+        DaysOfWeek:       l.Days,
+        ValidFrom:        l.ValidFrom.Format(models.DateLayout),
+        ValidTo:          l.ValidTo.Format(models.DateLayout),
+        DepartureTime:    l.DepartureTime,
+        ArrivalTime:      l.ArrivalTime,
+        ArrivalDayOffset: l.ArrivalDayOffset,
+        Terminal:         l.Terminal,
+        SourceKey:        l.SourceKey(),
+    }
+}
+
+// Текущая запись типа 2 (авиакомпания): режим времени и период
+type carrier struct {
+    utc       bool
+    season    string
+    validFrom time.Time
+    validTo   time.Time
+}
+
+// Разбирает файл SSIM. Записи типов 1, 4, 5 и строки-заполнители
+// пропускаются; ошибочные записи типа 3 попадают в список ошибок,
+// остальные участки возвращаются
+func Parse(r io.Reader) ([]Leg, []LineError, error) {
+    var (
+        legs    []Leg
+        errs    []LineError
+        current *carrier
+    )
+    
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 0, 1024), 64*1024)
+    line := 0
+    for scanner.Scan() {
+        line++
+        record := strings.TrimRight(scanner.Text(), "\r")
+        if strings.TrimSpace(record) == "" {
+            continue
+        }
+        if len(record) < recordLength {
+            record += strings.Repeat(" ", recordLength-len(record))
+        }
+        
+        switch record[0] {
+        case '2':
+            c, err := parseCarrier(record)
+            if err != nil {
+                errs = append(errs, LineError{Line: line, Message: err.Error()})
+                current = nil
+                continue
+            }
+            current = c
+        
+        case '3':
+            if current == nil {
+                errs = append(errs, LineError{Line: line, Message: "flight leg record without a valid carrier (type 2) record"})
+                continue
+            }
+            leg, lineErr := parseLeg(record, current)
+            if lineErr != nil {
+                lineErr.Line = line
+                errs = append(errs, *lineErr)
+                continue
+            }
+            leg.Line = line
+            legs = append(legs, *leg)
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, nil, fmt.Errorf("failed to read SSIM file: %w", err)
+    }
+    
+    return legs, errs, nil
+}
+
+// Поле по позициям из спецификации (с единицы, включительно)
+func field(record string, from, to int) string {
+    return strings.TrimSpace(record[from-1 : to])
+}
+
+func parseCarrier(record string) (*carrier, error) {
+    c := &carrier{season: field(record, 11, 13)}
+    
+    switch record[1] {
+    case 'U':
+        c.utc = true
+    case 'L':
+    default:
+        return nil, fmt.Errorf("time mode must be U or L, got %q", record[1:2])
+    }
+    
+    var err error
+    if c.validFrom, err = parseDate(field(record, 15, 21)); err != nil {
+        return nil, fmt.Errorf("schedule validity from: %w", err)
+    }
+    if c.validTo, err = parseDate(field(record, 22, 28)); err != nil {
+        return nil, fmt.Errorf("schedule validity to: %w", err)
+    }
+    return c, nil
+}
+
+func parseLeg(record string, c *carrier) (*Leg, *LineError) {
+    fail := func(name, format string, args ...interface{}) (*Leg, *LineError) {
+        return nil, &LineError{Field: name, Message: fmt.Sprintf(format, args...)}
+    }
+    
+    leg := &Leg{
+        Season:      c.season,
+        Airline:     field(record, 3, 5),
+        number:      field(record, 6, 9),
+        suffix:      field(record, 2, 2),
+        variation:   field(record, 10, 11),
+        sequence:    field(record, 12, 13),
+        ServiceType: field(record, 14, 14),
+        From:        field(record, 37, 39),
+        To:          field(record, 55, 57),
+        Terminal:    field(record, 53, 54),
+        Aircraft:    field(record, 73, 75),
+    }
+    
+    number, err := strconv.Atoi(leg.number)
+    if leg.Airline == "" || err != nil || number <= 0 {
+        return fail("flightNumber", "invalid airline designator or flight number %q", field(record, 3, 9))
+    }
+    leg.FlightNumber = fmt.Sprintf("%s %d%s", leg.Airline, number, leg.suffix)
+    
+    periodFrom, err := parseDate(field(record, 15, 21))
+    if err != nil || periodFrom.IsZero() {
+        return fail("periodFrom", "invalid date %q", field(record, 15, 21))
+    }
+    periodTo, err := parseDate(field(record, 22, 28))
+    if err != nil {
+        return fail("periodTo", "invalid date %q", field(record, 22, 28))
+    }
+    // 00XXX00 — до конца периода из записи авиакомпании
+    if periodTo.IsZero() {
+        periodTo = c.validTo
+    }
+    if periodTo.IsZero() || periodTo.Before(periodFrom) {
+        return fail("periodTo", "period of operation has no valid end date")
+    }
+    
+    days, err := parseDays(record[28:35])
+    if err != nil {
+        return fail("daysOfOperation", "%v", err)
+    }
+    if rate := field(record, 36, 36); rate != "" && rate != "1" {
+        return fail("frequencyRate", "only weekly schedules are supported, got frequency rate %s", rate)
+    }
+    
+    departure, err := parseClock(field(record, 40, 43))
+    if err != nil {
+        return fail("departureTime", "%v", err)
+    }
+    departureOffset, err := parseOffset(field(record, 48, 52))
+    if err != nil {
+        return fail("departureVariation", "%v", err)
+    }
+    arrival, err := parseClock(field(record, 62, 65))
+    if err != nil {
+        return fail("arrivalTime", "%v", err)
+    }
+    arrivalOffset, err := parseOffset(field(record, 66, 70))
+    if err != nil {
+        return fail("arrivalVariation", "%v", err)
+    }
+    departureDays, err := parseDateVariation(record[192])
+    if err != nil {
+        return fail("dateVariation", "%v", err)
+    }
+    arrivalDays, err := parseDateVariation(record[193])
+    if err != nil {
+        return fail("dateVariation", "%v", err)
+    }
+    
+    // Первый день периода: момент вылета и прилета, затем местное время
+    localDeparture := localTime(periodFrom.AddDate(0, 0, departureDays), departure, departureOffset, c.utc)
+    localArrival := localTime(periodFrom.AddDate(0, 0, arrivalDays), arrival, arrivalOffset, c.utc)
+    
+    // Местная дата вылета может отличаться от даты периода (UTC или следующий участок)
+    shift := daysBetween(periodFrom, localDeparture)
+    leg.ValidFrom = periodFrom.AddDate(0, 0, shift)
+    leg.ValidTo = periodTo.AddDate(0, 0, shift)
+    leg.Days = rotate(days, shift)
+    leg.DepartureTime = localDeparture.Format(models.ClockLayout)
+    leg.ArrivalTime = localArrival.Format(models.ClockLayout)
+    leg.ArrivalDayOffset = daysBetween(localDeparture, localArrival)
+    
+    return leg, nil
+}
+
+// Дата вида 01NOV26; 00XXX00 — дата не задана (нулевое время)
+func parseDate(value string) (time.Time, error) {
+    if value == "00XXX00" {
+        return time.Time{}, nil
+    }
+    if len(value) != 7 {
+        return time.Time{}, fmt.Errorf("invalid date %q, expected DDMMMYY", value)
+    }
+    month := value[2:3] + strings.ToLower(value[3:5])
+    t, err := time.Parse("02Jan06", value[:2]+month+value[5:])
+    if err != nil {
+        return time.Time{}, fmt.Errorf("invalid date %q, expected DDMMMYY", value)
+    }
+    return t, nil
+}
+
+// Дни выполнения: "1 3 5 7" — на i-й позиции цифра i или пробел
+func parseDays(value string) (models.Weekdays, error) {
+    var days models.Weekdays
+    for i := 0; i < 7; i++ {
+        switch value[i] {
+        case ' ':
+        case byte('1' + i):
+            days |= 1 << i
+        default:
+            return 0, fmt.Errorf("invalid days of operation %q", value)
+        }
+    }
+    if days == 0 {
+        return 0, fmt.Errorf("no days of operation")
+    }
+    return days, nil
+}
+
+// Время HHMM в минутах от полуночи
+func parseClock(value string) (int, error) {
+    if len(value) != 4 {
+        return 0, fmt.Errorf("invalid time %q, expected HHMM", value)
+    }
+    hours, err1 := strconv.Atoi(value[:2])
+    minutes, err2 := strconv.Atoi(value[2:])
+    if err1 != nil || err2 != nil || hours > 23 || minutes > 59 {
+        return 0, fmt.Errorf("invalid time %q, expected HHMM", value)
+    }
+    return hours*60 + minutes, nil
+}
+
+// Разница местного времени и UTC: +0300 или -0500, в минутах
+func parseOffset(value string) (int, error) {
+    if len(value) != 5 || (value[0] != '+' && value[0] != '-') {
+        return 0, fmt.Errorf("invalid UTC variation %q, expected +HHMM", value)
+    }
+    minutes, err := parseClock(value[1:])
+    if err != nil {
+        return 0, fmt.Errorf("invalid UTC variation %q, expected +HHMM", value)
+    }
+    if value[0] == '-' {
+        minutes = -minutes
+    }
+    return minutes, nil
+}
+
+// Сдвиг даты участка: цифра — дней вперед, A — на день назад
+func parseDateVariation(c byte) (int, error) {
+    switch {
+    case c == ' ':
+        return 0, nil
+    case c >= '0' && c <= '9':
+        return int(c - '0'), nil
+    case c == 'A':
+        return -1, nil
+    }
+    return 0, fmt.Errorf("invalid date variation %q", string(c))
+}
+
+// Местное время по дате, времени в минутах и смещению от UTC.
+// В режиме UTC время в файле — UTC, иначе уже местное
+func localTime(date time.Time, minutes, offset int, utc bool) time.Time {
+    zone := time.FixedZone("", offset*60)
+    y, m, d := date.Date()
+    if utc {
+        return time.Date(y, m, d, 0, minutes, 0, 0, time.UTC).In(zone)
+    }
+    return time.Date(y, m, d, 0, minutes, 0, 0, zone)
+}
+
+// Разница местных дат b и a в сутках
+func daysBetween(a, b time.Time) int {
+    ay, am, ad := a.Date()
+    by, bm, bd := b.Date()
+    da := time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)
+    db := time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC)
+    return int(db.Sub(da).Hours() / 24)
+}
+
+// Сдвиг дней недели на shift суток
+func rotate(days models.Weekdays, shift int) models.Weekdays {
+    shift = ((shift % 7) + 7) % 7
+    var rotated models.Weekdays
+    for i := 0; i < 7; i++ {
+        if days&(1<<i) != 0 {
+            rotated |= 1 << ((i + shift) % 7)
+        }
+    }
+    return rotated
+}
@@ -0,0 +1,125 @@
+package ssim
+
+import (
+    "os"
+    "testing"
+    "skyflow/internal/models"
+)
+
+func TestParse(t *testing.T) {
+    file, err := os.Open("testdata/season.ssim")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer file.Close()
+    
+    legs, errs, err := Parse(file)
+    if err != nil {
+        t.Fatal(err)
+    }
+    
+    tests := []struct {
+        name             string
+        flightNumber     string
+        from, to         string
+        validFrom        string
+        validTo          string
+        days             models.Weekdays
+        departureTime    string
+        arrivalTime      string
+        arrivalDayOffset int
+        sourceKey        string
+    }{
+        {
+            name:         "same day",
+            flightNumber: "SU 100", from: "SVO", to: "JFK",
+            validFrom: "2026-11-02", validTo: "2026-11-30",
+            days:          1 | 1<<2 | 1<<4,
+            departureTime: "10:00", arrivalTime: "13:00",
+            sourceKey: "ssim:W26:SU:0100:01:01",
+        },
+        {
+            name:         "arrival next day",
+            flightNumber: "SU 101", from: "JFK", to: "SVO",
+            validFrom: "2026-11-02", validTo: "2026-11-30",
+            days:          1,
+            departureTime: "22:00", arrivalTime: "14:00", arrivalDayOffset: 1,
+            sourceKey: "ssim:W26:SU:0101:01:01",
+        },
+        {
+            // Второй участок: вылет и прилет на следующий день после даты периода
+            name:         "departure date variation",
+            flightNumber: "SU 200", from: "SVO", to: "LED",
+            validFrom: "2026-11-03", validTo: "2026-12-01",
+            days:          1 << 1,
+            departureTime: "01:30", arrivalTime: "03:00",
+            sourceKey: "ssim:W26:SU:0200:01:02",
+        },
+    }
+    
+    if len(legs) != len(tests) {
+        t.Fatalf("got %d legs, want %d (errors: %v)", len(legs), len(tests), errs)
+    }
+    for i, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            leg := legs[i]
+            if leg.FlightNumber != tt.flightNumber || leg.From != tt.from || leg.To != tt.to {
+                t.Errorf("leg = %s %s-%s, want %s %s-%s", leg.FlightNumber, leg.From, leg.To, tt.flightNumber, tt.from, tt.to)
+            }
+            if got := leg.ValidFrom.Format(models.DateLayout); got != tt.validFrom {
+                t.Errorf("ValidFrom = %s, want %s", got, tt.validFrom)
+            }
+            if got := leg.ValidTo.Format(models.DateLayout); got != tt.validTo {
+                t.Errorf("ValidTo = %s, want %s", got, tt.validTo)
+            }
+            if leg.Days != tt.days {
+                t.Errorf("Days = %07b, want %07b", leg.Days, tt.days)
+            }
+            if leg.DepartureTime != tt.departureTime || leg.ArrivalTime != tt.arrivalTime {
+                t.Errorf("times = %s-%s, want %s-%s", leg.DepartureTime, leg.ArrivalTime, tt.departureTime, tt.arrivalTime)
+            }
+            if leg.ArrivalDayOffset != tt.arrivalDayOffset {
+                t.Errorf("ArrivalDayOffset = %d, want %d", leg.ArrivalDayOffset, tt.arrivalDayOffset)
+            }
+            if got := leg.SourceKey(); got != tt.sourceKey {
+                t.Errorf("SourceKey = %s, want %s", got, tt.sourceKey)
+            }
+        })
+    }
+    
+    if len(errs) != 1 || errs[0].Line != 6 || errs[0].Field != "dateVariation" {
+        t.Errorf("errors = %v, want invalid date variation on line 6", errs)
+    }
+}
+
+func TestParseDate(t *testing.T) {
+    tests := []struct {
+        value   string
+        want    string
+        wantErr bool
+    }{
+        {value: "01NOV26", want: "2026-11-01"},
+        {value: "29FEB28", want: "2028-02-29"},
+        {value: "00XXX00", want: ""},
+        {value: "1NOV26", wantErr: true},
+        {value: "01XYZ26", wantErr: true},
+    }
+    
+    for _, tt := range tests {
+        got, err := parseDate(tt.value)
+        if (err != nil) != tt.wantErr {
+            t.Errorf("parseDate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+            continue
+        }
+        if tt.wantErr {
+            continue
+        }
+        formatted := ""
+        if !got.IsZero() {
+            formatted = got.Format(models.DateLayout)
+        }
+        if formatted != tt.want {
+            t.Errorf("parseDate(%q) = %s, want %s", tt.value, formatted, tt.want)
+        }
+    }
+}
@@ -0,0 +1,100 @@
+// Одноразовые коды по RFC 6238 (TOTP) для второго фактора входа
+package totp
+
+import (
+    "crypto/hmac"
+    "crypto/rand"
+    "crypto/sha1"
+    "crypto/subtle"
+    "encoding/base32"
+    "encoding/binary"
+    "fmt"
+    "net/url"
+    "strings"
+    "time"
+)
+
+const (
+    // Параметры по умолчанию, которые понимают все приложения-аутентификаторы
+    Digits = 6
+    Period = 30 * time.Second
+    
+    secretSize = 20 // 160 бит, рекомендация RFC 4226
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Новый случайный секрет в base32
+func GenerateSecret() (string, error) {
+    buf := make([]byte, secretSize)
+    if _, err := rand.Read(buf); err != nil {
+        return "", fmt.Errorf("failed to generate secret: %w", err)
+    }
+    return encoding.EncodeToString(buf), nil
+}
+
+// Номер 30-секундного шага для момента t
+func Step(t time.Time) int64 {
+    return t.Unix() / int64(Period/time.Second)
+}
+
+// Код для шага step
+func Code(secret string, step int64) (string, error) {
+    key, err := encoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+    if err != nil {
+        return "", fmt.Errorf("invalid secret: %w", err)
+    }
+    
+    var msg [8]byte
+    binary.BigEndian.PutUint64(msg[:], uint64(step))
+    
+    mac := hmac.New(sha1.New, key)
+    mac.Write(msg[:])
+    sum := mac.Sum(nil)
+    
+    // Динамическое усечение (RFC 4226, раздел 5.3)
+    offset := sum[len(sum)-1] & 0x0f
+    value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+    
+    mod := uint32(1)
+    for i := 0; i < Digits; i++ {
+        mod *= 10
+    }
+    return fmt.Sprintf("%0*d", Digits, value%mod), nil
+}
+
+// Проверка кода с допуском ±skew шагов на расхождение часов.
+// Возвращает шаг, которому соответствует код, чтобы не принять его повторно
+func Verify(secret, code string, t time.Time, skew int) (int64, bool) {
+    code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+    if len(code) != Digits {
+        return 0, false
+    }
+    
+    current := Step(t)
+    for i := -skew; i <= skew; i++ {
+        step := current + int64(i)
+        expected, err := Code(secret, step)
+        if err != nil {
+            return 0, false
+        }
+        if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+            return step, true
+        }
+    }
+    return 0, false
+}
+
+// otpauth:// URI для QR-кода в приложении-аутентификаторе
+func URI(issuer, account, secret string) string {
+    label := url.PathEscape(issuer + ":" + account)
+    
+    params := url.Values{}
+    params.Set("secret", secret)
+    params.Set("issuer", issuer)
+    params.Set("algorithm", "SHA1")
+    params.Set("digits", fmt.Sprint(Digits))
+    params.Set("period", fmt.Sprint(int(Period/time.Second)))
+    
+    return "otpauth://totp/" + label + "?" + params.Encode()
+}
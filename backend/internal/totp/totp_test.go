@@ -0,0 +1,75 @@
+package totp
+
+import (
+    "testing"
+    "time"
+)
+
+// Секрет из приложения B RFC 6238 ("12345678901234567890") в base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Тестовые векторы SHA1 из RFC 6238: последние шесть цифр восьмизначных кодов
+var rfcVectors = []struct {
+    unix int64
+    code string
+}{
+    {59, "287082"},
+    {1111111109, "081804"},
+    {1111111111, "050471"},
+    {1234567890, "005924"},
+    {2000000000, "279037"},
+    {20000000000, "353130"},
+}
+
+func TestCodeRFC6238(t *testing.T) {
+    for _, tt := range rfcVectors {
+        got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got != tt.code {
+            t.Errorf("Code at %d = %s, want %s", tt.unix, got, tt.code)
+        }
+    }
+}
+
+func TestVerify(t *testing.T) {
+    now := time.Unix(1111111111, 0)
+    current := Step(now)
+    
+    tests := []struct {
+        name     string
+        secret   string
+        code     string
+        skew     int
+        wantStep int64
+        ok       bool
+    }{
+        {name: "current step", secret: rfcSecret, code: "050471", wantStep: current, ok: true},
+        {name: "spaces are ignored", secret: rfcSecret, code: " 050 471 ", wantStep: current, ok: true},
+        {name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "050471", wantStep: current, ok: true},
+        {name: "previous step within skew", secret: rfcSecret, code: mustCode(t, current-1), skew: 1, wantStep: current - 1, ok: true},
+        {name: "previous step without skew", secret: rfcSecret, code: mustCode(t, current-1)},
+        {name: "wrong code", secret: rfcSecret, code: "000000", skew: 1},
+        {name: "wrong length", secret: rfcSecret, code: "05047"},
+        {name: "invalid secret", secret: "not base32!", code: "050471"},
+    }
+    
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            step, ok := Verify(tt.secret, tt.code, now, tt.skew)
+            if ok != tt.ok || step != tt.wantStep {
+                t.Errorf("Verify() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.ok)
+            }
+        })
+    }
+}
+
+func mustCode(t *testing.T, step int64) string {
+    t.Helper()
+    code, err := Code(rfcSecret, step)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return code
+}
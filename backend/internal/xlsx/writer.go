@@ -0,0 +1,131 @@
+// Минимальная запись книги Office Open XML (.xlsx) с одним листом.
+// Все ячейки — строки (inline strings): этого достаточно для выгрузок,
+// которые открывают в Excel или LibreOffice
+package xlsx
+
+import (
+    "archive/zip"
+    "encoding/xml"
+    "io"
+    "strconv"
+    "strings"
+)
+
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+const contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+const workbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+// Пишет книгу с листом sheet; первая строка rows обычно заголовок
+func Write(w io.Writer, sheet string, rows [][]string) error {
+    z := zip.NewWriter(w)
+    
+    parts := []struct{ name, body string }{
+        {"[Content_Types].xml", contentTypesXML},
+        {"_rels/.rels", rootRelsXML},
+        {"xl/_rels/workbook.xml.rels", workbookRelsXML},
+        {"xl/workbook.xml", strings.Replace(workbookXML, "%s", escape(sheetName(sheet)), 1)},
+    }
+    for _, p := range parts {
+        f, err := z.Create(p.name)
+        if err != nil {
+            return err
+        }
+        if _, err := io.WriteString(f, p.body); err != nil {
+            return err
+        }
+    }
+    
+    f, err := z.Create("xl/worksheets/sheet1.xml")
+    if err != nil {
+        return err
+    }
+    if err := writeSheet(f, rows); err != nil {
+        return err
+    }
+    
+    return z.Close()
+}
+
+func writeSheet(w io.Writer, rows [][]string) error {
+    var b strings.Builder
+    b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+    b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+    for i, row := range rows {
+        n := strconv.Itoa(i + 1)
+        b.WriteString(`<row r="` + n + `">`)
+        for j, value := range row {
+            if value == "" {
+                continue
+            }
+            b.WriteString(`<c r="` + column(j) + n + `" t="inlineStr"><is><t xml:space="preserve">`)
+            b.WriteString(escape(value))
+            b.WriteString(`</t></is></c>`)
+        }
+        b.WriteString(`</row>`)
+        
+        // Сбрасываем порциями, чтобы не держать весь лист в памяти
+        if b.Len() > 64<<10 {
+            if _, err := io.WriteString(w, b.String()); err != nil {
+                return err
+            }
+            b.Reset()
+        }
+    }
+    b.WriteString(`</sheetData></worksheet>`)
+    _, err := io.WriteString(w, b.String())
+    return err
+}
+
+// Буквенное имя столбца: 0 -> A, 25 -> Z, 26 -> AA
+func column(index int) string {
+    name := ""
+    for index >= 0 {
+        name = string(rune('A'+index%26)) + name
+        index = index/26 - 1
+    }
+    return name
+}
+
+// Excel ограничивает имя листа 31 символом и запрещает []:*?/\
+func sheetName(name string) string {
+    name = strings.Map(func(r rune) rune {
+        if strings.ContainsRune(`[]:*?/\`, r) {
+            return '_'
+        }
+        return r
+    }, name)
+    if runes := []rune(name); len(runes) > 31 {
+        name = string(runes[:31])
+    }
+    if name == "" {
+        name = "Sheet1"
+    }
+    return name
+}
+
+func escape(s string) string {
+    var b strings.Builder
+    xml.EscapeText(&b, []byte(s))
+    return b.String()
+}
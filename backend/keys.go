@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"skyflow/internal/config"
+	"skyflow/internal/jwtkeys"
+)
+
+// skyflow keys generate [eddsa|rs256]|retire <kid>|list
+func runKeysCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: skyflow keys generate [eddsa|rs256]|retire <kid>|list")
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "generate":
+		alg := jwtkeys.AlgEdDSA
+		if len(args) > 1 && strings.EqualFold(args[1], "rs256") {
+			alg = jwtkeys.AlgRS256
+		}
+		key, err := jwtkeys.Generate(cfg.JWTKeysDir, alg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Создан ключ %s (%s), станет основным через %s", key.ID, key.Alg, 2*cfg.JWTKeysReload)
+
+	case "retire":
+		if len(args) < 2 {
+			log.Fatal("Не указан kid ключа")
+		}
+		if err := jwtkeys.Retire(cfg.JWTKeysDir, args[1]); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Ключ %s выведен из ротации: остался только для проверки токенов", args[1])
+
+	case "list":
+		keys := jwtkeys.NewKeySet(cfg.JWTKeysDir, 2*cfg.JWTKeysReload)
+		if err := keys.Load(); err != nil {
+			log.Fatal(err)
+		}
+		for _, key := range keys.Keys() {
+			state := "verify-only"
+			if key.Private != nil {
+				state = "standby"
+			}
+			if key.ID == keys.SigningKeyID() {
+				state = "active"
+			}
+			fmt.Printf("%-20s %-6s %-12s %s\n", key.ID, key.Alg, state, key.CreatedAt.Format("2006-01-02 15:04:05"))
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown keys command %q\n", args[0])
+		os.Exit(2)
+	}
+}
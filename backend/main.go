@@ -1,274 +1,486 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
-	"strconv"
-	"strings"
-	"sync"
 	"time"
-)
+	// Часовые пояса аэропортов не зависят от tzdata в образе
+	_ "time/tzdata"
 
-type Flight struct {
-	ID      string `json:"id"`
-	Number  string `json:"number"`
-	Airline string `json:"airline"`
-	From    string `json:"from"`
-	To      string `json:"to"`
-	Time    string `json:"time"`
-	Date    string `json:"date"`
-	Status  string `json:"status"`
-}
+	"skyflow/internal/config"
+	"skyflow/internal/database"
+	"skyflow/internal/events"
+	"skyflow/internal/handlers"
+	"skyflow/internal/jwtkeys"
+	"skyflow/internal/middleware"
+	"skyflow/internal/models"
+	"skyflow/migrations"
+	"skyflow/reference"
 
-var (
-	flights = []Flight{
-		{
-			ID:      "1",
-			Number:  "S7 123",
-			Airline: "S7 Airlines",
-			From:    "SKY",
-			To:      "SVO",
-			Time:    "14:30",
-			Date:    time.Now().Format("2006-01-02"),
-			Status:  "scheduled",
-		},
-		{
-			ID:      "2",
-			Number:  "SU 456",
-			Airline: "Aeroflot",
-			From:    "SKY",
-			To:      "LED",
-			Time:    "15:45",
-			Date:    time.Now().Format("2006-01-02"),
-			Status:  "boarding",
-		},
-		{
-			ID:      "3",
-			Number:  "TK 789",
-			Airline: "Turkish Airlines",
-			From:    "SKY",
-			To:      "IST",
-			Time:    "16:20",
-			Date:    time.Now().Format("2006-01-02"),
-			Status:  "delayed",
-		},
-	}
-	flightsMutex = &sync.RWMutex{}
+	"github.com/go-chi/chi/v5"
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
 )
 
 func main() {
-	host := os.Getenv("HOST")
-	if host == "" {
-		host = "0.0.0.0"
+	cfg := config.Load()
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(cfg, os.Args[2:])
+		return
 	}
-	
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		runKeysCommand(cfg, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "ssim" {
+		runSSIMCommand(cfg, os.Args[2:])
+		return
 	}
 
-	addr := fmt.Sprintf("%s:%s", host, port)
-	
-	// Получаем локальные IP адреса
-	localIPs := getLocalIPs()
-	
-	log.Println("✅ SKYFLOW Backend запущен!")
-	log.Println("📍 Сервер запущен на:", addr)
-	log.Println("🌐 Доступные IP адреса:")
-	for _, ip := range localIPs {
-		log.Printf("   - http://%s:%s", ip, port)
+	var (
+		db          *sql.DB
+		flightStore database.FlightStore
+		authHandler *handlers.AuthHandler
+		userHandler *handlers.UserHandler
+		historyRepo *database.FlightEventRepository
+		// Отозванные токены (nil в демо-режиме)
+		denylist middleware.TokenDenylist
+		// Ключи API внешних систем (nil в демо-режиме)
+		apiKeys    middleware.APIKeyVerifier
+		apiKeyRepo *database.APIKeyRepository
+		// Сезонные расписания (nil в демо-режиме)
+		flightRepo   *database.FlightRepository
+		scheduleRepo *database.ScheduleRepository
+		// Справочники аэропортов, авиакомпаний и типов ВС
+		referenceStore database.ReferenceStore
+	)
+
+	// Встроенные справочники для первоначального заполнения
+	dataset, err := reference.Load()
+	if err != nil {
+		log.Fatalf("Failed to load reference data: %v", err)
 	}
-	log.Println("📱 Для доступа с телефона:")
-	log.Println("   - Узнайте IP компьютера в сети Wi-Fi")
-	log.Println("   - Используйте: http://ВАШ-IP:3000")
-	log.Println("📊 API: http://localhost:8080/api/flights")
-	log.Println("🔐 Логин админа: admin / 0000")
-
-	// Разрешаем CORS
-	corsMiddleware := func(next http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-			
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-			
-			next(w, r)
-		}
+
+	// Ключи подписи JWT; новые ключи из каталога подхватываются без перезапуска
+	jwtKeys := jwtkeys.NewKeySet(cfg.JWTKeysDir, 2*cfg.JWTKeysReload)
+	if err := jwtKeys.Reload(); err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
 	}
+	go jwtKeys.Run(context.Background(), cfg.JWTKeysReload)
 
-	// Получить все рейсы
-	http.HandleFunc("/api/flights", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		flightsMutex.RLock()
-		json.NewEncoder(w).Encode(flights)
-		flightsMutex.RUnlock()
-	}))
-
-	// Добавить рейс
-	http.HandleFunc("/api/flights/add", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != "POST" {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+	// Журнал последних изменений для переподключений SSE
+	broker := events.NewBroker(cfg.EventLogSize)
+	// Кто публикует изменения рейсов из хендлеров (nil — их приносит LISTEN/NOTIFY)
+	flightEvents := broker
 
-		var req struct {
-			Number  string `json:"number"`
-			Airline string `json:"airline"`
-			From    string `json:"from"`
-			To      string `json:"to"`
-			Time    string `json:"time"`
-			Status  string `json:"status"`
+	if cfg.Storage == "memory" {
+		// Демо-режим: табло в памяти, без Postgres и без входа в админку
+		store := database.NewMemoryFlightStore()
+		seedDemoFlights(context.Background(), store, models.NewReferenceData(dataset.Airports, dataset.Airlines, dataset.AircraftTypes))
+		flightStore = store
+		referenceStore = database.NewMemoryReferenceStore()
+		log.Println("⚠️  Демо-режим: рейсы хранятся в памяти")
+	} else {
+		db, err = database.Connect(cfg.DatabaseURL)
+		if err != nil {
+			log.Fatal(err)
 		}
+		defer db.Close()
 
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
-			return
+		// Применяем новые миграции схемы
+		migrator, err := database.NewMigrator(db, migrations.FS)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			log.Fatal(err)
 		}
 
-		var flightTime, flightDate string
-		
-		if strings.Contains(req.Time, "T") {
-			parts := strings.Split(req.Time, "T")
-			if len(parts) == 2 {
-				flightDate = parts[0]
-				timePart := parts[1]
-				if len(timePart) >= 5 {
-					flightTime = timePart[:5]
-				} else {
-					flightTime = timePart
-				}
+		flightRepo = database.NewFlightRepository(db)
+		flightStore = flightRepo
+		referenceStore = database.NewReferenceRepository(db)
+
+		// Изменения приходят через LISTEN/NOTIFY, в том числе от других реплик
+		flightEvents = nil
+		listener := database.NewFlightChangeListener(cfg.DatabaseURL, flightRepo, broker)
+		go func() {
+			if err := listener.Run(context.Background()); err != nil {
+				log.Fatal("Не удалось подписаться на изменения рейсов: ", err)
 			}
-		}
+		}()
 
-		if flightDate == "" {
-			flightDate = time.Now().Format("2006-01-02")
-		}
-		if flightTime == "" {
-			flightTime = "12:00"
+		historyRepo = database.NewFlightEventRepository(db)
+		userRepo := database.NewUserRepository(db)
+
+		// Создаем админа admin / 0000, если его еще нет
+		if err := userRepo.CreateDefaultAdmin(context.Background()); err != nil {
+			log.Fatal("Не удалось создать администратора: ", err)
 		}
 
-		flight := Flight{
-			ID:      strconv.FormatInt(time.Now().UnixNano(), 10),
-			Number:  strings.TrimSpace(req.Number),
-			Airline: strings.TrimSpace(req.Airline),
-			From:    strings.TrimSpace(req.From),
-			To:      strings.TrimSpace(req.To),
-			Time:    flightTime,
-			Date:    flightDate,
-			Status:  "scheduled",
+		tokenRepo := database.NewTokenRepository(db)
+		tokenDenylist := database.NewTokenDenylist(tokenRepo)
+		if err := tokenDenylist.Reload(context.Background()); err != nil {
+			log.Fatal(err)
 		}
+		go tokenDenylist.Run(context.Background(), 30*time.Second)
+		denylist = tokenDenylist
+
+		throttle := database.NewLoginThrottle(db, database.ThrottlePolicy{
+			MaxUserFailures: cfg.LoginMaxUserFailures,
+			MaxIPFailures:   cfg.LoginMaxIPFailures,
+			BaseLockout:     cfg.LoginLockoutBase,
+			MaxLockout:      cfg.LoginLockoutMax,
+			Window:          cfg.LoginFailureWindow,
+		})
+		go throttle.Run(context.Background(), time.Minute)
+
+		authHandler = handlers.NewAuthHandler(userRepo, tokenRepo, tokenDenylist, throttle, jwtKeys, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+		userHandler = handlers.NewUserHandler(userRepo, tokenRepo, tokenDenylist)
+
+		apiKeyRepo = database.NewAPIKeyRepository(db)
+		apiKeys = apiKeyRepo
+
+		scheduleRepo = database.NewScheduleRepository(db)
+	}
+
+	// Пустые таблицы справочников заполняются встроенными данными
+	if err := referenceStore.Seed(context.Background(), dataset); err != nil {
+		log.Fatalf("Failed to seed reference data: %v", err)
+	}
+	referenceCache := database.NewReferenceCache(referenceStore)
+	if err := referenceCache.Reload(context.Background()); err != nil {
+		log.Fatalf("Failed to load reference data: %v", err)
+	}
+	go referenceCache.Run(context.Background(), time.Minute)
 
-		flightsMutex.Lock()
-		flights = append(flights, flight)
-		flightsMutex.Unlock()
+	// Рейсы в ответах и событиях дополняются названиями из справочников
+	flightStore = database.NewEnrichedFlightStore(flightStore, referenceCache)
+	broker.SetEnricher(referenceCache.Enrich)
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"status": "added",
-			"id":     flight.ID,
+	var apiKeyHandler *handlers.APIKeyHandler
+	if apiKeyRepo != nil {
+		apiKeyHandler = handlers.NewAPIKeyHandler(apiKeyRepo, referenceCache)
+	}
+
+	// Рейсы по расписаниям создаются заранее на ScheduleHorizonDays дней
+	var scheduleHandler *handlers.ScheduleHandler
+	if scheduleRepo != nil {
+		generator := database.NewScheduleGenerator(scheduleRepo, flightRepo, referenceCache, cfg.ScheduleHorizonDays)
+		go generator.Run(context.Background(), cfg.ScheduleGenerateInterval)
+		scheduleHandler = handlers.NewScheduleHandler(scheduleRepo, generator, referenceCache)
+	}
+
+	// Статусы рейсов меняются по времени; ручная правка важнее
+	if cfg.StatusAutomation {
+		statusScheduler := database.NewStatusScheduler(flightStore, flightEvents, models.StatusTimings{
+			CheckInOpen:  cfg.StatusCheckInOpen,
+			Boarding:     cfg.StatusBoarding,
+			FinalCall:    cfg.StatusFinalCall,
+			GateClosed:   cfg.StatusGateClosed,
+			ArchiveAfter: cfg.StatusArchiveAfter,
 		})
-	}))
+		go statusScheduler.Run(context.Background(), cfg.StatusInterval)
+	}
 
-	// Обновить статус
-	http.HandleFunc("/api/flights/update", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != "POST" {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+	boardHandler := handlers.NewBoardHandler(flightStore, referenceCache, cfg.HomeAirport,
+		models.BoardWindow{Past: cfg.DeparturesBoardPast, Ahead: cfg.DeparturesBoardAhead},
+		models.BoardWindow{Past: cfg.ArrivalsBoardPast, Ahead: cfg.ArrivalsBoardAhead})
+	flightHandler := handlers.NewFlightHandler(flightStore, flightEvents, historyRepo, referenceCache, scheduleRepo, cfg.HomeAirport, cfg.DelayThreshold)
+	referenceHandler := handlers.NewReferenceHandler(referenceCache)
+	streamHandler := handlers.NewStreamHandler(broker)
 
-		var update struct {
-			ID     string `json:"id"`
-			Status string `json:"status"`
-		}
+	// Рассылка изменений подписчикам отдельных рейсов (WebSocket)
+	hub := events.NewHub(broker)
+	go hub.Run(context.Background())
+	wsHandler := handlers.NewWSHandler(flightStore, hub)
 
-		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
-		}
+	jwtAuth := middleware.JWTAuth(jwtKeys, denylist)
+	// Рейсы доступны и сотрудникам (JWT), и внешним системам (X-API-Key)
+	flightAuth := middleware.APIKeyAuth(apiKeys, jwtAuth)
 
-		flightsMutex.Lock()
-		for i := range flights {
-			if flights[i].ID == update.ID {
-				flights[i].Status = update.Status
-				break
-			}
+	// Чтение рейсов: публичное или только с правом flights:read
+	readAccess := func(next http.Handler) http.Handler { return next }
+	if !cfg.PublicFlights {
+		readAccess = func(next http.Handler) http.Handler {
+			return flightAuth(middleware.RequirePermission(models.PermFlightsRead)(next))
 		}
-		flightsMutex.Unlock()
+	}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
-	}))
+	r := chi.NewRouter()
+	if cfg.TrustProxy {
+		r.Use(chimiddleware.RealIP)
+	}
+	r.Use(chimiddleware.Recoverer)
+	r.Use(corsMiddleware)
 
-	// Удалить рейс
-	http.HandleFunc("/api/flights/delete", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != "POST" {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
+	r.Route("/api", func(r chi.Router) {
+		r.Route("/flights", func(r chi.Router) {
+			// Чтение (публичное, если не выключено PUBLIC_FLIGHTS)
+			r.Group(func(r chi.Router) {
+				r.Use(readAccess)
+				r.Get("/", flightHandler.GetAllFlights)
+				r.Get("/stream", streamHandler.FlightStream)
+				r.Get("/number/{number}", flightHandler.GetFlightByNumber)
+				r.Get("/{id}", flightHandler.GetFlight)
+			})
 
-		var req struct {
-			ID string `json:"id"`
+			// Защищенные методы
+			r.Group(func(r chi.Router) {
+				r.Use(flightAuth)
+				r.Use(middleware.RequirePasswordChanged)
+				// Выгрузка всегда только с правом flights:read, даже при публичном чтении
+				r.With(middleware.RequirePermission(models.PermFlightsRead)).Get("/export", flightHandler.ExportFlights)
+				r.With(middleware.RequirePermission(models.PermFlightsCreate)).Post("/", flightHandler.CreateFlight)
+				r.With(middleware.RequirePermission(models.PermFlightsCreate)).Post("/import", flightHandler.ImportFlights)
+				// Какие поля можно менять, хендлер проверяет по роли
+				r.With(middleware.RequirePermission(models.PermFlightsGate)).Put("/{id}", flightHandler.UpdateFlight)
+				r.With(middleware.RequirePermission(models.PermFlightsDelete)).Delete("/{id}", flightHandler.DeleteFlight)
+
+				// Журнал изменений есть только при работе с Postgres
+				if historyRepo != nil {
+					r.With(middleware.RequirePermission(models.PermFlightsHistory)).Get("/{id}/history", flightHandler.GetFlightHistory)
+				}
+			})
+		})
+
+		// Табло вылетов и прилетов домашнего аэропорта (HOME_AIRPORT)
+		r.Route("/boards", func(r chi.Router) {
+			r.Use(readAccess)
+			r.Get("/departures", boardHandler.Departures)
+			r.Get("/arrivals", boardHandler.Arrivals)
+		})
+
+		if authHandler != nil {
+			r.Route("/auth", func(r chi.Router) {
+				r.Post("/login", authHandler.Login)
+				r.Post("/login/2fa", authHandler.LoginTwoFactor)
+				r.Post("/refresh", authHandler.Refresh)
+				r.With(jwtAuth).Post("/logout", authHandler.Logout)
+				r.With(jwtAuth).Get("/me", authHandler.GetCurrentUser)
+				r.With(jwtAuth).Post("/password", authHandler.ChangePassword)
+
+				// Настройка второго фактора (TOTP) своей учетной записи
+				r.Route("/2fa", func(r chi.Router) {
+					r.Use(jwtAuth)
+					r.Use(middleware.RequirePasswordChanged)
+					r.Get("/", authHandler.TwoFactorStatus)
+					r.Post("/setup", authHandler.SetupTwoFactor)
+					r.Post("/enable", authHandler.EnableTwoFactor)
+					r.Post("/disable", authHandler.DisableTwoFactor)
+					r.Post("/recovery-codes", authHandler.RegenerateRecoveryCodes)
+				})
+			})
+
+			// Управление сотрудниками (только админ)
+			r.Route("/users", func(r chi.Router) {
+				r.Use(jwtAuth)
+				r.Use(middleware.RequirePasswordChanged)
+				r.Use(middleware.RequirePermission(models.PermUsersManage))
+				r.Get("/", userHandler.ListUsers)
+				r.Post("/", userHandler.CreateUser)
+				r.Put("/{id}/role", userHandler.UpdateRole)
+				r.Post("/{id}/disable", userHandler.DisableUser)
+				r.Post("/{id}/enable", userHandler.EnableUser)
+				r.Post("/{id}/password", userHandler.ResetPassword)
+				r.Delete("/{id}/2fa", userHandler.ResetTwoFactor)
+			})
+
+			// Блокировки входа после перебора паролей (только админ)
+			r.Route("/lockouts", func(r chi.Router) {
+				r.Use(jwtAuth)
+				r.Use(middleware.RequirePasswordChanged)
+				r.Use(middleware.RequirePermission(models.PermUsersManage))
+				r.Get("/", authHandler.ListLockouts)
+				r.Delete("/{kind}/{subject}", authHandler.ClearLockout)
+			})
 		}
 
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+		// Ключи API для внешних систем (только админ)
+		if apiKeyHandler != nil {
+			r.Route("/api-keys", func(r chi.Router) {
+				r.Use(jwtAuth)
+				r.Use(middleware.RequirePasswordChanged)
+				r.Use(middleware.RequirePermission(models.PermAPIKeysManage))
+				r.Get("/", apiKeyHandler.ListKeys)
+				r.Post("/", apiKeyHandler.CreateKey)
+				r.Delete("/{id}", apiKeyHandler.RevokeKey)
+			})
 		}
 
-		flightsMutex.Lock()
-		newFlights := []Flight{}
-		for _, f := range flights {
-			if f.ID != req.ID {
-				newFlights = append(newFlights, f)
-			}
+		// Сезонные расписания рейсов
+		if scheduleHandler != nil {
+			r.Route("/schedules", func(r chi.Router) {
+				r.Use(flightAuth)
+				r.Use(middleware.RequirePasswordChanged)
+				r.Use(middleware.RequirePermission(models.PermSchedulesManage))
+				r.Get("/", scheduleHandler.ListSchedules)
+				r.Post("/", scheduleHandler.CreateSchedule)
+				r.Post("/import/ssim", scheduleHandler.ImportSSIM)
+				r.Get("/{id}", scheduleHandler.GetSchedule)
+				r.Put("/{id}", scheduleHandler.UpdateSchedule)
+				// Вместе с расписанием удаляются его будущие рейсы
+				r.With(middleware.RequirePermission(models.PermFlightsDelete)).Delete("/{id}", scheduleHandler.DeleteSchedule)
+				r.Get("/{id}/flights", scheduleHandler.ListScheduleFlights)
+				r.Post("/{id}/generate", scheduleHandler.GenerateSchedule)
+				r.Post("/{id}/dates/{date}/cancel", scheduleHandler.CancelScheduleDate)
+			})
 		}
-		flights = newFlights
-		flightsMutex.Unlock()
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
-	}))
+		// Справочники: читать могут все, у кого есть доступ к табло
+		r.Route("/airports", func(r chi.Router) {
+			r.With(readAccess).Get("/", referenceHandler.ListAirports)
+			r.With(readAccess).Get("/{code}", referenceHandler.GetAirport)
+			r.Group(func(r chi.Router) {
+				r.Use(flightAuth)
+				r.Use(middleware.RequirePasswordChanged)
+				r.Use(middleware.RequirePermission(models.PermReferenceManage))
+				r.Post("/", referenceHandler.CreateAirport)
+				r.Put("/{code}", referenceHandler.UpdateAirport)
+				r.Delete("/{code}", referenceHandler.DeleteAirport)
+			})
+		})
+		r.Route("/airlines", func(r chi.Router) {
+			r.With(readAccess).Get("/", referenceHandler.ListAirlines)
+			r.With(readAccess).Get("/{code}", referenceHandler.GetAirline)
+			r.Group(func(r chi.Router) {
+				r.Use(flightAuth)
+				r.Use(middleware.RequirePasswordChanged)
+				r.Use(middleware.RequirePermission(models.PermReferenceManage))
+				r.Post("/", referenceHandler.CreateAirline)
+				r.Put("/{code}", referenceHandler.UpdateAirline)
+				r.Delete("/{code}", referenceHandler.DeleteAirline)
+			})
+		})
+		r.Route("/aircraft-types", func(r chi.Router) {
+			r.With(readAccess).Get("/", referenceHandler.ListAircraftTypes)
+			r.With(readAccess).Get("/{code}", referenceHandler.GetAircraftType)
+			r.Group(func(r chi.Router) {
+				r.Use(flightAuth)
+				r.Use(middleware.RequirePasswordChanged)
+				r.Use(middleware.RequirePermission(models.PermReferenceManage))
+				r.Post("/", referenceHandler.CreateAircraftType)
+				r.Put("/{code}", referenceHandler.UpdateAircraftType)
+				r.Delete("/{code}", referenceHandler.DeleteAircraftType)
+			})
+		})
 
-	// Информация о сервере (для QR кода) - ОБНОВЛЕНО!
-	http.HandleFunc("/api/server/info", corsMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		
-		// Проверяем, работаем ли в Docker
-		isDocker := false
-		if _, err := os.Stat("/.dockerenv"); err == nil {
-			isDocker = true
-		}
-		
-		response := map[string]interface{}{
-			"url": "http://host.docker.internal:3000",
-			"backend": "http://localhost:8080",
-			"isDocker": isDocker,
-			"timestamp": time.Now().Format(time.RFC3339),
-			"message": "Для доступа с телефона используйте IP вашего компьютера в локальной сети",
-		}
-		
-		json.NewEncoder(w).Encode(response)
-	}))
+		// Коды задержки IATA AHM 730
+		r.With(readAccess).Get("/delay-codes", referenceHandler.ListDelayCodes)
+
+		// Подписки на отдельные рейсы
+		r.With(readAccess).Get("/ws", wsHandler.Serve)
+
+		// Информация о сервере (для QR кода)
+		r.Get("/server/info", serverInfo)
+	})
+
+	// Открытые ключи для проверки наших токенов другими сервисами
+	r.Get("/.well-known/jwks.json", handlers.NewJWKSHandler(jwtKeys).Serve)
 
 	// Статические файлы фронтенда
-	fs := http.FileServer(http.Dir("./static"))
-	http.Handle("/", fs)
+	r.Handle("/*", http.FileServer(http.Dir(cfg.StaticDir)))
 
-	log.Printf("🚀 Сервер запущен на %s", addr)
-	
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	addr := fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)
+
+	// Получаем локальные IP адреса
+	localIPs := getLocalIPs()
+
+	log.Println("✅ SKYFLOW Backend запущен!")
+	log.Println("📍 Сервер запущен на:", addr)
+	log.Println("🌐 Доступные IP адреса:")
+	for _, ip := range localIPs {
+		log.Printf("   - http://%s:%s", ip, cfg.Port)
+	}
+	log.Println("📱 Для доступа с телефона:")
+	log.Println("   - Узнайте IP компьютера в сети Wi-Fi")
+	log.Println("   - Используйте: http://ВАШ-IP:3000")
+	log.Printf("📊 API: http://localhost:%s/api/flights", cfg.Port)
+	if authHandler != nil {
+		log.Println("🔐 Логин админа: admin / 0000")
+	}
+
+	if err := http.ListenAndServe(addr, r); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// Разрешаем CORS
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID, X-API-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Next-Cursor, Link, Content-Disposition")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Информация о сервере (для QR кода)
+func serverInfo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Проверяем, работаем ли в Docker
+	isDocker := false
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		isDocker = true
+	}
+
+	response := map[string]interface{}{
+		"url":       "http://host.docker.internal:3000",
+		"backend":   "http://localhost:8080",
+		"isDocker":  isDocker,
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+		"message":   "Для доступа с телефона используйте IP вашего компьютера в локальной сети",
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// Тестовые рейсы для демо-режима (те же, что в migrations/001_init.sql).
+// Время задается по местным часам аэропорта вылета
+func seedDemoFlights(ctx context.Context, store database.FlightStore, data *models.ReferenceData) {
+	at := func(airport string, hour, min int) time.Time {
+		loc, _ := data.Location(airport)
+		y, m, d := time.Now().In(loc).Date()
+		return time.Date(y, m, d, hour, min, 0, 0, loc).UTC()
+	}
+	arrival := func(departure time.Time, duration time.Duration) *time.Time {
+		t := departure.Add(duration)
+		return &t
+	}
+	estimated := func(t time.Time) *time.Time {
+		return &t
+	}
+
+	flights := []models.Flight{
+		{FlightNumber: "S7 123", Airline: "S7", From: "SKY", To: "SVO", Scheduled: at("SKY", 14, 30), Actual: at("SKY", 14, 30), Terminal: "A", Gate: "12", Status: string(models.StatusScheduled)},
+		{FlightNumber: "SU 456", Airline: "SU", From: "SKY", To: "LED", Scheduled: at("SKY", 15, 45), Actual: at("SKY", 15, 45), Terminal: "A", Gate: "8", Status: string(models.StatusBoarding)},
+		{FlightNumber: "TK 789", Airline: "TK", From: "SKY", To: "IST", Scheduled: at("SKY", 16, 20), EstimatedOffBlock: estimated(at("SKY", 16, 45)), Terminal: "B", Gate: "15", Status: string(models.StatusDelayed),
+			DelayCodes: []models.FlightDelay{{Code: "93", Alpha: "RA", Minutes: 25}}, Codeshares: []models.Codeshare{{FlightNumber: "S7 4789", Airline: "S7"}}},
+		{FlightNumber: "S7 987", Airline: "S7", From: "SVO", To: "SKY", Scheduled: at("SVO", 17, 30), Actual: at("SVO", 17, 30), Terminal: "A", Gate: "22", Status: string(models.StatusScheduled)},
+	}
+	durations := []time.Duration{4*time.Hour + 15*time.Minute, 4*time.Hour + 40*time.Minute, 6 * time.Hour, 4 * time.Hour}
+
+	for i := range flights {
+		flights[i].ScheduledArrival = arrival(flights[i].Scheduled, durations[i])
+		if err := store.Create(ctx, &flights[i], nil); err != nil {
+			log.Printf("Не удалось добавить демо-рейс %s: %v", flights[i].FlightNumber, err)
+		}
+	}
+}
+
 // Функция для получения локальных IP адресов
 func getLocalIPs() []string {
 	var ips []string
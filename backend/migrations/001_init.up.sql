@@ -29,9 +29,10 @@ CREATE INDEX IF NOT EXISTS idx_flights_scheduled ON flights(scheduled_time);
 CREATE INDEX IF NOT EXISTS idx_flights_status ON flights(status);
 CREATE INDEX IF NOT EXISTS idx_flights_number ON flights(flight_number);
 
--- Тестовые данные
+-- Тестовые данные (ON CONFLICT — для баз, созданных до появления schema_migrations)
 INSERT INTO flights (id, flight_number, airline, origin, destination, scheduled_time, actual_time, terminal, gate, status) VALUES
-('1', 'S7 123', 'S7 Airlines', 'SKY', 'SVO', '2024-03-20 14:30:00', '2024-03-20 14:30:00', 'A', '12', 'scheduled'),
-('2', 'SU 456', 'Aeroflot', 'SKY', 'LED', '2024-03-20 15:45:00', '2024-03-20 15:45:00', 'A', '8', 'boarding'),
-('3', 'TK 789', 'Turkish Airlines', 'SKY', 'IST', '2024-03-20 16:20:00', '2024-03-20 16:45:00', 'B', '15', 'delayed'),
-('4', 'S7 987', 'S7 Airlines', 'SVO', 'SKY', '2024-03-20 17:30:00', '2024-03-20 17:30:00', 'A', '22', 'scheduled');
\ No newline at end of file
+('1', 'S7 123', 'S7', 'SKY', 'SVO', '2024-03-20 14:30:00', '2024-03-20 14:30:00', 'A', '12', 'scheduled'),
+('2', 'SU 456', 'SU', 'SKY', 'LED', '2024-03-20 15:45:00', '2024-03-20 15:45:00', 'A', '8', 'boarding'),
+('3', 'TK 789', 'TK', 'SKY', 'IST', '2024-03-20 16:20:00', '2024-03-20 16:45:00', 'B', '15', 'delayed'),
+('4', 'S7 987', 'S7', 'SVO', 'SKY', '2024-03-20 17:30:00', '2024-03-20 17:30:00', 'A', '22', 'scheduled')
+ON CONFLICT (id) DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS flights_notify_change ON flights;
+DROP FUNCTION IF EXISTS notify_flight_change();
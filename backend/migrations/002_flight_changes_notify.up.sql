@@ -0,0 +1,25 @@
+-- Уведомления об изменениях рейсов для всех реплик бэкенда
+CREATE OR REPLACE FUNCTION notify_flight_change() RETURNS trigger AS $$
+DECLARE
+    rec RECORD;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        rec := OLD;
+    ELSE
+        rec := NEW;
+    END IF;
+
+    PERFORM pg_notify('flight_changes', json_build_object(
+        'op', lower(TG_OP),
+        'id', rec.id,
+        'flightNumber', rec.flight_number
+    )::text);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS flights_notify_change ON flights;
+CREATE TRIGGER flights_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON flights
+    FOR EACH ROW EXECUTE FUNCTION notify_flight_change();
@@ -0,0 +1,14 @@
+-- Журнал изменений рейсов: кто, когда и что поменял
+CREATE TABLE IF NOT EXISTS flight_events (
+    id TEXT PRIMARY KEY,
+    flight_id TEXT NOT NULL,
+    flight_number TEXT NOT NULL,
+    action TEXT NOT NULL,
+    user_id TEXT,
+    username TEXT,
+    changes JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Без внешнего ключа: история удаленных рейсов должна сохраняться
+CREATE INDEX IF NOT EXISTS idx_flight_events_flight ON flight_events(flight_id, created_at);
@@ -0,0 +1,9 @@
+-- Неудачные попытки входа по имени пользователя и по IP
+CREATE TABLE IF NOT EXISTS login_throttle (
+    kind TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (kind, subject)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE flights DROP COLUMN IF EXISTS aircraft_type;
+DROP TABLE IF EXISTS aircraft_types;
+DROP TABLE IF EXISTS airlines;
+DROP TABLE IF EXISTS airports;
@@ -0,0 +1,40 @@
+-- Справочники: аэропорты, авиакомпании, типы воздушных судов.
+-- Заполняются встроенными данными при запуске (пакет reference)
+CREATE TABLE IF NOT EXISTS airports (
+    iata_code TEXT PRIMARY KEY,
+    icao_code TEXT UNIQUE,
+    name TEXT NOT NULL,
+    city TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS airlines (
+    iata_code TEXT PRIMARY KEY,
+    icao_code TEXT UNIQUE,
+    name TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT '',
+    logo_url TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS aircraft_types (
+    icao_code TEXT PRIMARY KEY,
+    iata_code TEXT,
+    name TEXT NOT NULL,
+    manufacturer TEXT NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE flights ADD COLUMN IF NOT EXISTS aircraft_type TEXT;
+
+-- Коды аэропортов в рейсах — в верхнем регистре без пробелов (SKY, а не sky)
+UPDATE flights SET origin = UPPER(TRIM(origin)) WHERE origin <> UPPER(TRIM(origin));
+UPDATE flights SET destination = UPPER(TRIM(destination)) WHERE destination <> UPPER(TRIM(destination));
+
+-- Авиакомпании в рейсах — кодом IATA, а не названием (S7, а не S7 Airlines).
+-- Если справочник еще пуст, то же делает его заполнение при запуске
+UPDATE flights SET airline = a.iata_code
+FROM airlines a
+WHERE LOWER(flights.airline) = LOWER(a.name) AND flights.airline <> a.iata_code;
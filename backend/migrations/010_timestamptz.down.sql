@@ -0,0 +1,13 @@
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
+
+ALTER TABLE flight_events ALTER COLUMN created_at TYPE TIMESTAMP;
+
+ALTER TABLE flights DROP COLUMN IF EXISTS scheduled_arrival_time;
+
+ALTER TABLE flights
+    ALTER COLUMN scheduled_time TYPE TIMESTAMP USING scheduled_time AT TIME ZONE 'UTC',
+    ALTER COLUMN actual_time TYPE TIMESTAMP USING actual_time AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
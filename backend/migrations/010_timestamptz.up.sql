@@ -0,0 +1,17 @@
+-- Время хранится как момент (TIMESTAMPTZ), а не как показания часов.
+-- Старые значения рейсов считаем временем UTC: так их отправляла админка
+-- (toISOString), а смещение из RFC3339 колонка TIMESTAMP отбрасывала
+ALTER TABLE flights
+    ALTER COLUMN scheduled_time TYPE TIMESTAMPTZ USING scheduled_time AT TIME ZONE 'UTC',
+    ALTER COLUMN actual_time TYPE TIMESTAMPTZ USING actual_time AT TIME ZONE 'UTC',
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
+
+-- Плановый прилет (по умолчанию неизвестен)
+ALTER TABLE flights ADD COLUMN IF NOT EXISTS scheduled_arrival_time TIMESTAMPTZ;
+
+ALTER TABLE flight_events ALTER COLUMN created_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ;
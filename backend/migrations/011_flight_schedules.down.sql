@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_flights_schedule_date;
+
+ALTER TABLE flights
+    DROP COLUMN IF EXISTS schedule_override,
+    DROP COLUMN IF EXISTS service_date,
+    DROP COLUMN IF EXISTS schedule_id;
+
+DROP TABLE IF EXISTS flight_schedule_exceptions;
+DROP TABLE IF EXISTS flight_schedules;
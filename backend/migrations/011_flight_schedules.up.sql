@@ -0,0 +1,40 @@
+-- Сезонные расписания: по ним генератор создает рейсы на ближайшие дни
+CREATE TABLE IF NOT EXISTS flight_schedules (
+    id TEXT PRIMARY KEY,
+    flight_number TEXT NOT NULL,
+    airline TEXT NOT NULL,
+    origin TEXT NOT NULL,
+    destination TEXT NOT NULL,
+    aircraft_type TEXT,
+    -- Битовая маска дней недели: пн = 1 ... вс = 64
+    days_of_week SMALLINT NOT NULL CHECK (days_of_week BETWEEN 1 AND 127),
+    valid_from DATE NOT NULL,
+    valid_to DATE NOT NULL,
+    -- Местное время аэропортов вылета и прилета, HH:MM
+    departure_time TEXT NOT NULL,
+    arrival_time TEXT,
+    arrival_day_offset SMALLINT NOT NULL DEFAULT 0,
+    terminal TEXT,
+    gate TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CHECK (valid_to >= valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_flight_schedules_number ON flight_schedules(flight_number);
+
+-- Даты, на которые рейс по расписанию отменен или удален
+CREATE TABLE IF NOT EXISTS flight_schedule_exceptions (
+    schedule_id TEXT NOT NULL REFERENCES flight_schedules(id) ON DELETE CASCADE,
+    service_date DATE NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (schedule_id, service_date)
+);
+
+-- Рейс из расписания: одна строка на дату, повторная генерация ничего не дублирует
+ALTER TABLE flights
+    ADD COLUMN IF NOT EXISTS schedule_id TEXT REFERENCES flight_schedules(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS service_date DATE,
+    ADD COLUMN IF NOT EXISTS schedule_override BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_flights_schedule_date ON flights(schedule_id, service_date);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_flight_schedules_source_key;
+
+ALTER TABLE flight_schedules DROP COLUMN IF EXISTS source_key;
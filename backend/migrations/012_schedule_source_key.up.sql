@@ -0,0 +1,5 @@
+-- Ключ источника импорта (участок SSIM): повторный импорт обновляет
+-- то же расписание, а не создает новое
+ALTER TABLE flight_schedules ADD COLUMN IF NOT EXISTS source_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_flight_schedules_source_key ON flight_schedules(source_key);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_flights_airline_scheduled;
+DROP INDEX IF EXISTS idx_flights_destination_scheduled;
+DROP INDEX IF EXISTS idx_flights_origin_scheduled;
+DROP INDEX IF EXISTS idx_flights_scheduled_id;
@@ -0,0 +1,6 @@
+-- Индексы для фильтров табло и постраничного списка рейсов.
+-- (scheduled_time, id) — порядок по умолчанию с продолжением по курсору
+CREATE INDEX IF NOT EXISTS idx_flights_scheduled_id ON flights(scheduled_time, id);
+CREATE INDEX IF NOT EXISTS idx_flights_origin_scheduled ON flights(origin, scheduled_time);
+CREATE INDEX IF NOT EXISTS idx_flights_destination_scheduled ON flights(destination, scheduled_time);
+CREATE INDEX IF NOT EXISTS idx_flights_airline_scheduled ON flights(airline, scheduled_time);
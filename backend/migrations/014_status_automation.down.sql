@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_flights_active;
+
+ALTER TABLE flights
+    DROP COLUMN IF EXISTS archived_at,
+    DROP COLUMN IF EXISTS status_source,
+    DROP COLUMN IF EXISTS status_updated_at;
@@ -0,0 +1,16 @@
+-- Кто и когда последний раз менял статус рейса: планировщик статусов
+-- не перезаписывает статус, выставленный вручную позже наступления этапа
+ALTER TABLE flights
+    ADD COLUMN IF NOT EXISTS status_updated_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS status_source TEXT NOT NULL DEFAULT 'manual',
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+UPDATE flights SET status_updated_at = COALESCE(updated_at, created_at, now())
+WHERE status_updated_at IS NULL;
+
+ALTER TABLE flights
+    ALTER COLUMN status_updated_at SET DEFAULT now(),
+    ALTER COLUMN status_updated_at SET NOT NULL;
+
+-- Планировщик и табло работают только с неархивными рейсами
+CREATE INDEX IF NOT EXISTS idx_flights_active ON flights(scheduled_time) WHERE archived_at IS NULL;
@@ -0,0 +1,12 @@
+ALTER TABLE flights
+    DROP COLUMN IF EXISTS delay_codes,
+    DROP COLUMN IF EXISTS arrival_delay_minutes,
+    DROP COLUMN IF EXISTS delay_minutes,
+    DROP COLUMN IF EXISTS actual_in_block,
+    DROP COLUMN IF EXISTS actual_landing,
+    DROP COLUMN IF EXISTS actual_takeoff,
+    DROP COLUMN IF EXISTS actual_off_block,
+    DROP COLUMN IF EXISTS estimated_in_block,
+    DROP COLUMN IF EXISTS estimated_landing,
+    DROP COLUMN IF EXISTS estimated_takeoff,
+    DROP COLUMN IF EXISTS estimated_off_block;
@@ -0,0 +1,22 @@
+-- Оценочные и фактические времена этапов рейса, минуты задержки
+-- и коды задержки IATA AHM 730 ([{"code":"93","alpha":"RA","minutes":25}])
+ALTER TABLE flights
+    ADD COLUMN IF NOT EXISTS estimated_off_block TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS estimated_takeoff TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS estimated_landing TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS estimated_in_block TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS actual_off_block TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS actual_takeoff TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS actual_landing TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS actual_in_block TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS delay_minutes INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS arrival_delay_minutes INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS delay_codes JSONB NOT NULL DEFAULT '[]';
+
+-- Прежнее actual_time, отличное от планового, — оценка ухода со стоянки
+UPDATE flights SET
+    estimated_off_block = actual_time,
+    delay_minutes = GREATEST(0, round(EXTRACT(EPOCH FROM actual_time - scheduled_time) / 60)),
+    arrival_delay_minutes = CASE WHEN scheduled_arrival_time IS NULL THEN 0
+        ELSE GREATEST(0, round(EXTRACT(EPOCH FROM actual_time - scheduled_time) / 60)) END
+WHERE actual_time <> scheduled_time AND estimated_off_block IS NULL;
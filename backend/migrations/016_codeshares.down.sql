@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_flights_codeshares;
+
+ALTER TABLE flights DROP COLUMN IF EXISTS codeshares;
@@ -0,0 +1,5 @@
+-- Коммерческие номера рейса (код-шеринг): [{"flightNumber":"SU 1234","airline":"SU"}]
+ALTER TABLE flights ADD COLUMN IF NOT EXISTS codeshares JSONB NOT NULL DEFAULT '[]';
+
+-- Поиск рейса по любому из коммерческих номеров (@>)
+CREATE INDEX IF NOT EXISTS idx_flights_codeshares ON flights USING GIN (codeshares jsonb_path_ops);
@@ -0,0 +1,2 @@
+-- Прежний вид номеров рейсов не восстанавливается
+SELECT 1;
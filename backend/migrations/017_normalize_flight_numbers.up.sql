@@ -0,0 +1,24 @@
+-- Номера рейсов в едином виде, как models.NormalizeFlightNumber:
+-- верхний регистр, код авиакомпании отделен пробелом (SU1234 — SU 1234)
+UPDATE flights SET flight_number = normalized.number
+FROM (
+    SELECT id, CASE
+        WHEN position(' ' IN n) > 0 OR length(n) <= 2 THEN n
+        WHEN length(n) > 3 AND substr(n, 3, 1) ~ '[A-Z]' THEN substr(n, 1, 3) || ' ' || substr(n, 4)
+        ELSE substr(n, 1, 2) || ' ' || substr(n, 3)
+    END AS number
+    FROM (SELECT id, UPPER(regexp_replace(TRIM(flight_number), '\s+', ' ', 'g')) AS n FROM flights) AS raw
+) AS normalized
+WHERE flights.id = normalized.id AND flights.flight_number <> normalized.number;
+
+-- Расписания тоже: иначе генератор вернул бы рейсам прежний номер
+UPDATE flight_schedules SET flight_number = normalized.number
+FROM (
+    SELECT id, CASE
+        WHEN position(' ' IN n) > 0 OR length(n) <= 2 THEN n
+        WHEN length(n) > 3 AND substr(n, 3, 1) ~ '[A-Z]' THEN substr(n, 1, 3) || ' ' || substr(n, 4)
+        ELSE substr(n, 1, 2) || ' ' || substr(n, 3)
+    END AS number
+    FROM (SELECT id, UPPER(regexp_replace(TRIM(flight_number), '\s+', ' ', 'g')) AS n FROM flight_schedules) AS raw
+) AS normalized
+WHERE flight_schedules.id = normalized.id AND flight_schedules.flight_number <> normalized.number;
@@ -0,0 +1,2 @@
+-- Прежние названия авиакомпаний в рейсах не восстанавливаются
+SELECT 1;
@@ -0,0 +1,5 @@
+-- Авиакомпании в рейсах — кодом IATA, а не названием: для баз,
+-- где 009 применилась до заполнения справочника
+UPDATE flights SET airline = a.iata_code
+FROM airlines a
+WHERE LOWER(flights.airline) = LOWER(a.name) AND flights.airline <> a.iata_code;
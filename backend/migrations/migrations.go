@@ -0,0 +1,8 @@
+// Package migrations содержит SQL-миграции схемы, встроенные в бинарник.
+// Файлы называются NNN_описание.up.sql / NNN_описание.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,63 @@
+// Package reference содержит встроенный справочник аэропортов, авиакомпаний
+// и типов ВС, которым заполняются пустые таблицы при запуске.
+package reference
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	"skyflow/internal/models"
+)
+
+//go:embed *.json
+var FS embed.FS
+
+type Dataset struct {
+	Airports      []models.Airport
+	Airlines      []models.Airline
+	AircraftTypes []models.AircraftType
+}
+
+// Загружает и проверяет встроенный справочник
+func Load() (*Dataset, error) {
+	var d Dataset
+	if err := load("airports.json", &d.Airports); err != nil {
+		return nil, err
+	}
+	if err := load("airlines.json", &d.Airlines); err != nil {
+		return nil, err
+	}
+	if err := load("aircraft_types.json", &d.AircraftTypes); err != nil {
+		return nil, err
+	}
+
+	for i := range d.Airports {
+		if err := d.Airports[i].Normalize(); err != nil {
+			return nil, fmt.Errorf("airports.json: %s: %w", d.Airports[i].IATACode, err)
+		}
+	}
+	for i := range d.Airlines {
+		if err := d.Airlines[i].Normalize(); err != nil {
+			return nil, fmt.Errorf("airlines.json: %s: %w", d.Airlines[i].IATACode, err)
+		}
+	}
+	for i := range d.AircraftTypes {
+		if err := d.AircraftTypes[i].Normalize(); err != nil {
+			return nil, fmt.Errorf("aircraft_types.json: %s: %w", d.AircraftTypes[i].ICAOCode, err)
+		}
+	}
+
+	return &d, nil
+}
+
+func load(name string, v interface{}) error {
+	data, err := FS.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
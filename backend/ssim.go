@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"skyflow/internal/config"
+	"skyflow/internal/database"
+	"skyflow/internal/models"
+	"skyflow/internal/ssim"
+	"skyflow/reference"
+)
+
+// Имя в журнале изменений для рейсов, измененных импортом из командной строки
+const ssimImportUsername = "ssim-import"
+
+// skyflow ssim import [-dry-run] [-mode schedules|flights] <file>
+func runSSIMCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 || args[0] != "import" {
+		fmt.Fprintln(os.Stderr, "usage: skyflow ssim import [-dry-run] [-mode schedules|flights] <file>")
+		os.Exit(2)
+	}
+
+	flags := flag.NewFlagSet("ssim import", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "только показать, что изменится")
+	mode := flags.String("mode", ssim.ModeSchedules, "schedules — только расписания, flights — и рейсы на весь сезон")
+	flags.Parse(args[1:])
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: skyflow ssim import [-dry-run] [-mode schedules|flights] <file>")
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	db, err := database.Connect(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	// Коды проверяются по тем же справочникам, что и в API
+	dataset, err := reference.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	referenceStore := database.NewReferenceRepository(db)
+	// При -dry-run в базу ничего не пишется: справочники берутся как есть
+	if !*dryRun {
+		if err := referenceStore.Seed(ctx, dataset); err != nil {
+			log.Fatal(err)
+		}
+	}
+	referenceCache := database.NewReferenceCache(referenceStore)
+	if err := referenceCache.Reload(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	schedules := database.NewScheduleRepository(db)
+	generator := database.NewScheduleGenerator(schedules, database.NewFlightRepository(db), referenceCache, cfg.ScheduleHorizonDays)
+
+	report, err := ssim.NewImporter(schedules, generator, referenceCache).Import(ctx, file, ssim.Options{
+		Mode: *mode, DryRun: *dryRun, RemoveFlights: true,
+		Author: &models.FlightAuthor{Username: ssimImportUsername},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, entry := range report.Entries {
+		line := fmt.Sprintf("%5d  %-9s %-8s %s-%s %s %s..%s", entry.Line, entry.Action, entry.Schedule.FlightNumber,
+			entry.Schedule.From, entry.Schedule.To, entry.Schedule.DaysOfWeek, entry.Schedule.ValidFrom, entry.Schedule.ValidTo)
+		if entry.Flights != nil {
+			line += fmt.Sprintf("  рейсов: +%d ~%d -%d", entry.Flights.Created, entry.Flights.Updated, entry.Flights.Removed)
+		}
+		fmt.Println(line)
+	}
+	for _, lineErr := range report.Errors {
+		fmt.Fprintln(os.Stderr, lineErr.Error())
+	}
+
+	prefix := ""
+	if report.DryRun {
+		prefix = "(dry run) "
+	}
+	log.Printf("%sУчастков: %d, создано: %d, изменено: %d, без изменений: %d, ошибок: %d",
+		prefix, report.Legs, report.Created, report.Updated, report.Unchanged, len(report.Errors))
+
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}